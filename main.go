@@ -1,14 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/sachin-duhan/gomock/pkg/certs"
 	"github.com/sachin-duhan/gomock/pkg/config"
+	"github.com/sachin-duhan/gomock/pkg/grpcmock"
+	"github.com/sachin-duhan/gomock/pkg/importer"
+	"github.com/sachin-duhan/gomock/pkg/logging"
 	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/openapi"
 	"github.com/sachin-duhan/gomock/pkg/server"
+	"github.com/sachin-duhan/gomock/pkg/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
 )
 
 func main() {
+	// Importing captures does not need a loaded mock set
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -21,13 +40,277 @@ func main() {
 		log.Fatalf("Failed to load mock responses: %v", err)
 	}
 
+	// Load the OpenAPI document the mocks are bound to, if any
+	var spec *openapi.Spec
+	if cfg.OpenAPISpecPath != "" {
+		spec, err = openapi.Load(cfg.OpenAPISpecPath)
+		if err != nil {
+			log.Fatalf("Failed to load OpenAPI spec: %v", err)
+		}
+	}
+
+	// In validate mode, check every mock response against the spec and exit
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(spec, mockResponses, os.Args[2:]))
+	}
+
+	logger, err := logging.New(logging.Options{
+		Level:      cfg.Logging.Level,
+		Format:     cfg.Logging.Format,
+		Outputs:    cfg.Logging.Outputs,
+		File:       cfg.Logging.File,
+		MaxSizeMB:  cfg.Logging.MaxSizeMB,
+		MaxBackups: cfg.Logging.MaxBackups,
+		MaxAgeDays: cfg.Logging.MaxAgeDays,
+		Compress:   cfg.Logging.Compress,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	redaction := logging.BodyOptions{
+		MaxBytes:      cfg.Logging.BodyMaxBytes,
+		RedactHeaders: cfg.Logging.RedactHeaders,
+		RedactFields:  cfg.Logging.RedactFields,
+	}
+	opts := []server.Option{
+		server.WithLogger(logger),
+		server.WithPrefix(cfg.ReservedPrefix),
+		server.WithRedaction(redaction),
+	}
+	if cfg.Logging.Bodies {
+		opts = append(opts, server.WithBodyLogging(redaction))
+	}
+	if cfg.MaxBodyBytes > 0 {
+		opts = append(opts, server.WithMaxBodySize(int64(cfg.MaxBodyBytes)))
+	}
+	if cfg.FakeSeed != nil {
+		opts = append(opts, server.WithFakeSeed(*cfg.FakeSeed))
+	}
+	if cfg.TLS.Port != "" {
+		tlsConfig, result, err := certs.ServerConfig(certs.Options{
+			CertFile:     cfg.TLS.CertFile,
+			KeyFile:      cfg.TLS.KeyFile,
+			Dir:          cfg.TLS.CertDir,
+			Hosts:        cfg.TLS.Hosts,
+			ClientAuth:   cfg.TLS.ClientAuth,
+			ClientCAFile: cfg.TLS.ClientCAFile,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+		if result.Generated {
+			log.Printf("Generated TLS certificate %s; trust the CA at %s", result.CertFile, result.CAFile)
+		}
+		if result.ClientCertFile != "" {
+			log.Printf("Generated client certificate %s (key %s) for mutual TLS", result.ClientCertFile, result.ClientKeyFile)
+		}
+		opts = append(opts, server.WithTLS(cfg.TLS.Port, tlsConfig))
+	}
+	if cfg.DisableHTTP {
+		opts = append(opts, server.WithoutHTTP())
+	}
+	var registry *grpcmock.Registry
+	if cfg.GRPC.Port != "" {
+		registry, err = grpcmock.Load(cfg.GRPC.ProtoFiles, cfg.GRPC.ImportPaths)
+		if err != nil {
+			log.Fatalf("Failed to load proto files: %v", err)
+		}
+		if err := registry.Validate(mockResponses); err != nil {
+			log.Fatalf("Invalid gRPC mock: %v", err)
+		}
+		opts = append(opts, server.WithGRPC(cfg.GRPC.Port, registry))
+	}
+	if spec != nil {
+		for _, v := range spec.ValidateMocks(mockResponses) {
+			if v.Warning {
+				log.Printf("Warning: mock is not documented in the OpenAPI spec: %v", v)
+				continue
+			}
+			log.Printf("Warning: mock response does not match OpenAPI spec: %v", v)
+		}
+		opts = append(opts, server.WithOpenAPI(spec))
+	}
+	if cfg.MetricsPath != "" {
+		opts = append(opts, server.WithMetrics(cfg.MetricsPath))
+	}
+
+	// The admin API can reload the mocks folder without a restart
+	opts = append(opts, server.WithReload(func() (map[string]mock.Response, error) {
+		responses, err := mock.LoadResponses(cfg.JSONFolderPath)
+		if err != nil {
+			return nil, err
+		}
+		if registry != nil {
+			if err := registry.Validate(responses); err != nil {
+				return nil, err
+			}
+		}
+		return responses, nil
+	}))
+
+	var provider *sdktrace.TracerProvider
+	if cfg.Tracing.Endpoint != "" {
+		provider, err = tracing.NewProvider(context.Background(), tracing.Options{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Protocol:    cfg.Tracing.Protocol,
+			ServiceName: cfg.Tracing.ServiceName,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure tracing: %v", err)
+		}
+		opts = append(opts, server.WithTracing(provider))
+	}
+
 	// Create and start the server
-	srv, err := server.New(mockResponses, cfg.Port)
+	srv, err := server.New(mockResponses, cfg.Port, opts...)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
-	if err := srv.Start(); err != nil {
-		log.Fatalf("Server error: %v", err)
+	if cfg.StateFile != "" {
+		if err := srv.LoadState(cfg.StateFile); err != nil {
+			log.Fatalf("Failed to restore state: %v", err)
+		}
+	}
+
+	code := serve(srv, cfg, logger)
+	if provider != nil {
+		// Flush the spans still buffered
+		if err := provider.Shutdown(context.Background()); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+	}
+	logger.Sync()
+	os.Exit(code)
+}
+
+// Exit codes of the server
+const (
+	exitOK    = 0
+	exitError = 1
+
+	// exitForced means connections were still open when the shutdown
+	// timeout passed and were closed
+	exitForced = 3
+)
+
+// serve runs the server until it fails or receives SIGINT or SIGTERM, drains
+// it within the shutdown timeout, saves its state if configured and returns
+// the process exit code
+func serve(srv *server.Server, cfg *config.Config, logger *zap.Logger) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Start()
+	}()
+
+	code := exitOK
+	select {
+	case err := <-errCh:
+		logger.Error("Server error", zap.Error(err))
+		code = exitError
+	case <-ctx.Done():
+		// A second signal kills the process without waiting for the drain
+		stop()
+		logger.Info("Shutting down", zap.Duration("timeout", cfg.ShutdownTimeout))
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Stop(shutdownCtx); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				logger.Warn("Shutdown timeout passed, closed the remaining connections", zap.Duration("timeout", cfg.ShutdownTimeout))
+				code = exitForced
+			} else {
+				logger.Error("Failed to shut down", zap.Error(err))
+				code = exitError
+			}
+		} else {
+			logger.Info("Server stopped")
+		}
+	}
+
+	if cfg.StateFile != "" {
+		if err := srv.SaveState(cfg.StateFile); err != nil {
+			logger.Error("Failed to save state", zap.Error(err))
+			if code == exitOK {
+				code = exitError
+			}
+		}
+	}
+	return code
+}
+
+// runValidate reports mock responses that drift from the OpenAPI document
+// and returns the process exit code. Mocks of undocumented operations are
+// warnings unless --strict is given.
+func runValidate(spec *openapi.Spec, mockResponses map[string]mock.Response, args []string) int {
+	strict := len(args) == 1 && args[0] == "--strict"
+	if len(args) > 0 && !strict {
+		fmt.Fprintln(os.Stderr, "usage: gomock validate [--strict]")
+		return 2
+	}
+	if spec == nil {
+		fmt.Fprintln(os.Stderr, "validate mode requires OPENAPI_SPEC_PATH to be set")
+		return 2
+	}
+
+	var failures, warnings int
+	for _, v := range spec.ValidateMocks(mockResponses) {
+		if v.Warning && !strict {
+			warnings++
+			fmt.Printf("warning: %s\n", v.Error())
+			continue
+		}
+		failures++
+		fmt.Println(v.Error())
+	}
+	if failures > 0 {
+		fmt.Printf("%d violation(s) found in %d endpoint(s)\n", failures, len(mockResponses))
+		return 1
+	}
+	if warnings > 0 {
+		fmt.Printf("Documented mock responses match the OpenAPI spec (%d endpoints, %d undocumented)\n", len(mockResponses), warnings)
+		return 0
+	}
+	fmt.Printf("All mock responses match the OpenAPI spec (%d endpoints)\n", len(mockResponses))
+	return 0
+}
+
+// runImport converts a HAR capture or Postman collection into mock files
+// and returns the process exit code
+func runImport(args []string) int {
+	if len(args) != 3 || (args[0] != "har" && args[0] != "postman") {
+		fmt.Fprintln(os.Stderr, "usage: gomock import <har|postman> <input file> <output folder>")
+		return 2
+	}
+
+	var result *importer.Result
+	var err error
+	if args[0] == "har" {
+		result, err = importer.LoadHAR(args[1])
+	} else {
+		result, err = importer.LoadPostman(args[1])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to import %s: %v\n", args[1], err)
+		return 1
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	written, err := importer.WriteMocks(args[2], result.Responses)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write mock files: %v\n", err)
+		return 1
+	}
+	for _, name := range written {
+		fmt.Println(name)
 	}
+	fmt.Printf("Imported %d endpoint(s) into %s\n", len(written), args[2])
+	return 0
 }
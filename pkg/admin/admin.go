@@ -0,0 +1,154 @@
+// Package admin defines the admin API of the mock server and a client for it.
+// The server encodes the same types the client decodes.
+package admin
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+// DefaultPrefix starts every path the server reserves for itself. A server
+// configured with another prefix serves the same routes under it; see
+// Reserved.
+const DefaultPrefix = "/__"
+
+// Probe and info routes
+const (
+	HealthPath = "/__health"
+	ReadyPath  = "/__ready"
+	InfoPath   = "/__info"
+
+	// DashboardPath serves the web dashboard
+	DashboardPath = "/__dashboard/"
+)
+
+// Admin API routes
+const (
+	ResetPath         = "/__admin/reset"
+	RequestsPath      = "/__admin/requests"
+	RequestCountPath  = "/__admin/requests/count"
+	RequestStreamPath = "/__admin/requests/stream"
+	MocksPath         = "/__admin/mocks"
+	ScenariosPath     = "/__admin/scenarios"
+	ReloadPath        = "/__admin/reload"
+	ForcedPath        = "/__admin/forced"
+)
+
+// Reserved returns a reserved route, such as HealthPath, under prefix instead
+// of DefaultPrefix. The prefix replaces "/__" as is, so "/_mock/" serves
+// HealthPath on /_mock/health. An empty prefix keeps the default.
+func Reserved(prefix, path string) string {
+	if prefix == "" || !strings.HasPrefix(path, DefaultPrefix) {
+		return path
+	}
+	return prefix + strings.TrimPrefix(path, DefaultPrefix)
+}
+
+// HealthResponse is returned while the server is alive
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadyResponse reports whether the server is ready to serve its mocks. Error
+// explains why it is not.
+type ReadyResponse struct {
+	Status    string `json:"status"`
+	Ready     bool   `json:"ready"`
+	Endpoints int    `json:"endpoints"`
+	Error     string `json:"error,omitempty"`
+}
+
+// InfoResponse describes the running server
+type InfoResponse struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+
+	// Files is the number of mocks in the last successful load of the mocks
+	// folder; Endpoints also counts mocks added through the admin API or in
+	// code
+	Files     int `json:"files"`
+	Endpoints int `json:"endpoints"`
+
+	StartedAt time.Time     `json:"started_at"`
+	Uptime    string        `json:"uptime"`
+	Config    ConfigSummary `json:"config"`
+}
+
+// ConfigSummary lists the features a server runs with. It holds no
+// certificates, keys or other secrets.
+type ConfigSummary struct {
+	Port           string `json:"port,omitempty"`
+	TLSPort        string `json:"tls_port,omitempty"`
+	GRPCPort       string `json:"grpc_port,omitempty"`
+	ReservedPrefix string `json:"reserved_prefix"`
+	MetricsPath    string `json:"metrics_path,omitempty"`
+	OpenAPI        bool   `json:"openapi"`
+	Reload         bool   `json:"reload"`
+	Tracing        bool   `json:"tracing"`
+	BodyLogging    bool   `json:"body_logging"`
+}
+
+// ResetResponse is returned when resources are reset to their seed data
+type ResetResponse struct {
+	Status    string   `json:"status"`
+	Resources []string `json:"resources"`
+}
+
+// RequestsResponse holds the request journal
+type RequestsResponse struct {
+	Status   string          `json:"status"`
+	Requests []journal.Entry `json:"requests"`
+}
+
+// CountResponse holds the number of journaled requests matching a method and
+// path
+type CountResponse struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// MocksResponse holds the mocks served, keyed by path
+type MocksResponse struct {
+	Status string                   `json:"status"`
+	Mocks  map[string]mock.Response `json:"mocks"`
+}
+
+// MockResponse is returned when a mock is added or removed
+type MockResponse struct {
+	Status string `json:"status"`
+	Path   string `json:"path"`
+}
+
+// ScenariosResponse holds the current state of every scenario
+type ScenariosResponse struct {
+	Status    string            `json:"status"`
+	Scenarios map[string]string `json:"scenarios"`
+}
+
+// ScenarioStateRequest moves a scenario to a new state
+type ScenarioStateRequest struct {
+	State string `json:"state"`
+}
+
+// ForcedResponses holds the responses forced per endpoint, as indexes into
+// the endpoint's responses
+type ForcedResponses struct {
+	Status string         `json:"status"`
+	Forced map[string]int `json:"forced"`
+}
+
+// ForceRequest makes an endpoint answer every request with one of its
+// responses, chosen by its index, until cleared
+type ForceRequest struct {
+	Path  string `json:"path"`
+	Index int    `json:"index"`
+}
+
+// ReloadResponse is returned when the mocks are reloaded
+type ReloadResponse struct {
+	Status    string `json:"status"`
+	Endpoints int    `json:"endpoints"`
+}
@@ -0,0 +1,308 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+// DefaultTimeout bounds every call made by a client unless WithTimeout is
+// given
+const DefaultTimeout = 10 * time.Second
+
+// Client calls the admin API of a running mock server
+//
+//	client := admin.NewClient("http://localhost:8080")
+//	err := client.AddStubs(ctx, mock.On("GET", "/users/1").Reply(200).JSON(user))
+//	...
+//	err = client.VerifyCount(ctx, "GET", "/users/1", 1)
+type Client struct {
+	baseURL    string
+	prefix     string
+	httpClient *http.Client
+	timeout    time.Duration
+}
+
+// ClientOption configures a client
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used for calls, such as one trusting
+// the server's self-signed certificate
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout bounds every call. The deadline of the context passed to a
+// call still applies when it is earlier; zero disables the bound.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithPrefix calls a server whose reserved routes are under prefix instead
+// of DefaultPrefix
+func WithPrefix(prefix string) ClientOption {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// NewClient creates a client for the server at baseURL, such as
+// http://localhost:8080
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		timeout:    DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is returned when the server rejects an admin call
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("admin API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Mocks returns the mocks served, keyed by path
+func (c *Client) Mocks(ctx context.Context) (map[string]mock.Response, error) {
+	var resp MocksResponse
+	if err := c.do(ctx, http.MethodGet, MocksPath, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Mocks, nil
+}
+
+// AddMock adds a mock, replacing any mock of the same path. The mock must set
+// its path and is validated as if loaded from a file.
+func (c *Client) AddMock(ctx context.Context, m mock.Response) error {
+	return c.do(ctx, http.MethodPost, MocksPath, nil, m, &MockResponse{})
+}
+
+// AddStubs adds mocks built with mock.On
+func (c *Client) AddStubs(ctx context.Context, stubs ...*mock.ReplyBuilder) error {
+	responses, err := mock.Build(stubs...)
+	if err != nil {
+		return err
+	}
+	for path, m := range responses {
+		m.Path = path
+		if err := c.AddMock(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveMock removes the mock of a path
+func (c *Client) RemoveMock(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodDelete, MocksPath, url.Values{"path": {path}}, nil, &MockResponse{})
+}
+
+// Forced returns the responses forced per endpoint, as indexes into the
+// endpoint's responses
+func (c *Client) Forced(ctx context.Context) (map[string]int, error) {
+	var resp ForcedResponses
+	if err := c.do(ctx, http.MethodGet, ForcedPath, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Forced, nil
+}
+
+// ForceResponse makes the endpoint answer every request with its response at
+// index, ignoring input body and header matching, until cleared. The
+// x-stub-status header still selects a response by status.
+func (c *Client) ForceResponse(ctx context.Context, path string, index int) error {
+	return c.do(ctx, http.MethodPut, ForcedPath, nil, ForceRequest{Path: path, Index: index}, &ForcedResponses{})
+}
+
+// ClearForced goes back to matching requests for the endpoint. An empty path
+// clears every forced response.
+func (c *Client) ClearForced(ctx context.Context, path string) error {
+	var query url.Values
+	if path != "" {
+		query = url.Values{"path": {path}}
+	}
+	return c.do(ctx, http.MethodDelete, ForcedPath, query, nil, &ForcedResponses{})
+}
+
+// Reload replaces the mocks with a fresh load of the mocks folder and returns
+// the number of endpoints loaded. The server must have been started with
+// reloading enabled.
+func (c *Client) Reload(ctx context.Context) (int, error) {
+	var resp ReloadResponse
+	if err := c.do(ctx, http.MethodPost, ReloadPath, nil, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Endpoints, nil
+}
+
+// Reset restores resources to their seed data and returns the paths of the
+// resources reset. An empty path resets every resource, every scenario and
+// the request journal.
+func (c *Client) Reset(ctx context.Context, path string) ([]string, error) {
+	var query url.Values
+	if path != "" {
+		query = url.Values{"path": {path}}
+	}
+	var resp ResetResponse
+	if err := c.do(ctx, http.MethodPost, ResetPath, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
+
+// Requests returns the request journal, oldest first
+func (c *Client) Requests(ctx context.Context) ([]journal.Entry, error) {
+	var resp RequestsResponse
+	if err := c.do(ctx, http.MethodGet, RequestsPath, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Requests, nil
+}
+
+// ClearRequests empties the request journal
+func (c *Client) ClearRequests(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, RequestsPath, nil, nil, &RequestsResponse{})
+}
+
+// CountRequests returns the number of journaled requests for the method and
+// path. An empty method or path matches any value.
+func (c *Client) CountRequests(ctx context.Context, method, path string) (int, error) {
+	query := url.Values{}
+	if method != "" {
+		query.Set("method", method)
+	}
+	if path != "" {
+		query.Set("path", path)
+	}
+	var resp CountResponse
+	if err := c.do(ctx, http.MethodGet, RequestCountPath, query, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// VerifyCount returns an error unless the endpoint received exactly want
+// requests
+func (c *Client) VerifyCount(ctx context.Context, method, path string, want int) error {
+	count, err := c.CountRequests(ctx, method, path)
+	if err != nil {
+		return err
+	}
+	if count != want {
+		return fmt.Errorf("expected %s %s to be called %d time(s), got %d", strings.ToUpper(method), path, want, count)
+	}
+	return nil
+}
+
+// Scenarios returns the current state of every scenario
+func (c *Client) Scenarios(ctx context.Context) (map[string]string, error) {
+	var resp ScenariosResponse
+	if err := c.do(ctx, http.MethodGet, ScenariosPath, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Scenarios, nil
+}
+
+// SetScenarioState moves a scenario to a new state
+func (c *Client) SetScenarioState(ctx context.Context, name, state string) error {
+	return c.do(ctx, http.MethodPut, ScenariosPath+"/"+url.PathEscape(name), nil,
+		ScenarioStateRequest{State: state}, &ScenariosResponse{})
+}
+
+// ResetScenarios moves every scenario back to mock.ScenarioStarted
+func (c *Client) ResetScenarios(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, ScenariosPath, nil, nil, &ScenariosResponse{})
+}
+
+// Ready returns nil once the server has loaded its mocks, and an *Error
+// explaining why it is not ready otherwise
+func (c *Client) Ready(ctx context.Context) error {
+	err := c.do(ctx, http.MethodGet, ReadyPath, nil, nil, &ReadyResponse{})
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		var resp ReadyResponse
+		if json.Unmarshal([]byte(apiErr.Message), &resp) == nil && resp.Error != "" {
+			apiErr.Message = resp.Error
+		}
+	}
+	return err
+}
+
+// Info describes the running server
+func (c *Client) Info(ctx context.Context) (*InfoResponse, error) {
+	var resp InfoResponse
+	if err := c.do(ctx, http.MethodGet, InfoPath, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do sends a call with an optional JSON body and decodes the JSON response
+// into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	target := c.baseURL + Reserved(c.prefix, path)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/admin"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *admin.Client) {
+	t.Helper()
+	srv, err := server.New(map[string]mock.Response{}, "", server.WithLogger(zaptest.NewLogger(t, zaptest.Level(zap.WarnLevel))))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	httpServer := httptest.NewServer(srv.Handler())
+	t.Cleanup(httpServer.Close)
+	return httpServer, admin.NewClient(httpServer.URL, admin.WithHTTPClient(httpServer.Client()))
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestClientMocks(t *testing.T) {
+	httpServer, client := newTestServer(t)
+	ctx := context.Background()
+
+	err := client.AddStubs(ctx, mock.On("GET", "/greeting").Reply(200).Text("hello"))
+	if err != nil {
+		t.Fatalf("AddStubs failed: %v", err)
+	}
+	mocks, err := client.Mocks(ctx)
+	if err != nil {
+		t.Fatalf("Mocks failed: %v", err)
+	}
+	if _, ok := mocks["/greeting"]; !ok {
+		t.Errorf("expected /greeting in mocks, got %v", mocks)
+	}
+
+	if status, body := get(t, httpServer.URL+"/greeting"); status != http.StatusOK || body != "hello" {
+		t.Errorf("expected 200 hello, got %d %q", status, body)
+	}
+	if err := client.VerifyCount(ctx, "GET", "/greeting", 1); err != nil {
+		t.Errorf("VerifyCount failed: %v", err)
+	}
+	if err := client.VerifyCount(ctx, "GET", "/greeting", 2); err == nil {
+		t.Error("expected VerifyCount to fail for a wrong count")
+	}
+
+	requests, err := client.Requests(ctx)
+	if err != nil || len(requests) != 1 {
+		t.Fatalf("expected 1 journaled request, got %d (%v)", len(requests), err)
+	}
+	if err := client.ClearRequests(ctx); err != nil {
+		t.Fatalf("ClearRequests failed: %v", err)
+	}
+	if count, _ := client.CountRequests(ctx, "", ""); count != 0 {
+		t.Errorf("expected an empty journal, got %d requests", count)
+	}
+
+	if err := client.RemoveMock(ctx, "/greeting"); err != nil {
+		t.Fatalf("RemoveMock failed: %v", err)
+	}
+	if status, _ := get(t, httpServer.URL+"/greeting"); status != http.StatusNotFound {
+		t.Errorf("expected 404 after removal, got %d", status)
+	}
+
+	var apiErr *admin.Error
+	if err := client.RemoveMock(ctx, "/greeting"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 admin error, got %v", err)
+	}
+	if err := client.AddMock(ctx, mock.Response{Method: "GET", Path: "no-slash"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a 400 admin error, got %v", err)
+	}
+
+	// Posted mocks cannot read files from the server's host
+	books := mock.Response{Method: "GET", Path: "/books", Type: mock.TypeResource, Resource: &mock.ResourceConfig{SeedFile: "/etc/passwd"}}
+	if err := client.AddMock(ctx, books); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a 400 admin error for a file reference, got %v", err)
+	}
+}
+
+func TestClientScenarios(t *testing.T) {
+	httpServer, client := newTestServer(t)
+	ctx := context.Background()
+
+	err := client.AddStubs(ctx,
+		mock.On("GET", "/order").InScenario("checkout", "paid").Reply(200).Text("paid"),
+		mock.On("GET", "/order").InScenario("checkout", mock.ScenarioStarted).Reply(200).Text("pending").SetState("checkout", "paid"),
+	)
+	if err != nil {
+		t.Fatalf("AddStubs failed: %v", err)
+	}
+
+	for _, want := range []string{"pending", "paid", "paid"} {
+		if _, body := get(t, httpServer.URL+"/order"); body != want {
+			t.Errorf("expected %q, got %q", want, body)
+		}
+	}
+
+	states, err := client.Scenarios(ctx)
+	if err != nil || states["checkout"] != "paid" {
+		t.Errorf("expected checkout to be paid, got %v (%v)", states, err)
+	}
+	if err := client.ResetScenarios(ctx); err != nil {
+		t.Fatalf("ResetScenarios failed: %v", err)
+	}
+	if _, body := get(t, httpServer.URL+"/order"); body != "pending" {
+		t.Errorf("expected pending after reset, got %q", body)
+	}
+
+	if err := client.SetScenarioState(ctx, "checkout", "paid"); err != nil {
+		t.Fatalf("SetScenarioState failed: %v", err)
+	}
+	if _, body := get(t, httpServer.URL+"/order"); body != "paid" {
+		t.Errorf("expected paid after setting the state, got %q", body)
+	}
+
+	// A full reset also resets scenarios and the journal
+	if _, err := client.Reset(ctx, ""); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if count, _ := client.CountRequests(ctx, "", ""); count != 0 {
+		t.Errorf("expected an empty journal after reset, got %d requests", count)
+	}
+	if _, body := get(t, httpServer.URL+"/order"); body != "pending" {
+		t.Errorf("expected pending after a full reset, got %q", body)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+
+	client := admin.NewClient(slow.URL, admin.WithTimeout(50*time.Millisecond))
+	if _, err := client.Mocks(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
+
+func TestClientProbes(t *testing.T) {
+	srv, err := server.New(map[string]mock.Response{}, "", server.WithPrefix("/_mock/"), server.WithLogger(zaptest.NewLogger(t, zaptest.Level(zap.WarnLevel))))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	httpServer := httptest.NewServer(srv.Handler())
+	defer httpServer.Close()
+	client := admin.NewClient(httpServer.URL, admin.WithPrefix("/_mock/"))
+	ctx := context.Background()
+
+	if err := client.Ready(ctx); err != nil {
+		t.Errorf("expected ready, got %v", err)
+	}
+	info, err := client.Info(ctx)
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.Config.ReservedPrefix != "/_mock/" {
+		t.Errorf("expected prefix /_mock/, got %q", info.Config.ReservedPrefix)
+	}
+
+	srv.Stop(ctx)
+	var apiErr *admin.Error
+	if err := client.Ready(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "shutting down" {
+		t.Errorf("expected 503 shutting down, got %v", err)
+	}
+}
+
+func TestClientForced(t *testing.T) {
+	httpServer, client := newTestServer(t)
+	ctx := context.Background()
+
+	err := client.AddStubs(ctx,
+		mock.On("GET", "/greeting").Reply(200).Text("hello"),
+		mock.On("GET", "/greeting").Reply(503).Text("down"),
+	)
+	if err != nil {
+		t.Fatalf("AddStubs failed: %v", err)
+	}
+	if err := client.ForceResponse(ctx, "/greeting", 1); err != nil {
+		t.Fatalf("ForceResponse failed: %v", err)
+	}
+	if forced, err := client.Forced(ctx); err != nil || forced["/greeting"] != 1 {
+		t.Errorf("expected /greeting forced to 1, got %v %v", forced, err)
+	}
+	if status, body := get(t, httpServer.URL+"/greeting"); status != http.StatusServiceUnavailable || body != "down" {
+		t.Errorf("expected the forced 503, got %d %q", status, body)
+	}
+
+	if err := client.ClearForced(ctx, ""); err != nil {
+		t.Fatalf("ClearForced failed: %v", err)
+	}
+	if status, _ := get(t, httpServer.URL+"/greeting"); status != http.StatusOK {
+		t.Errorf("expected 200 after clearing, got %d", status)
+	}
+}
@@ -0,0 +1,308 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File names used for generated certificates
+const (
+	CAFile        = "ca.pem"
+	CAKeyFile     = "ca-key.pem"
+	ServerFile    = "server.pem"
+	ServerKeyFile = "server-key.pem"
+	ClientFile    = "client.pem"
+	ClientKeyFile = "client-key.pem"
+)
+
+// Client authentication modes
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthVerify  = "verify"
+	ClientAuthRequire = "require"
+)
+
+// Options configures the TLS setup of the server
+type Options struct {
+	// CertFile and KeyFile are a user-provided certificate and key. When they
+	// are empty a local CA and a leaf certificate are generated in Dir.
+	CertFile string
+	KeyFile  string
+
+	// Dir is where generated certificates are written
+	Dir string
+
+	// Hosts are the DNS names and IP addresses of the generated leaf certificate
+	Hosts []string
+
+	// ClientAuth enables mutual TLS: none, request, verify (verify if given)
+	// or require
+	ClientAuth string
+
+	// ClientCAFile holds the CAs trusted for client certificates. It defaults
+	// to the generated CA.
+	ClientCAFile string
+}
+
+// Result describes the files used or written while building the TLS config
+type Result struct {
+	CertFile       string
+	KeyFile        string
+	CAFile         string
+	ClientCertFile string
+	ClientKeyFile  string
+	Generated      bool
+}
+
+// DefaultHosts are used for generated certificates when no hosts are given
+var DefaultHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// ServerConfig builds the server TLS config from the options, generating a
+// local CA and leaf certificate when no certificate is provided
+func ServerConfig(opts Options) (*tls.Config, *Result, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, nil, fmt.Errorf("both a certificate and a key file must be provided")
+	}
+
+	clientAuth, err := parseClientAuth(opts.ClientAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &Result{}
+	var ca *Authority
+	var cert tls.Certificate
+	if opts.CertFile != "" {
+		cert, err = tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load certificate: %v", err)
+		}
+		result.CertFile, result.KeyFile = opts.CertFile, opts.KeyFile
+	} else {
+		ca, err = LoadOrCreateCA(opts.Dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		hosts := opts.Hosts
+		if len(hosts) == 0 {
+			hosts = DefaultHosts
+		}
+		cert, err = ca.IssueServer(hosts)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.CertFile = filepath.Join(opts.Dir, ServerFile)
+		result.KeyFile = filepath.Join(opts.Dir, ServerKeyFile)
+		if err := writeKeyPair(cert, result.CertFile, result.KeyFile); err != nil {
+			return nil, nil, err
+		}
+		result.CAFile = filepath.Join(opts.Dir, CAFile)
+		result.Generated = true
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   clientAuth,
+	}
+
+	if clientAuth == tls.NoClientCert {
+		return config, result, nil
+	}
+
+	pool := x509.NewCertPool()
+	switch {
+	case opts.ClientCAFile != "":
+		content, err := ioutil.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, nil, fmt.Errorf("no certificates found in client CA file %s", opts.ClientCAFile)
+		}
+	case ca != nil:
+		// Trust the generated CA and hand out a client certificate signed by it
+		pool.AddCert(ca.Certificate)
+		clientCert, err := ca.IssueClient("gomock-client")
+		if err != nil {
+			return nil, nil, err
+		}
+		result.ClientCertFile = filepath.Join(opts.Dir, ClientFile)
+		result.ClientKeyFile = filepath.Join(opts.Dir, ClientKeyFile)
+		if err := writeKeyPair(clientCert, result.ClientCertFile, result.ClientKeyFile); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("client authentication with a provided certificate requires a client CA file")
+	}
+	config.ClientCAs = pool
+	return config, result, nil
+}
+
+func parseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(mode) {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthVerify:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("unknown client auth mode %q, expected none, request, verify or require", mode)
+}
+
+// Authority is a local certificate authority used to issue certificates
+type Authority struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+// LoadOrCreateCA loads the CA from dir, creating and writing a new one when
+// none exists yet. Reusing the CA keeps clients that already trust it working
+// across restarts.
+func LoadOrCreateCA(dir string) (*Authority, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create certificate directory: %v", err)
+	}
+
+	certPath := filepath.Join(dir, CAFile)
+	keyPath := filepath.Join(dir, CAKeyFile)
+	if pair, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CA certificate: %v", err)
+		}
+		signer, ok := pair.PrivateKey.(crypto.Signer)
+		if !ok || !cert.IsCA {
+			return nil, fmt.Errorf("%s is not a usable CA certificate", certPath)
+		}
+		return &Authority{Certificate: cert, Key: signer}, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "gomock local CA", Organization: []string{"gomock"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &Authority{Certificate: cert, Key: key}
+	pair := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	if err := writeKeyPair(pair, certPath, keyPath); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// IssueServer issues a leaf certificate valid for the given hosts
+func (ca *Authority) IssueServer(hosts []string) (tls.Certificate, error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0], Organization: []string{"gomock"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	return ca.issue(template)
+}
+
+// IssueClient issues a client certificate for mutual TLS
+func (ca *Authority) IssueClient(commonName string) (tls.Certificate, error) {
+	return ca.issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName, Organization: []string{"gomock"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+}
+
+func (ca *Authority) issue(template *x509.Certificate) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	template.SerialNumber = randomSerial()
+	template.NotBefore = time.Now().Add(-time.Hour)
+	// Stay below the 398 day limit enforced by some clients
+	template.NotAfter = time.Now().AddDate(0, 0, 397)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, &key.PublicKey, ca.Key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{der, ca.Certificate.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// writeKeyPair writes the certificate chain and private key as PEM files
+func writeKeyPair(cert tls.Certificate, certPath, keyPath string) error {
+	var certPEM []byte
+	for _, der := range cert.Certificate {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	if err := ioutil.WriteFile(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", certPath, err)
+	}
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", keyPath, err)
+	}
+	return nil
+}
+
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
@@ -0,0 +1,139 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gomock-certs")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func caPool(t *testing.T, path string) *x509.CertPool {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		t.Fatal("Failed to parse CA")
+	}
+	return pool
+}
+
+func TestServerConfigGenerated(t *testing.T) {
+	dir := tempDir(t)
+
+	config, result, err := ServerConfig(Options{Dir: dir, Hosts: []string{"mock.local", "127.0.0.1"}})
+	if err != nil {
+		t.Fatalf("ServerConfig failed: %v", err)
+	}
+	if !result.Generated || result.CAFile != filepath.Join(dir, CAFile) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	for _, name := range []string{CAFile, CAKeyFile, ServerFile, ServerKeyFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+
+	// The leaf chains to the written CA for every host
+	leaf := config.Certificates[0].Leaf
+	for _, host := range []string{"mock.local", "127.0.0.1"} {
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: caPool(t, result.CAFile)}); err != nil {
+			t.Errorf("Expected certificate to be valid for %s: %v", host, err)
+		}
+	}
+
+	// The CA is reused on the next start
+	caBefore, _ := ioutil.ReadFile(result.CAFile)
+	if _, _, err := ServerConfig(Options{Dir: dir}); err != nil {
+		t.Fatalf("ServerConfig failed: %v", err)
+	}
+	caAfter, _ := ioutil.ReadFile(result.CAFile)
+	if string(caBefore) != string(caAfter) {
+		t.Error("Expected the existing CA to be reused")
+	}
+}
+
+func TestServerConfigMutualTLS(t *testing.T) {
+	dir := tempDir(t)
+
+	config, result, err := ServerConfig(Options{Dir: dir, ClientAuth: ClientAuthRequire})
+	if err != nil {
+		t.Fatalf("ServerConfig failed: %v", err)
+	}
+	if config.ClientAuth != tls.RequireAndVerifyClientCert || result.ClientCertFile == "" {
+		t.Fatalf("Expected mutual TLS with a generated client certificate, got %+v", result)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = config
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := caPool(t, result.CAFile)
+
+	// Without a client certificate the handshake fails
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if resp, err := client.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("Expected request without client certificate to fail")
+	}
+
+	clientCert, err := tls.LoadX509KeyPair(result.ClientCertFile, result.ClientKeyFile)
+	if err != nil {
+		t.Fatalf("Failed to load client certificate: %v", err)
+	}
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}}}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected request with client certificate to succeed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "gomock-client" {
+		t.Errorf("Expected client certificate to reach the handler, got %q", body)
+	}
+}
+
+func TestServerConfigProvided(t *testing.T) {
+	dir := tempDir(t)
+	_, generated, err := ServerConfig(Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("ServerConfig failed: %v", err)
+	}
+
+	_, result, err := ServerConfig(Options{CertFile: generated.CertFile, KeyFile: generated.KeyFile})
+	if err != nil {
+		t.Fatalf("ServerConfig with provided certificate failed: %v", err)
+	}
+	if result.Generated {
+		t.Error("Expected provided certificate to be used")
+	}
+
+	invalid := []Options{
+		{CertFile: generated.CertFile},
+		{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: generated.KeyFile},
+		{Dir: dir, ClientAuth: "sometimes"},
+		{CertFile: generated.CertFile, KeyFile: generated.KeyFile, ClientAuth: ClientAuthRequire},
+	}
+	for _, opts := range invalid {
+		if _, _, err := ServerConfig(opts); err == nil {
+			t.Errorf("Expected error for options %+v", opts)
+		}
+	}
+}
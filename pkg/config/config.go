@@ -1,16 +1,80 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config represents the application configuration
 type Config struct {
-	JSONFolderPath string
-	Port           string
+	JSONFolderPath  string
+	Port            string
+	OpenAPISpecPath string
+	FakeSeed        *int64
+	DisableHTTP     bool
+	MetricsPath     string
+	ShutdownTimeout time.Duration
+	StateFile       string
+	ReservedPrefix  string
+	MaxBodyBytes    int
+	TLS             TLSConfig
+	GRPC            GRPCConfig
+	Tracing         TracingConfig
+	Logging         LoggingConfig
+}
+
+// TLSConfig represents the HTTPS listener configuration. HTTPS is enabled
+// when Port is set.
+type TLSConfig struct {
+	Port         string
+	CertFile     string
+	KeyFile      string
+	CertDir      string
+	Hosts        []string
+	ClientAuth   string
+	ClientCAFile string
+}
+
+// GRPCConfig represents the gRPC listener configuration. gRPC is enabled when
+// Port is set.
+type GRPCConfig struct {
+	Port        string
+	ProtoFiles  []string
+	ImportPaths []string
+}
+
+// TracingConfig represents the OTLP trace exporter configuration. Tracing is
+// enabled when Endpoint is set.
+type TracingConfig struct {
+	Endpoint    string
+	Protocol    string
+	ServiceName string
+}
+
+// LoggingConfig represents the logger configuration
+type LoggingConfig struct {
+	Level      string
+	Format     string
+	Outputs    []string
+	File       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+
+	// Bodies logs request and response headers and bodies, up to
+	// BodyMaxBytes each, with the listed headers and JSON fields redacted
+	Bodies        bool
+	BodyMaxBytes  int
+	RedactHeaders []string
+	RedactFields  []string
 }
 
 // LoadConfig loads configuration from environment variables
@@ -34,8 +98,222 @@ func LoadConfig() (*Config, error) {
 		log.Printf("PORT not set, using default: %s", port)
 	}
 
+	// Optional OpenAPI document used to validate requests and mock responses
+	openAPISpecPath := os.Getenv("OPENAPI_SPEC_PATH")
+
+	// Optional seed for reproducible schema-driven fake data
+	var fakeSeed *int64
+	if value := os.Getenv("FAKE_DATA_SEED"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FAKE_DATA_SEED %q: %v", value, err)
+		}
+		fakeSeed = &seed
+	}
+
+	disableHTTP, err := getBool("DISABLE_HTTP")
+	if err != nil {
+		return nil, err
+	}
+
+	// Prometheus metrics are served on /metrics unless disabled
+	disableMetrics, err := getBool("DISABLE_METRICS")
+	if err != nil {
+		return nil, err
+	}
+	metricsPath := os.Getenv("METRICS_PATH")
+	if metricsPath == "" {
+		metricsPath = "/metrics"
+	}
+	if disableMetrics {
+		metricsPath = ""
+	} else if !strings.HasPrefix(metricsPath, "/") {
+		return nil, fmt.Errorf("invalid METRICS_PATH %q: must start with /", metricsPath)
+	}
+
+	// Time given to in-flight requests and streams to finish on shutdown
+	shutdownTimeout := 30 * time.Second
+	if value := os.Getenv("SHUTDOWN_TIMEOUT"); value != "" {
+		shutdownTimeout, err = time.ParseDuration(value)
+		if err != nil || shutdownTimeout <= 0 {
+			return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT %q: expected a positive duration such as 30s", value)
+		}
+	}
+
+	// The admin API, probes and info route are served under this prefix
+	reservedPrefix := os.Getenv("RESERVED_PREFIX")
+	if reservedPrefix == "" {
+		reservedPrefix = "/__"
+	}
+	if !strings.HasPrefix(reservedPrefix, "/") || reservedPrefix == "/" {
+		return nil, fmt.Errorf("invalid RESERVED_PREFIX %q: must start with / and not be /", reservedPrefix)
+	}
+
+	// Largest JSON or form body read for matching; 0 keeps the server default
+	maxBodyBytes, err := getInt("MAX_BODY_BYTES")
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional HTTPS listener; certificates are generated into TLS_CERT_DIR
+	// unless TLS_CERT_FILE and TLS_KEY_FILE are provided
+	tlsConfig := TLSConfig{
+		Port:         os.Getenv("TLS_PORT"),
+		CertFile:     os.Getenv("TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("TLS_KEY_FILE"),
+		CertDir:      os.Getenv("TLS_CERT_DIR"),
+		Hosts:        getList("TLS_HOSTS"),
+		ClientAuth:   os.Getenv("TLS_CLIENT_AUTH"),
+		ClientCAFile: os.Getenv("TLS_CLIENT_CA_FILE"),
+	}
+	if tlsConfig.CertDir == "" {
+		tlsConfig.CertDir = "./certs"
+	}
+	if disableHTTP && tlsConfig.Port == "" {
+		return nil, fmt.Errorf("DISABLE_HTTP requires TLS_PORT to be set")
+	}
+
+	// Optional gRPC listener serving the methods described by the proto files
+	grpcConfig := GRPCConfig{
+		Port:        os.Getenv("GRPC_PORT"),
+		ProtoFiles:  getList("GRPC_PROTO_FILES"),
+		ImportPaths: getList("GRPC_PROTO_IMPORT_PATHS"),
+	}
+	if grpcConfig.Port != "" && len(grpcConfig.ProtoFiles) == 0 {
+		return nil, fmt.Errorf("GRPC_PORT requires GRPC_PROTO_FILES to be set")
+	}
+
+	// Optional OpenTelemetry tracing, configured with the standard OTLP
+	// environment variables
+	tracingConfig := TracingConfig{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Protocol:    os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	}
+	switch tracingConfig.Protocol {
+	case "", "grpc", "http/protobuf":
+	default:
+		return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_PROTOCOL %q: expected grpc or http/protobuf", tracingConfig.Protocol)
+	}
+
+	loggingConfig, err := loadLoggingConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		JSONFolderPath: jsonFolderPath,
-		Port:           port,
+		JSONFolderPath:  jsonFolderPath,
+		Port:            port,
+		OpenAPISpecPath: openAPISpecPath,
+		FakeSeed:        fakeSeed,
+		DisableHTTP:     disableHTTP,
+		MetricsPath:     metricsPath,
+		ShutdownTimeout: shutdownTimeout,
+		StateFile:       os.Getenv("STATE_FILE"),
+		ReservedPrefix:  reservedPrefix,
+		MaxBodyBytes:    maxBodyBytes,
+		TLS:             tlsConfig,
+		GRPC:            grpcConfig,
+		Tracing:         tracingConfig,
+		Logging:         *loggingConfig,
 	}, nil
 }
+
+// loadLoggingConfig reads the logger settings. Logs go to the console and to
+// mock-server.log under LOG_PATH unless LOG_OUTPUTS says otherwise.
+func loadLoggingConfig() (*LoggingConfig, error) {
+	cfg := &LoggingConfig{
+		Level:         strings.ToLower(os.Getenv("LOG_LEVEL")),
+		Format:        strings.ToLower(os.Getenv("LOG_FORMAT")),
+		Outputs:       getList("LOG_OUTPUTS"),
+		File:          os.Getenv("LOG_FILE"),
+		RedactHeaders: getList("LOG_REDACT_HEADERS"),
+		RedactFields:  getList("LOG_REDACT_FIELDS"),
+	}
+	if len(cfg.Outputs) == 0 {
+		cfg.Outputs = []string{"stdout", "file"}
+	}
+	if cfg.File == "" {
+		logPath := os.Getenv("LOG_PATH")
+		if logPath == "" {
+			logPath = "logs"
+		}
+		cfg.File = filepath.Join(logPath, "mock-server.log")
+	}
+
+	switch cfg.Level {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q: expected debug, info, warn or error", cfg.Level)
+	}
+	switch cfg.Format {
+	case "", "json", "console":
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q: expected json or console", cfg.Format)
+	}
+	for _, output := range cfg.Outputs {
+		switch output {
+		case "stdout", "stderr", "file", "none":
+		default:
+			return nil, fmt.Errorf("invalid LOG_OUTPUTS entry %q: expected stdout, stderr, file or none", output)
+		}
+	}
+
+	var err error
+	if cfg.MaxSizeMB, err = getInt("LOG_MAX_SIZE_MB"); err != nil {
+		return nil, err
+	}
+	if cfg.MaxBackups, err = getInt("LOG_MAX_BACKUPS"); err != nil {
+		return nil, err
+	}
+	if cfg.MaxAgeDays, err = getInt("LOG_MAX_AGE_DAYS"); err != nil {
+		return nil, err
+	}
+	if cfg.Compress, err = getBool("LOG_COMPRESS"); err != nil {
+		return nil, err
+	}
+	if cfg.Bodies, err = getBool("LOG_BODIES"); err != nil {
+		return nil, err
+	}
+	if cfg.BodyMaxBytes, err = getInt("LOG_BODY_MAX_BYTES"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// getBool reads a boolean environment variable, defaulting to false
+func getBool(name string) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: expected true or false", name, value)
+	}
+	return b, nil
+}
+
+// getInt reads a non-negative integer environment variable, defaulting to 0
+func getInt(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a non-negative integer", name, value)
+	}
+	return n, nil
+}
+
+// getList reads a comma separated environment variable
+func getList(name string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -48,3 +50,226 @@ func TestLoadConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadConfigTLS(t *testing.T) {
+	t.Run("With TLS variables", func(t *testing.T) {
+		os.Setenv("TLS_PORT", "8443")
+		os.Setenv("TLS_HOSTS", "localhost, mock.local")
+		os.Setenv("TLS_CLIENT_AUTH", "require")
+		defer func() {
+			os.Unsetenv("TLS_PORT")
+			os.Unsetenv("TLS_HOSTS")
+			os.Unsetenv("TLS_CLIENT_AUTH")
+		}()
+
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.TLS.Port != "8443" || cfg.TLS.ClientAuth != "require" {
+			t.Errorf("Unexpected TLS config: %+v", cfg.TLS)
+		}
+		if len(cfg.TLS.Hosts) != 2 || cfg.TLS.Hosts[1] != "mock.local" {
+			t.Errorf("Expected hosts [localhost mock.local], got %v", cfg.TLS.Hosts)
+		}
+		if cfg.TLS.CertDir != "./certs" {
+			t.Errorf("Expected default CertDir to be ./certs, got %s", cfg.TLS.CertDir)
+		}
+	})
+
+	t.Run("HTTP disabled without TLS", func(t *testing.T) {
+		os.Setenv("DISABLE_HTTP", "true")
+		defer os.Unsetenv("DISABLE_HTTP")
+
+		if _, err := LoadConfig(); err == nil {
+			t.Error("Expected error when HTTP is disabled without a TLS port")
+		}
+	})
+}
+
+func TestLoadConfigGRPC(t *testing.T) {
+	t.Run("With gRPC variables", func(t *testing.T) {
+		os.Setenv("GRPC_PORT", "9090")
+		os.Setenv("GRPC_PROTO_FILES", "a.proto, b.protoset")
+		defer func() {
+			os.Unsetenv("GRPC_PORT")
+			os.Unsetenv("GRPC_PROTO_FILES")
+		}()
+
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.GRPC.Port != "9090" || len(cfg.GRPC.ProtoFiles) != 2 || cfg.GRPC.ProtoFiles[1] != "b.protoset" {
+			t.Errorf("Unexpected gRPC config: %+v", cfg.GRPC)
+		}
+	})
+
+	t.Run("Port without proto files", func(t *testing.T) {
+		os.Setenv("GRPC_PORT", "9090")
+		defer os.Unsetenv("GRPC_PORT")
+
+		if _, err := LoadConfig(); err == nil {
+			t.Error("Expected error when GRPC_PORT is set without GRPC_PROTO_FILES")
+		}
+	})
+}
+
+func TestLoadConfigMetrics(t *testing.T) {
+	t.Run("Default path", func(t *testing.T) {
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.MetricsPath != "/metrics" {
+			t.Errorf("Expected metrics on /metrics, got %q", cfg.MetricsPath)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		os.Setenv("DISABLE_METRICS", "true")
+		defer os.Unsetenv("DISABLE_METRICS")
+
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.MetricsPath != "" {
+			t.Errorf("Expected metrics to be disabled, got %q", cfg.MetricsPath)
+		}
+	})
+
+	t.Run("Invalid path", func(t *testing.T) {
+		os.Setenv("METRICS_PATH", "metrics")
+		defer os.Unsetenv("METRICS_PATH")
+
+		if _, err := LoadConfig(); err == nil {
+			t.Error("Expected error for a METRICS_PATH without a leading slash")
+		}
+	})
+}
+
+func TestLoadConfigShutdown(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.ShutdownTimeout != 30*time.Second || cfg.StateFile != "" {
+			t.Errorf("Unexpected shutdown defaults: %v %q", cfg.ShutdownTimeout, cfg.StateFile)
+		}
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		os.Setenv("SHUTDOWN_TIMEOUT", "5s")
+		os.Setenv("STATE_FILE", "data/state.json")
+		defer os.Unsetenv("SHUTDOWN_TIMEOUT")
+		defer os.Unsetenv("STATE_FILE")
+
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.ShutdownTimeout != 5*time.Second || cfg.StateFile != "data/state.json" {
+			t.Errorf("Unexpected shutdown config: %v %q", cfg.ShutdownTimeout, cfg.StateFile)
+		}
+	})
+
+	t.Run("Invalid timeout", func(t *testing.T) {
+		os.Setenv("SHUTDOWN_TIMEOUT", "30")
+		defer os.Unsetenv("SHUTDOWN_TIMEOUT")
+
+		if _, err := LoadConfig(); err == nil {
+			t.Error("Expected error for a SHUTDOWN_TIMEOUT without a unit")
+		}
+	})
+}
+
+func TestLoadConfigReservedPrefix(t *testing.T) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.ReservedPrefix != "/__" {
+		t.Errorf("Expected reserved prefix /__, got %q", cfg.ReservedPrefix)
+	}
+
+	os.Setenv("RESERVED_PREFIX", "_mock/")
+	defer os.Unsetenv("RESERVED_PREFIX")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected error for a RESERVED_PREFIX without a leading slash")
+	}
+}
+
+func TestLoadConfigMaxBodyBytes(t *testing.T) {
+	os.Setenv("MAX_BODY_BYTES", "1024")
+	cfg, err := LoadConfig()
+	os.Unsetenv("MAX_BODY_BYTES")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.MaxBodyBytes != 1024 {
+		t.Errorf("Expected max body bytes 1024, got %d", cfg.MaxBodyBytes)
+	}
+
+	os.Setenv("MAX_BODY_BYTES", "-1")
+	defer os.Unsetenv("MAX_BODY_BYTES")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected error for a negative MAX_BODY_BYTES")
+	}
+}
+
+func TestLoadConfigLogging(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if len(cfg.Logging.Outputs) != 2 || cfg.Logging.File != filepath.Join("logs", "mock-server.log") || cfg.Logging.Bodies {
+			t.Errorf("Unexpected logging config: %+v", cfg.Logging)
+		}
+	})
+
+	t.Run("With logging variables", func(t *testing.T) {
+		vars := map[string]string{
+			"LOG_LEVEL":          "DEBUG",
+			"LOG_FORMAT":         "json",
+			"LOG_OUTPUTS":        "stderr",
+			"LOG_MAX_SIZE_MB":    "10",
+			"LOG_BODIES":         "true",
+			"LOG_BODY_MAX_BYTES": "1024",
+			"LOG_REDACT_FIELDS":  "password, token",
+		}
+		for name, value := range vars {
+			os.Setenv(name, value)
+		}
+		defer func() {
+			for name := range vars {
+				os.Unsetenv(name)
+			}
+		}()
+
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		l := cfg.Logging
+		if l.Level != "debug" || l.Outputs[0] != "stderr" || l.MaxSizeMB != 10 || !l.Bodies || l.BodyMaxBytes != 1024 || len(l.RedactFields) != 2 {
+			t.Errorf("Unexpected logging config: %+v", l)
+		}
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		for name, value := range map[string]string{
+			"LOG_LEVEL":       "verbose",
+			"LOG_OUTPUTS":     "syslog",
+			"LOG_MAX_SIZE_MB": "-1",
+		} {
+			os.Setenv(name, value)
+			if _, err := LoadConfig(); err == nil {
+				t.Errorf("Expected error for %s=%s", name, value)
+			}
+			os.Unsetenv(name)
+		}
+	})
+}
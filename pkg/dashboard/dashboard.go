@@ -0,0 +1,24 @@
+// Package dashboard holds the web dashboard of the mock server. The dashboard
+// is a static page, embedded in the binary, that lists the mocks, shows live
+// traffic and forces responses through the admin API.
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard files. The server mounts it under the
+// dashboard route with the route stripped; the page finds the admin API
+// relative to its own URL, so it works under any reserved prefix.
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(files)
+}
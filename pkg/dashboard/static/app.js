@@ -0,0 +1,229 @@
+// The dashboard is served on <prefix>dashboard/, next to the admin API on
+// <prefix>admin/, so the API is found relative to the page
+const prefix = location.pathname.replace(/dashboard\/(index\.html)?$/, "");
+const api = (path) => prefix + path;
+
+const maxFeedRows = 500;
+
+let mocks = {};
+let forced = {};
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (key === "class") {
+      node.className = value;
+    } else if (key.startsWith("on")) {
+      node.addEventListener(key.slice(2), value);
+    } else {
+      node.setAttribute(key, value);
+    }
+  }
+  for (const child of children) {
+    if (child !== null && child !== undefined) {
+      node.append(child);
+    }
+  }
+  return node;
+}
+
+async function call(method, path, body) {
+  const options = { method, headers: { Accept: "application/json" } };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const resp = await fetch(api(path), options);
+  if (!resp.ok) {
+    throw new Error((await resp.text()).trim() || resp.statusText);
+  }
+  return resp.json();
+}
+
+function statusClass(status) {
+  return "status-" + String(status).charAt(0);
+}
+
+// Endpoints
+
+async function loadEndpoints() {
+  const error = document.getElementById("endpoints-error");
+  try {
+    const [mocksResp, forcedResp] = await Promise.all([
+      call("GET", "admin/mocks"),
+      call("GET", "admin/forced"),
+    ]);
+    mocks = mocksResp.mocks || {};
+    forced = forcedResp.forced || {};
+    error.hidden = true;
+  } catch (err) {
+    error.textContent = "Failed to load mocks: " + err.message;
+    error.hidden = false;
+  }
+  renderEndpoints();
+}
+
+function renderEndpoints() {
+  const filter = document.getElementById("endpoint-filter").value.toLowerCase();
+  const list = document.getElementById("endpoints");
+  list.replaceChildren();
+
+  for (const path of Object.keys(mocks).sort()) {
+    if (filter && !path.toLowerCase().includes(filter)) {
+      continue;
+    }
+    const mock = mocks[path];
+    const forceable = !mock.type;
+
+    const responses = el("ul", { class: "responses" });
+    (mock.responses || []).forEach((response, index) => {
+      const isForced = forced[path] === index;
+      let button = null;
+      if (forceable) {
+        button = el("button", {
+          type: "button",
+          class: isForced ? "active" : "",
+          title: isForced ? "Go back to matching requests" : "Answer every request with this response",
+          onclick: () => (isForced ? clearForced(path) : force(path, index)),
+        }, isForced ? "Forced" : "Force");
+      }
+      const status = response.status || (mock.type ? "" : 200);
+      responses.append(el("li", null,
+        el("span", { class: statusClass(status) }, String(status)),
+        el("span", { class: "description" }, describe(response)),
+        button,
+      ));
+    });
+
+    list.append(el("li", null,
+      el("div", { class: "endpoint-title" },
+        el("span", { class: "method" }, mock.method || ""),
+        el("span", { class: "path" }, path),
+        mock.type ? el("span", { class: "badge" }, mock.type) : null,
+      ),
+      responses,
+    ));
+  }
+}
+
+function describe(response) {
+  const parts = [];
+  if (response.description) {
+    parts.push(response.description);
+  }
+  if (response.input_body !== undefined) {
+    parts.push("when body matches " + JSON.stringify(response.input_body));
+  }
+  if (response.scenario) {
+    parts.push("in scenario " + response.scenario.name);
+  }
+  return parts.join(", ");
+}
+
+async function force(path, index) {
+  try {
+    forced = (await call("PUT", "admin/forced", { path, index })).forced || {};
+  } catch (err) {
+    alert("Failed to force the response: " + err.message);
+  }
+  renderEndpoints();
+}
+
+async function clearForced(path) {
+  try {
+    forced = (await call("DELETE", "admin/forced?path=" + encodeURIComponent(path))).forced || {};
+  } catch (err) {
+    alert("Failed to clear the forced response: " + err.message);
+  }
+  renderEndpoints();
+}
+
+// Live feed
+
+function addEntry(entry) {
+  const outcome = entry.outcome || (entry.unmatched ? "unmatched" : "");
+  const row = el("tr", { "data-path": entry.path },
+    el("td", null, new Date(entry.time).toLocaleTimeString()),
+    el("td", { class: "method" }, entry.method),
+    el("td", { class: "path" }, entry.path + (entry.query ? "?" + entry.query : "")),
+    el("td", { class: statusClass(entry.status) }, String(entry.status)),
+    el("td", null, outcome ? el("span", { class: "badge outcome-" + outcome }, outcome) : null),
+    el("td", { class: "response" }, entry.response || ""),
+    el("td", null, entry.duration_ms.toFixed(1)),
+  );
+  applyFeedFilter(row);
+
+  const feed = document.getElementById("feed");
+  feed.prepend(row);
+  while (feed.children.length > maxFeedRows) {
+    feed.lastChild.remove();
+  }
+}
+
+function applyFeedFilter(row) {
+  const filter = document.getElementById("feed-filter").value.toLowerCase();
+  row.hidden = filter !== "" && !row.dataset.path.toLowerCase().includes(filter);
+}
+
+async function loadFeed() {
+  // Long requests complete after later ones, so entries are told apart by id
+  // rather than order when the stream replays what the journal returned
+  const seen = new Set();
+  try {
+    const resp = await call("GET", "admin/requests");
+    for (const entry of (resp.requests || []).slice(-maxFeedRows)) {
+      // Requests still in flight are shown when the stream sends them
+      if (entry.status !== 0) {
+        addEntry(entry);
+        seen.add(entry.id);
+      }
+    }
+  } catch (err) {
+    console.error("Failed to load the request journal", err);
+  }
+
+  const connection = document.getElementById("connection");
+  const source = new EventSource(api("admin/requests/stream"));
+  source.addEventListener("open", () => {
+    connection.textContent = "live";
+    connection.className = "badge outcome-forced";
+  });
+  source.addEventListener("error", () => {
+    connection.textContent = "reconnecting";
+    connection.className = "badge outcome-unmatched";
+  });
+  source.addEventListener("request", (event) => {
+    const entry = JSON.parse(event.data);
+    if (!seen.has(entry.id)) {
+      addEntry(entry);
+      seen.add(entry.id);
+    }
+  });
+}
+
+async function loadInfo() {
+  try {
+    const info = await call("GET", "info");
+    document.getElementById("info").textContent =
+      info.version + (info.commit ? " (" + info.commit.slice(0, 7) + ")" : "") +
+      " · " + info.endpoints + " endpoints · up " + info.uptime;
+  } catch (err) {
+    console.error("Failed to load server info", err);
+  }
+}
+
+document.getElementById("endpoint-filter").addEventListener("input", renderEndpoints);
+document.getElementById("refresh").addEventListener("click", () => {
+  loadEndpoints();
+  loadInfo();
+});
+document.getElementById("feed-filter").addEventListener("input", () => {
+  document.querySelectorAll("#feed tr").forEach(applyFeedFilter);
+});
+document.getElementById("clear-feed").addEventListener("click", () => {
+  document.getElementById("feed").replaceChildren();
+});
+
+loadInfo();
+loadEndpoints();
+loadFeed();
@@ -0,0 +1,238 @@
+// Package gomocktest runs a mock server inside Go tests.
+//
+// The server listens on an ephemeral port for the duration of the test and
+// is shut down through t.Cleanup. When the test ends it fails on requests no
+// mock answered and on expectations that were not met:
+//
+//	func TestClient(t *testing.T) {
+//		srv := gomocktest.New(t, gomocktest.WithFolder("testdata/mocks"))
+//		srv.Expect("POST", "/users").Times(1)
+//
+//		client := NewClient(srv.URL)
+//		...
+//	}
+package gomocktest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// Server is a mock server running for the duration of a test
+type Server struct {
+	// URL is the base URL of the server, such as http://127.0.0.1:50123
+	URL string
+
+	t          testing.TB
+	srv        *server.Server
+	httpServer *httptest.Server
+
+	mu             sync.Mutex
+	expectations   []*Expectation
+	allowUnmatched bool
+}
+
+type options struct {
+	folders        []string
+	responses      map[string]mock.Response
+	stubs          []*mock.ReplyBuilder
+	serverOpts     []server.Option
+	allowUnmatched bool
+}
+
+// Option configures a test server
+type Option func(*options)
+
+// WithFolder loads the mocks of a folder, as the server does at startup
+func WithFolder(path string) Option {
+	return func(o *options) {
+		o.folders = append(o.folders, path)
+	}
+}
+
+// WithResponses adds mocks defined in code. They take precedence over mocks
+// loaded from folders with the same path, as described by mock.Merge.
+func WithResponses(responses map[string]mock.Response) Option {
+	return func(o *options) {
+		o.responses = mock.Merge(o.responses, responses)
+	}
+}
+
+// WithMock adds a single mock defined in code
+func WithMock(path string, response mock.Response) Option {
+	return WithResponses(map[string]mock.Response{path: response})
+}
+
+// WithStubs adds mocks built with mock.On:
+//
+//	gomocktest.New(t, gomocktest.WithStubs(
+//		mock.On("GET", "/users/1").Reply(200).JSON(user),
+//	))
+func WithStubs(stubs ...*mock.ReplyBuilder) Option {
+	return func(o *options) {
+		o.stubs = append(o.stubs, stubs...)
+	}
+}
+
+// WithServerOptions passes options such as server.WithOpenAPI to the server
+func WithServerOptions(opts ...server.Option) Option {
+	return func(o *options) {
+		o.serverOpts = append(o.serverOpts, opts...)
+	}
+}
+
+// AllowUnmatched stops the test from failing on requests no mock answered
+func AllowUnmatched() Option {
+	return func(o *options) {
+		o.allowUnmatched = true
+	}
+}
+
+// New starts a mock server on an ephemeral port. It is closed and verified
+// when the test ends.
+func New(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	o := &options{responses: make(map[string]mock.Response)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	responses := make(map[string]mock.Response)
+	for _, folder := range o.folders {
+		loaded, err := mock.LoadResponses(folder)
+		if err != nil {
+			t.Fatalf("gomocktest: failed to load mocks from %s: %v", folder, err)
+		}
+		for path, response := range loaded {
+			responses[path] = response
+		}
+	}
+	responses = mock.Merge(responses, o.responses)
+	if len(o.stubs) > 0 {
+		built, err := mock.Build(o.stubs...)
+		if err != nil {
+			t.Fatalf("gomocktest: invalid stub: %v", err)
+		}
+		responses = mock.Merge(responses, built)
+	}
+
+	// Logs go to the test log, warnings and errors only, and never to disk.
+	// The journal keeps every request so expectations count all of them.
+	logger := zaptest.NewLogger(t, zaptest.Level(zap.WarnLevel))
+	serverOpts := append([]server.Option{
+		server.WithLogger(logger),
+		server.WithJournalLimit(journal.Unlimited),
+	}, o.serverOpts...)
+	srv, err := server.New(responses, "", serverOpts...)
+	if err != nil {
+		t.Fatalf("gomocktest: failed to create server: %v", err)
+	}
+
+	s := &Server{
+		t:              t,
+		srv:            srv,
+		httpServer:     httptest.NewServer(srv.Handler()),
+		allowUnmatched: o.allowUnmatched,
+	}
+	s.URL = s.httpServer.URL
+	t.Cleanup(func() {
+		s.httpServer.Close()
+		s.verify()
+	})
+	return s
+}
+
+// Client returns an HTTP client for the server
+func (s *Server) Client() *http.Client {
+	return s.httpServer.Client()
+}
+
+// Requests returns the requests received so far, oldest first
+func (s *Server) Requests() []journal.Entry {
+	return s.srv.Journal().Entries()
+}
+
+// Expect declares that the test calls the endpoint. By default it must be
+// called at least once; use Times to require an exact count.
+func (s *Server) Expect(method, path string) *Expectation {
+	e := &Expectation{method: strings.ToUpper(method), path: path, times: -1}
+	s.mu.Lock()
+	s.expectations = append(s.expectations, e)
+	s.mu.Unlock()
+	return e
+}
+
+// verify fails the test on unmatched requests and unmet expectations
+func (s *Server) verify() {
+	s.t.Helper()
+	entries := s.Requests()
+
+	if !s.allowUnmatched {
+		for _, entry := range entries {
+			if entry.Unmatched {
+				s.t.Errorf("gomocktest: no mock matched %s %s (status %d)", entry.Method, requestPath(entry), entry.Status)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.expectations {
+		if err := e.check(entries); err != nil {
+			s.t.Errorf("gomocktest: %v", err)
+		}
+	}
+}
+
+// Expectation is an expected call to an endpoint
+type Expectation struct {
+	method string
+	path   string
+	times  int
+}
+
+// Times requires the endpoint to be called exactly n times
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Never requires the endpoint not to be called
+func (e *Expectation) Never() *Expectation {
+	return e.Times(0)
+}
+
+func (e *Expectation) check(entries []journal.Entry) error {
+	count := 0
+	for _, entry := range entries {
+		if entry.Method == e.method && entry.Path == e.path {
+			count++
+		}
+	}
+
+	switch {
+	case e.times < 0 && count == 0:
+		return fmt.Errorf("expected %s %s to be called, but it was not", e.method, e.path)
+	case e.times >= 0 && count != e.times:
+		return fmt.Errorf("expected %s %s to be called %d time(s), got %d", e.method, e.path, e.times, count)
+	}
+	return nil
+}
+
+func requestPath(entry journal.Entry) string {
+	if entry.Query != "" {
+		return entry.Path + "?" + entry.Query
+	}
+	return entry.Path
+}
@@ -0,0 +1,171 @@
+package gomocktest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+// recorder captures failures and cleanups so failing tests can be checked
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func (r *recorder) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func TestNew(t *testing.T) {
+	srv := New(t,
+		WithFolder("testdata/mocks"),
+		WithMock("/users", mock.Response{
+			Method:    "POST",
+			Responses: []mock.ResponseConfig{{Status: 201, Body: map[string]interface{}{"id": 2}}},
+		}),
+		WithMock("/health", mock.Response{
+			Method:    "GET",
+			Responses: []mock.ResponseConfig{{Status: 200, Body: "ok"}},
+		}),
+	)
+	srv.Expect("POST", "/users").Times(1)
+	srv.Expect("GET", "/health")
+
+	// Code-defined mocks override folder mocks with the same path
+	resp, err := srv.Client().Post(srv.URL+"/users", "application/json", strings.NewReader(`{"name": "Grace"}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected 201, got %d", resp.StatusCode)
+	}
+
+	resp, err = srv.Client().Get(srv.URL + "/health")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if requests := srv.Requests(); len(requests) != 2 || requests[0].Body != `{"name": "Grace"}` {
+		t.Errorf("Unexpected requests %+v", requests)
+	}
+}
+
+func TestNewFailures(t *testing.T) {
+	rec := &recorder{TB: t}
+	srv := New(rec, WithFolder("testdata/mocks"))
+	srv.Expect("GET", "/users").Times(2)
+	srv.Expect("DELETE", "/users").Never()
+	srv.Expect("GET", "/orders")
+
+	for _, path := range []string{"/users", "/missing"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	rec.finish()
+
+	want := []string{
+		"no mock matched GET /missing",
+		"expected GET /users to be called 2 time(s), got 1",
+		"expected GET /orders to be called, but it was not",
+	}
+	if len(rec.errors) != len(want) {
+		t.Fatalf("Expected %d failures, got %v", len(want), rec.errors)
+	}
+	for i, msg := range want {
+		if !strings.Contains(rec.errors[i], msg) {
+			t.Errorf("Expected failure %q, got %q", msg, rec.errors[i])
+		}
+	}
+
+	// Server is closed after the test
+	if _, err := http.Get(srv.URL + "/users"); err == nil {
+		t.Error("Expected server to be closed")
+	}
+}
+
+func TestExpectCountsEveryRequest(t *testing.T) {
+	srv := New(t, WithMock("/health", mock.Response{
+		Method:    "GET",
+		Responses: []mock.ResponseConfig{{Status: 200, Body: "ok"}},
+	}))
+	calls := journal.DefaultLimit + 1
+	srv.Expect("GET", "/health").Times(calls)
+
+	for i := 0; i < calls; i++ {
+		resp, err := srv.Client().Get(srv.URL + "/health")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestAllowUnmatched(t *testing.T) {
+	rec := &recorder{TB: t}
+	srv := New(rec, AllowUnmatched())
+	resp, err := http.Get(srv.URL + "/missing")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	rec.finish()
+
+	if len(rec.errors) != 0 {
+		t.Errorf("Expected no failures, got %v", rec.errors)
+	}
+}
+
+func TestWithStubs(t *testing.T) {
+	srv := New(t,
+		WithFolder("testdata/mocks"),
+		WithStubs(
+			mock.On("GET", "/users").WithHeader("X-Tenant", "empty").Reply(200).JSON(map[string]interface{}{"users": []interface{}{}}),
+		),
+	)
+	srv.Expect("GET", "/users").Times(2)
+
+	get := func(tenant string) string {
+		req, _ := http.NewRequest("GET", srv.URL+"/users", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return strings.TrimSpace(string(body))
+	}
+
+	// The stub answers its tenant and the folder mock everyone else
+	if body := get("empty"); body != `{"users":[]}` {
+		t.Errorf("Expected stub response, got %s", body)
+	}
+	if body := get(""); !strings.Contains(body, "Ada") {
+		t.Errorf("Expected folder response, got %s", body)
+	}
+}
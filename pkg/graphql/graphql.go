@@ -0,0 +1,167 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// Request is a GraphQL operation sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Error is an entry of the errors list of a GraphQL response
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// ParseRequest reads a GraphQL request. POST bodies are either JSON or, with
+// the application/graphql content type, the bare query; GET requests carry
+// the operation in the query, operationName and variables parameters.
+func ParseRequest(r *http.Request) (*Request, error) {
+	req := &Request{}
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, fmt.Errorf("variables must be a JSON object")
+			}
+		}
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request body")
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "application/graphql" {
+			req.Query = string(body)
+		} else if err := json.Unmarshal(body, req); err != nil {
+			return nil, fmt.Errorf("request body must be a JSON object with a query")
+		}
+	default:
+		return nil, fmt.Errorf("GraphQL requests must use GET or POST")
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	return req, nil
+}
+
+// Operation returns the name of the executed operation: the requested one, or
+// the only operation in the query when none was requested
+func (req *Request) Operation() string {
+	if req.OperationName != "" {
+		return req.OperationName
+	}
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err != nil || len(doc.Operations) != 1 {
+		return ""
+	}
+	return doc.Operations[0].Name
+}
+
+// Normalize parses a query and formats it canonically so queries differing
+// only in whitespace, commas or comments compare equal
+func Normalize(query string) (string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf, formatter.WithCompacted()).FormatQueryDocument(doc)
+	return buf.String(), nil
+}
+
+// LoadSchema parses a schema written in the GraphQL schema definition
+// language
+func LoadSchema(name, sdl string) (*ast.Schema, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: name, Input: sdl})
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Validate checks the request query and variables against the schema
+func Validate(schema *ast.Schema, req *Request) []Error {
+	doc, errs := gqlparser.LoadQueryWithRules(schema, req.Query, nil)
+	if len(errs) > 0 {
+		return convertErrors(errs)
+	}
+
+	op := doc.Operations.ForName(req.OperationName)
+	if op == nil {
+		if req.OperationName != "" {
+			return []Error{{Message: fmt.Sprintf("unknown operation %q", req.OperationName)}}
+		}
+		return []Error{{Message: "operationName is required when the query has several operations"}}
+	}
+	if _, err := validator.VariableValues(schema, op, req.Variables); err != nil {
+		if gqlErr, ok := err.(*gqlerror.Error); ok {
+			return convertErrors(gqlerror.List{gqlErr})
+		}
+		return []Error{{Message: err.Error()}}
+	}
+	return nil
+}
+
+func convertErrors(list gqlerror.List) []Error {
+	errs := make([]Error, len(list))
+	for i, err := range list {
+		errs[i] = Error{Message: err.Message}
+		for _, element := range err.Path {
+			errs[i].Path = append(errs[i].Path, element)
+		}
+	}
+	return errs
+}
+
+// ContainsVariables reports whether the variables include every expected
+// value. Objects are compared recursively so only the listed fields need to
+// match; other values must be equal.
+func ContainsVariables(variables, expected map[string]interface{}) bool {
+	for key, want := range expected {
+		got, ok := variables[key]
+		if !ok || !containsValue(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(got, want interface{}) bool {
+	if wantObj, ok := want.(map[string]interface{}); ok {
+		gotObj, ok := got.(map[string]interface{})
+		return ok && ContainsVariables(gotObj, wantObj)
+	}
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		return false
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		return false
+	}
+	return string(gotJSON) == string(wantJSON)
+}
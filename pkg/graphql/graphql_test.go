@@ -0,0 +1,134 @@
+package graphql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+type Query {
+	user(id: ID!): User
+}
+
+type User {
+	id: ID!
+	name: String!
+}
+`
+
+func TestNormalize(t *testing.T) {
+	a, err := Normalize("query GetUser($id: ID!) { user(id: $id) { id name } }")
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	b, err := Normalize(`
+		# fetch a user
+		query GetUser($id: ID!) {
+			user(id: $id) {
+				id,
+				name
+			}
+		}`)
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if a != b {
+		t.Errorf("Expected equal normalized queries, got %q and %q", a, b)
+	}
+
+	if _, err := Normalize("query {"); err == nil {
+		t.Error("Expected error for invalid query")
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query GetUser { user(id: 1) { id } }", "variables": {"id": "1"}}`))
+	parsed, err := ParseRequest(req)
+	if err != nil {
+		t.Fatalf("ParseRequest failed: %v", err)
+	}
+	if parsed.Operation() != "GetUser" || parsed.Variables["id"] != "1" {
+		t.Errorf("Unexpected request: %+v", parsed)
+	}
+
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader("{ user(id: 1) { id } }"))
+	req.Header.Set("Content-Type", "application/graphql")
+	if parsed, err = ParseRequest(req); err != nil || parsed.Query != "{ user(id: 1) { id } }" {
+		t.Errorf("Expected bare query body to be parsed, got %+v (%v)", parsed, err)
+	}
+
+	req = httptest.NewRequest("GET", "/graphql?"+url.Values{
+		"query":         {"query A { user(id: 1) { id } } query B { user(id: 2) { id } }"},
+		"operationName": {"B"},
+		"variables":     {`{"x": 1}`},
+	}.Encode(), nil)
+	if parsed, err = ParseRequest(req); err != nil || parsed.Operation() != "B" {
+		t.Errorf("Expected GET request to be parsed, got %+v (%v)", parsed, err)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"variables": {}}`)),
+		httptest.NewRequest("POST", "/graphql", strings.NewReader(`not json`)),
+		httptest.NewRequest("PUT", "/graphql", nil),
+	} {
+		if _, err := ParseRequest(req); err == nil {
+			t.Errorf("Expected error for %s request", req.Method)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := LoadSchema("schema.graphql", testSchema)
+	if err != nil {
+		t.Fatalf("LoadSchema failed: %v", err)
+	}
+
+	valid := &Request{Query: "query GetUser($id: ID!) { user(id: $id) { name } }", Variables: map[string]interface{}{"id": "1"}}
+	if errs := Validate(schema, valid); len(errs) != 0 {
+		t.Errorf("Expected valid request, got %v", errs)
+	}
+
+	invalid := []*Request{
+		{Query: "{ user(id: 1) { email } }"},
+		{Query: "query GetUser($id: ID!) { user(id: $id) { name } }"},
+		{Query: "query A { user(id: 1) { id } } query B { user(id: 2) { id } }"},
+	}
+	for _, req := range invalid {
+		if errs := Validate(schema, req); len(errs) == 0 {
+			t.Errorf("Expected errors for query %q", req.Query)
+		}
+	}
+
+	if _, err := LoadSchema("broken.graphql", "type Query { user: Missing }"); err == nil {
+		t.Error("Expected error for schema with unknown type")
+	}
+}
+
+func TestContainsVariables(t *testing.T) {
+	variables := map[string]interface{}{
+		"id":     "1",
+		"filter": map[string]interface{}{"role": "admin", "active": true},
+		"tags":   []interface{}{"a", "b"},
+	}
+
+	testCases := []struct {
+		expected map[string]interface{}
+		want     bool
+	}{
+		{nil, true},
+		{map[string]interface{}{"id": "1"}, true},
+		{map[string]interface{}{"filter": map[string]interface{}{"role": "admin"}}, true},
+		{map[string]interface{}{"tags": []interface{}{"a", "b"}}, true},
+		{map[string]interface{}{"tags": []interface{}{"a"}}, false},
+		{map[string]interface{}{"id": "2"}, false},
+		{map[string]interface{}{"missing": nil}, false},
+	}
+	for _, tc := range testCases {
+		if got := ContainsVariables(variables, tc.expected); got != tc.want {
+			t.Errorf("ContainsVariables(%v) = %v, want %v", tc.expected, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package grpcmock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	// Register the google.rpc error detail messages for status details
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// Registry holds the protobuf descriptors of the mocked services and converts
+// between protobuf messages and the JSON used in mock files
+type Registry struct {
+	files *protoregistry.Files
+	types *dynamicpb.Types
+}
+
+// Load builds a registry from .proto files, compiled with the bundled parser,
+// and FileDescriptorSets (.protoset, .pb, .desc or .binpb, as written by
+// protoc --include_imports --descriptor_set_out). Imports of .proto files are
+// resolved against importPaths, defaulting to the directory of each file.
+func Load(paths, importPaths []string) (*Registry, error) {
+	files := &protoregistry.Files{}
+	for _, path := range paths {
+		var err error
+		if strings.HasSuffix(path, ".proto") {
+			err = loadProto(files, path, importPaths)
+		} else {
+			err = loadDescriptorSet(files, path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return &Registry{files: files, types: dynamicpb.NewTypes(files)}, nil
+}
+
+func loadProto(files *protoregistry.Files, path string, importPaths []string) error {
+	name := filepath.Base(path)
+	paths := []string{filepath.Dir(path)}
+	if len(importPaths) > 0 {
+		paths = importPaths
+		name = ""
+		for _, importPath := range importPaths {
+			if rel, err := filepath.Rel(importPath, path); err == nil && !strings.HasPrefix(rel, "..") {
+				name = filepath.ToSlash(rel)
+				break
+			}
+		}
+		if name == "" {
+			return fmt.Errorf("file is not under any import path")
+		}
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: paths}),
+	}
+	compiled, err := compiler.Compile(context.Background(), name)
+	if err != nil {
+		return err
+	}
+	for _, file := range compiled {
+		if err := register(files, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadDescriptorSet(files *protoregistry.Files, path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(content, &set); err != nil {
+		return fmt.Errorf("not a FileDescriptorSet: %v", err)
+	}
+	loaded, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return err
+	}
+	var registerErr error
+	loaded.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		registerErr = register(files, file)
+		return registerErr == nil
+	})
+	return registerErr
+}
+
+// register adds a file and its imports, skipping files that are already known
+func register(files *protoregistry.Files, file protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(file.Path()); err == nil {
+		return nil
+	}
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := register(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(file)
+}
+
+// Method returns the descriptor of a method given its full name as used in
+// gRPC paths, /package.Service/Method
+func (r *Registry) Method(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	name := strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1)
+	desc, err := r.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown method %s", fullMethod)
+	}
+	method, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a method", fullMethod)
+	}
+	return method, nil
+}
+
+// Files returns the loaded descriptors
+func (r *Registry) Files() *protoregistry.Files {
+	return r.files
+}
+
+// ServiceInfo lists the services and methods that have mocks, for the
+// reflection service
+func (r *Registry) ServiceInfo(responses map[string]mock.Response) map[string]grpc.ServiceInfo {
+	info := make(map[string]grpc.ServiceInfo)
+	for path, m := range responses {
+		if !m.IsGRPC() {
+			continue
+		}
+		method, err := r.Method(path)
+		if err != nil {
+			continue
+		}
+		service := string(method.Parent().FullName())
+		serviceInfo := info[service]
+		serviceInfo.Metadata = method.ParentFile().Path()
+		serviceInfo.Methods = append(serviceInfo.Methods, grpc.MethodInfo{
+			Name:           string(method.Name()),
+			IsClientStream: method.IsStreamingClient(),
+			IsServerStream: method.IsStreamingServer(),
+		})
+		info[service] = serviceInfo
+	}
+	for _, serviceInfo := range info {
+		sort.Slice(serviceInfo.Methods, func(i, j int) bool {
+			return serviceInfo.Methods[i].Name < serviceInfo.Methods[j].Name
+		})
+	}
+	return info
+}
+
+// NewMessage returns an empty message of the given type
+func (r *Registry) NewMessage(desc protoreflect.MessageDescriptor) *dynamicpb.Message {
+	return dynamicpb.NewMessage(desc)
+}
+
+// ToJSON converts a message to a JSON value using the proto field names
+func (r *Registry) ToJSON(msg proto.Message) (interface{}, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, Resolver: r}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// FromJSON converts a JSON value to a message of the given type. Both proto
+// and JSON field names are accepted.
+func (r *Registry) FromJSON(desc protoreflect.MessageDescriptor, value interface{}) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(desc)
+	if value == nil {
+		return msg, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := (protojson.UnmarshalOptions{Resolver: r}).Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", desc.FullName(), err)
+	}
+	return msg, nil
+}
+
+// Normalize converts a JSON value through the message type so values written
+// with JSON or proto field names compare equal. Fields set to their default
+// value are dropped.
+func (r *Registry) Normalize(desc protoreflect.MessageDescriptor, value interface{}) (interface{}, error) {
+	msg, err := r.FromJSON(desc, value)
+	if err != nil {
+		return nil, err
+	}
+	return r.ToJSON(msg)
+}
+
+// Status builds the status returned for a response, nil for OK responses
+func (r *Registry) Status(resp *mock.GRPCResponse) (*status.Status, error) {
+	if resp == nil {
+		return nil, nil
+	}
+	code, err := resp.StatusCode()
+	if err != nil || code == 0 {
+		return nil, err
+	}
+
+	st := &spb.Status{Code: int32(code), Message: resp.Message}
+	for i, detail := range resp.Details {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			return nil, err
+		}
+		var packed anypb.Any
+		if err := (protojson.UnmarshalOptions{Resolver: r}).Unmarshal(data, &packed); err != nil {
+			return nil, fmt.Errorf("invalid status detail %d: %v", i, err)
+		}
+		st.Details = append(st.Details, &packed)
+	}
+	return status.FromProto(st), nil
+}
+
+// Validate checks that every gRPC mock refers to a known unary or
+// server-streaming method and that its messages convert to protobuf
+func (r *Registry) Validate(responses map[string]mock.Response) error {
+	paths := make([]string, 0, len(responses))
+	for path, m := range responses {
+		if m.IsGRPC() {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		m := responses[path]
+		method, err := r.Method(path)
+		if err != nil {
+			return err
+		}
+		if method.IsStreamingClient() {
+			return fmt.Errorf("%s: client and bidirectional streaming methods are not supported", path)
+		}
+		for i, resp := range m.Responses {
+			if resp.InputBody != nil {
+				if _, err := r.FromJSON(method.Input(), resp.InputBody); err != nil {
+					return fmt.Errorf("%s: response %d: input_body: %v", path, i, err)
+				}
+			}
+			if !resp.IsGenerated() {
+				if _, err := r.FromJSON(method.Output(), resp.Body); err != nil {
+					return fmt.Errorf("%s: response %d: body: %v", path, i, err)
+				}
+			}
+			if resp.GRPC == nil {
+				continue
+			}
+			for j, chunk := range resp.GRPC.Stream {
+				if _, err := r.FromJSON(method.Output(), chunk.Data); err != nil {
+					return fmt.Errorf("%s: response %d: stream message %d: %v", path, i, j, err)
+				}
+			}
+			if _, err := r.Status(resp.GRPC); err != nil {
+				return fmt.Errorf("%s: response %d: %v", path, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// FindMessageByName resolves message types from the loaded descriptors first
+// and the linked-in types second
+func (r *Registry) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if mt, err := r.types.FindMessageByName(name); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+// FindMessageByURL resolves Any type URLs
+func (r *Registry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if mt, err := r.types.FindMessageByURL(url); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByURL(url)
+}
+
+// FindExtensionByName resolves extensions by name
+func (r *Registry) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if xt, err := r.types.FindExtensionByName(name); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(name)
+}
+
+// FindExtensionByNumber resolves extensions by field number
+func (r *Registry) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if xt, err := r.types.FindExtensionByNumber(message, field); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
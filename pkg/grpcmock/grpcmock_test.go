@@ -0,0 +1,194 @@
+package grpcmock
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const greeterProto = `
+syntax = "proto3";
+
+package greet.v1;
+
+import "google/protobuf/timestamp.proto";
+
+service Greeter {
+  rpc SayHello(HelloRequest) returns (HelloReply);
+  rpc StreamHellos(HelloRequest) returns (stream HelloReply);
+  rpc Chat(stream HelloRequest) returns (stream HelloReply);
+}
+
+message HelloRequest {
+  string user_name = 1;
+  int32 count = 2;
+}
+
+message HelloReply {
+  string message = 1;
+  google.protobuf.Timestamp sent_at = 2;
+}
+`
+
+func loadTestRegistry(t *testing.T) (*Registry, string) {
+	dir, err := ioutil.TempDir("", "grpcmock")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "greeter.proto")
+	if err := ioutil.WriteFile(path, []byte(greeterProto), 0644); err != nil {
+		t.Fatalf("Failed to write proto file: %v", err)
+	}
+	registry, err := Load([]string{path}, nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return registry, dir
+}
+
+func TestLoad(t *testing.T) {
+	registry, dir := loadTestRegistry(t)
+
+	method, err := registry.Method("/greet.v1.Greeter/StreamHellos")
+	if err != nil {
+		t.Fatalf("Method failed: %v", err)
+	}
+	if !method.IsStreamingServer() || method.Input().FullName() != "greet.v1.HelloRequest" {
+		t.Errorf("Unexpected method descriptor %v", method.FullName())
+	}
+	if _, err := registry.Method("/greet.v1.Greeter/Missing"); err == nil {
+		t.Error("Expected error for unknown method")
+	}
+
+	// The same descriptors load from a FileDescriptorSet
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, dep := range []string{"google/protobuf/timestamp.proto", "greeter.proto"} {
+		fd, err := registry.Files().FindFileByPath(dep)
+		if err != nil {
+			t.Fatalf("Missing file %s: %v", dep, err)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	data, _ := proto.Marshal(set)
+	setPath := filepath.Join(dir, "greeter.protoset")
+	if err := ioutil.WriteFile(setPath, data, 0644); err != nil {
+		t.Fatalf("Failed to write descriptor set: %v", err)
+	}
+	fromSet, err := Load([]string{setPath}, nil)
+	if err != nil {
+		t.Fatalf("Load descriptor set failed: %v", err)
+	}
+	if _, err := fromSet.Method("/greet.v1.Greeter/SayHello"); err != nil {
+		t.Errorf("Method missing from descriptor set: %v", err)
+	}
+}
+
+func TestJSONConversion(t *testing.T) {
+	registry, _ := loadTestRegistry(t)
+	method, _ := registry.Method("/greet.v1.Greeter/SayHello")
+
+	// JSON and proto field names normalize to the same value
+	camel, err := registry.Normalize(method.Input(), map[string]interface{}{"userName": "ada", "count": 2})
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	snake, _ := registry.Normalize(method.Input(), map[string]interface{}{"user_name": "ada", "count": "2"})
+	if !reflect.DeepEqual(camel, snake) {
+		t.Errorf("Expected equal values, got %v and %v", camel, snake)
+	}
+
+	msg, err := registry.FromJSON(method.Output(), map[string]interface{}{
+		"message": "hi",
+		"sent_at": "2024-01-02T03:04:05Z",
+	})
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+	value, _ := registry.ToJSON(msg)
+	if value.(map[string]interface{})["sent_at"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("Unexpected round trip %v", value)
+	}
+
+	if _, err := registry.FromJSON(method.Output(), map[string]interface{}{"unknown": 1}); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	registry, _ := loadTestRegistry(t)
+
+	if st, err := registry.Status(&mock.GRPCResponse{}); st != nil || err != nil {
+		t.Errorf("Expected no status for OK responses, got %v %v", st, err)
+	}
+
+	st, err := registry.Status(&mock.GRPCResponse{
+		Code:    "INVALID_ARGUMENT",
+		Message: "bad name",
+		Details: []interface{}{map[string]interface{}{
+			"@type": "type.googleapis.com/google.rpc.BadRequest",
+			"fieldViolations": []interface{}{
+				map[string]interface{}{"field": "user_name", "description": "required"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if st.Code() != codes.InvalidArgument || st.Message() != "bad name" {
+		t.Errorf("Unexpected status %v", st)
+	}
+	details := st.Details()
+	badRequest, ok := details[0].(*errdetails.BadRequest)
+	if len(details) != 1 || !ok || badRequest.FieldViolations[0].Field != "user_name" {
+		t.Errorf("Unexpected details %v", details)
+	}
+
+	if _, err := registry.Status(&mock.GRPCResponse{
+		Code:    "INTERNAL",
+		Details: []interface{}{map[string]interface{}{"@type": "type.googleapis.com/unknown.Type"}},
+	}); err == nil {
+		t.Error("Expected error for unknown detail type")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	registry, _ := loadTestRegistry(t)
+
+	valid := map[string]mock.Response{
+		"/greet.v1.Greeter/SayHello": {
+			Type: mock.TypeGRPC,
+			Responses: []mock.ResponseConfig{
+				{InputBody: map[string]interface{}{"user_name": "ada"}, Body: map[string]interface{}{"message": "hi"}},
+			},
+		},
+		"/users": {Method: "GET"},
+	}
+	if err := registry.Validate(valid); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	tests := map[string]mock.Response{
+		"/greet.v1.Greeter/Missing": {Type: mock.TypeGRPC},
+		"/greet.v1.Greeter/Chat":    {Type: mock.TypeGRPC},
+		"/greet.v1.Greeter/SayHello": {
+			Type:      mock.TypeGRPC,
+			Responses: []mock.ResponseConfig{{Body: map[string]interface{}{"text": "hi"}}},
+		},
+	}
+	for path, m := range tests {
+		if err := registry.Validate(map[string]mock.Response{path: m}); err == nil {
+			t.Errorf("Expected validation error for %s", path)
+		}
+	}
+}
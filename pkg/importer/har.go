@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+// harDocument represents the parts of an HTTP Archive used for import
+type harDocument struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method   string   `json:"method"`
+		URL      string   `json:"url"`
+		Headers  []header `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status     int      `json:"status"`
+		StatusText string   `json:"statusText"`
+		Headers    []header `json:"headers"`
+		Content    struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// LoadHAR reads a HAR capture from disk and converts it into mocks
+func LoadHAR(path string) (*Result, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseHAR(content)
+}
+
+// ParseHAR converts every entry of a HAR capture into a mock response.
+// Entries for the same endpoint become alternative responses, and JSON and
+// form request bodies become input_body matchers.
+func ParseHAR(content []byte) (*Result, error) {
+	var doc harDocument
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("invalid HAR file: %v", err)
+	}
+
+	result := newResult()
+	for i, entry := range doc.Log.Entries {
+		path, err := requestPath(entry.Request.URL)
+		if err != nil {
+			result.warnf("skipped entry %d: invalid URL %q", i, entry.Request.URL)
+			continue
+		}
+		if entry.Response.Status == 0 {
+			result.warnf("skipped entry %d (%s %s): no response was captured", i, entry.Request.Method, path)
+			continue
+		}
+
+		resp := mock.ResponseConfig{
+			Status:      entry.Response.Status,
+			Headers:     responseHeaders(entry.Response.Headers),
+			Body:        decodeBody(entry.Response.Content.Text, entry.Response.Content.MimeType, entry.Response.Content.Encoding),
+			Description: fmt.Sprintf("%s %s -> %d (HAR entry %d)", entry.Request.Method, path, entry.Response.Status, i),
+		}
+		if postData := entry.Request.PostData; postData != nil && postData.Text != "" {
+			switch {
+			case isJSON(postData.MimeType):
+				resp.InputBody = decodeBody(postData.Text, postData.MimeType, "")
+			case isForm(postData.MimeType):
+				form, err := decodeForm(postData.Text)
+				if err != nil {
+					result.warnf("entry %d (%s %s): request body not matched: invalid form data", i, entry.Request.Method, path)
+					break
+				}
+				resp.InputBody = form
+			default:
+				result.warnf("entry %d (%s %s): request body not matched: unsupported content type %q", i, entry.Request.Method, path, postData.MimeType)
+			}
+		}
+
+		result.add(entry.Request.Method, path, resp)
+	}
+	return result, nil
+}
@@ -0,0 +1,219 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+// Result holds the mocks converted from a capture along with notes about
+// entries that could not be imported
+type Result struct {
+	Responses map[string]mock.Response
+	Warnings  []string
+}
+
+// skippedHeaders are response headers that describe the original transfer
+// rather than the response itself and must not be replayed
+var skippedHeaders = map[string]bool{
+	"content-length":    true,
+	"content-encoding":  true,
+	"transfer-encoding": true,
+	"connection":        true,
+	"keep-alive":        true,
+	"date":              true,
+}
+
+func newResult() *Result {
+	return &Result{Responses: make(map[string]mock.Response)}
+}
+
+// add appends a response to the mock for the given method and path. A path
+// maps to a single method, so entries for another method on an already
+// imported path are skipped with a warning.
+func (r *Result) add(method, path string, resp mock.ResponseConfig) {
+	method = strings.ToUpper(method)
+	existing, ok := r.Responses[path]
+	if ok && existing.Method != method {
+		r.warnf("skipped %s %s: path already imported for method %s", method, path, existing.Method)
+		return
+	}
+	if !ok {
+		existing = mock.Response{Method: method, Path: path}
+	}
+	existing.Responses = append(existing.Responses, resp)
+	r.Responses[path] = existing
+}
+
+func (r *Result) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// WriteMocks writes one mock file per endpoint into dir and returns the
+// written file names. Endpoints whose paths map to the same file name get a
+// numbered suffix. Existing files with the same name are overwritten.
+func WriteMocks(dir string, responses map[string]mock.Response) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	paths := make([]string, 0, len(responses))
+	for path := range responses {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var written []string
+	// The folder auth file is never an endpoint
+	used := map[string]bool{mock.AuthFile: true}
+	for _, path := range paths {
+		content, err := json.MarshalIndent(responses[path], "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("failed to encode mock for %s: %v", path, err)
+		}
+		name := fileName(path)
+		base := strings.TrimSuffix(name, ".json")
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s_%d.json", base, i)
+		}
+		used[name] = true
+		if err := ioutil.WriteFile(filepath.Join(dir, name), append(content, '\n'), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %v", name, err)
+		}
+		written = append(written, name)
+	}
+	return written, nil
+}
+
+// fileName derives a file name from an endpoint path, e.g. /api/v1/users
+// becomes api_v1_users.json
+func fileName(path string) string {
+	name := strings.Trim(path, "/")
+	if name == "" {
+		name = "root"
+	}
+	name = strings.NewReplacer("/", "_", ":", "", "{", "", "}", "", "?", "", "*", "").Replace(name)
+	return name + ".json"
+}
+
+// requestPath extracts the decoded path component of a captured URL, as the
+// server matches it
+func requestPath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return path, nil
+}
+
+// decodeBody converts captured body text into a mock body. JSON content is
+// decoded so it can be matched and re-encoded, anything else stays a string.
+func decodeBody(text, mimeType, encoding string) interface{} {
+	if encoding == "base64" {
+		if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+			text = string(decoded)
+		}
+	}
+	if text == "" {
+		return nil
+	}
+	if isJSON(mimeType) || mimeType == "" {
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err == nil {
+			return value
+		}
+	}
+	return text
+}
+
+// decodeForm converts a form-encoded request body into an input_body
+// matcher shaped like the server's parsed forms: one entry per field, a
+// list when the field repeats
+func decodeForm(text string) (interface{}, error) {
+	values, err := url.ParseQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	form := make(map[string]interface{}, len(values))
+	for key, list := range values {
+		form[key] = formField(list)
+	}
+	return form, nil
+}
+
+// formField returns a single form value as is and repeated values as a list
+func formField(list []string) interface{} {
+	if len(list) == 1 {
+		return list[0]
+	}
+	items := make([]interface{}, len(list))
+	for i, value := range list {
+		items[i] = value
+	}
+	return items
+}
+
+func isJSON(mimeType string) bool {
+	mediaType := normalizeMediaType(mimeType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isForm(mimeType string) bool {
+	return normalizeMediaType(mimeType) == "application/x-www-form-urlencoded"
+}
+
+func normalizeMediaType(mimeType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+}
+
+// responseHeaders keeps the replayable headers of a captured response
+func responseHeaders(headers []header) map[string]string {
+	result := make(map[string]string)
+	for _, h := range headers {
+		if h.Disabled || skippedHeaders[strings.ToLower(h.Name())] {
+			continue
+		}
+		result[h.Name()] = h.Value
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// header is a name/value pair as stored in both HAR and Postman documents
+type header struct {
+	HARName  string `json:"name"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// Name returns the header name regardless of the source format
+func (h header) Name() string {
+	if h.HARName != "" {
+		return h.HARName
+	}
+	return h.Key
+}
+
+func headerValue(headers []header, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name(), name) {
+			return h.Value
+		}
+	}
+	return ""
+}
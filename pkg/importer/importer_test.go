@@ -0,0 +1,319 @@
+package importer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+func TestParseHAR(t *testing.T) {
+	har := `{
+		"log": {
+			"entries": [
+				{
+					"request": {
+						"method": "GET",
+						"url": "https://api.example.com/api/v1/users?page=1",
+						"headers": []
+					},
+					"response": {
+						"status": 200,
+						"headers": [
+							{"name": "Content-Type", "value": "application/json"},
+							{"name": "X-Request-Id", "value": "abc"},
+							{"name": "Content-Length", "value": "42"}
+						],
+						"content": {"mimeType": "application/json", "text": "{\"users\":[{\"id\":1}]}"}
+					}
+				},
+				{
+					"request": {
+						"method": "POST",
+						"url": "https://api.example.com/api/v1/login",
+						"postData": {"mimeType": "application/json", "text": "{\"user\":\"alice\"}"}
+					},
+					"response": {
+						"status": 201,
+						"headers": [{"name": "Content-Type", "value": "text/plain"}],
+						"content": {"mimeType": "text/plain", "text": "b2s=", "encoding": "base64"}
+					}
+				},
+				{
+					"request": {"method": "DELETE", "url": "https://api.example.com/api/v1/users?page=1"},
+					"response": {"status": 204, "content": {}}
+				}
+			]
+		}
+	}`
+
+	result, err := ParseHAR([]byte(har))
+	if err != nil {
+		t.Fatalf("ParseHAR failed: %v", err)
+	}
+
+	users, ok := result.Responses["/api/v1/users"]
+	if !ok || users.Method != "GET" || len(users.Responses) != 1 {
+		t.Fatalf("Unexpected users mock: %+v", users)
+	}
+	resp := users.Responses[0]
+	if resp.Status != 200 {
+		t.Errorf("Expected status 200, got %d", resp.Status)
+	}
+	if resp.Headers["X-Request-Id"] != "abc" || resp.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected headers to be preserved, got %v", resp.Headers)
+	}
+	if _, ok := resp.Headers["Content-Length"]; ok {
+		t.Error("Expected Content-Length to be dropped")
+	}
+	body, _ := json.Marshal(resp.Body)
+	if string(body) != `{"users":[{"id":1}]}` {
+		t.Errorf("Unexpected body %s", body)
+	}
+
+	login := result.Responses["/api/v1/login"].Responses[0]
+	if login.Body != "ok" {
+		t.Errorf("Expected base64 text body to be decoded, got %v", login.Body)
+	}
+	input, _ := json.Marshal(login.InputBody)
+	if string(input) != `{"user":"alice"}` {
+		t.Errorf("Expected input_body from request, got %s", input)
+	}
+
+	// A second method on an imported path is reported rather than merged
+	if len(result.Warnings) != 1 {
+		t.Errorf("Expected one warning, got %v", result.Warnings)
+	}
+}
+
+func TestParsePostman(t *testing.T) {
+	collection := `{
+		"info": {
+			"name": "Users API",
+			"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+		},
+		"variable": [{"key": "baseUrl", "value": "https://api.example.com"}],
+		"item": [
+			{
+				"name": "Users",
+				"item": [
+					{
+						"name": "Create user",
+						"request": {
+							"method": "POST",
+							"url": "{{baseUrl}}/users",
+							"body": {"mode": "raw", "raw": "{\"name\":\"{{userName}}\"}", "options": {"raw": {"language": "json"}}}
+						},
+						"response": [
+							{
+								"name": "Created",
+								"originalRequest": {
+									"method": "POST",
+									"url": {"raw": "{{baseUrl}}/users", "path": ["users"]},
+									"body": {"mode": "raw", "raw": "{\"name\":\"Alice\"}", "options": {"raw": {"language": "json"}}}
+								},
+								"code": 201,
+								"header": [{"key": "Content-Type", "value": "application/json"}],
+								"body": "{\"id\": 7, \"name\": \"Alice\"}"
+							},
+							{
+								"name": "Conflict",
+								"code": 409,
+								"header": [],
+								"body": "{\"error\": \"exists\"}"
+							}
+						]
+					},
+					{
+						"name": "Get user",
+						"request": {
+							"method": "GET",
+							"url": {"raw": "{{baseUrl}}/users/:id", "path": ["users", ":id"], "variable": [{"key": "id", "value": "7"}]}
+						},
+						"response": [
+							{"name": "Found", "code": 200, "header": [], "body": "{\"id\": 7}"}
+						]
+					},
+					{
+						"name": "No examples",
+						"request": {"method": "GET", "url": "{{baseUrl}}/health"}
+					}
+				]
+			}
+		]
+	}`
+
+	result, err := ParsePostman([]byte(collection))
+	if err != nil {
+		t.Fatalf("ParsePostman failed: %v", err)
+	}
+
+	users := result.Responses["/users"]
+	if users.Method != "POST" || len(users.Responses) != 2 {
+		t.Fatalf("Unexpected users mock: %+v", users)
+	}
+	created := users.Responses[0]
+	if created.Status != 201 || created.Description != "Users / Create user - Created" {
+		t.Errorf("Unexpected created response: %+v", created)
+	}
+	input, _ := json.Marshal(created.InputBody)
+	if string(input) != `{"name":"Alice"}` {
+		t.Errorf("Expected input_body from the example request, got %s", input)
+	}
+	if users.Responses[1].Status != 409 {
+		t.Errorf("Expected second example to be 409, got %d", users.Responses[1].Status)
+	}
+
+	if user, ok := result.Responses["/users/7"]; !ok || user.Method != "GET" {
+		t.Errorf("Expected path variables to be applied, got %v", result.Responses)
+	}
+
+	if len(result.Warnings) != 1 {
+		t.Errorf("Expected one warning for the item without examples, got %v", result.Warnings)
+	}
+
+	// Other collection versions are rejected
+	if _, err := ParsePostman([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`)); err == nil {
+		t.Error("Expected error for unsupported collection schema")
+	}
+}
+
+func TestWriteMocks(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gomock-import")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	responses := map[string]mock.Response{
+		"/api/v1/users": {
+			Method: "GET",
+			Path:   "/api/v1/users",
+			Responses: []mock.ResponseConfig{
+				{Status: 200, Headers: map[string]string{"X-Total": "1"}, Body: []interface{}{"a"}},
+			},
+		},
+	}
+
+	written, err := WriteMocks(tempDir, responses)
+	if err != nil {
+		t.Fatalf("WriteMocks failed: %v", err)
+	}
+	if len(written) != 1 || written[0] != "api_v1_users.json" {
+		t.Fatalf("Unexpected written files: %v", written)
+	}
+
+	// Written files load back through the regular loader
+	loaded, err := mock.LoadResponses(tempDir)
+	if err != nil {
+		t.Fatalf("LoadResponses failed: %v", err)
+	}
+	users, ok := loaded["/api/v1/users"]
+	if !ok || users.Responses[0].Headers["X-Total"] != "1" {
+		t.Errorf("Unexpected loaded mock: %+v", loaded)
+	}
+}
+
+func TestImportRequestBodies(t *testing.T) {
+	har := `{
+		"log": {
+			"entries": [
+				{
+					"request": {
+						"method": "POST",
+						"url": "https://api.example.com/files/annual%20report",
+						"postData": {"mimeType": "application/x-www-form-urlencoded", "text": "name=Ada&tag=a&tag=b"}
+					},
+					"response": {"status": 201, "content": {}}
+				},
+				{
+					"request": {
+						"method": "PUT",
+						"url": "https://api.example.com/soap",
+						"postData": {"mimeType": "text/xml", "text": "<Envelope/>"}
+					},
+					"response": {"status": 200, "content": {}}
+				}
+			]
+		}
+	}`
+
+	result, err := ParseHAR([]byte(har))
+	if err != nil {
+		t.Fatalf("ParseHAR failed: %v", err)
+	}
+	files, ok := result.Responses["/files/annual report"]
+	if !ok {
+		t.Fatalf("Expected the decoded path the server matches, got %v", result.Responses)
+	}
+	input, _ := json.Marshal(files.Responses[0].InputBody)
+	if string(input) != `{"name":"Ada","tag":["a","b"]}` {
+		t.Errorf("Expected input_body from the form, got %s", input)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "text/xml") {
+		t.Errorf("Expected a warning for the unmatched XML body, got %v", result.Warnings)
+	}
+
+	collection := `{
+		"info": {"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [
+			{
+				"name": "Login",
+				"request": {
+					"method": "POST",
+					"url": "https://api.example.com/login",
+					"body": {"mode": "urlencoded", "urlencoded": [
+						{"key": "user", "value": "ada"},
+						{"key": "debug", "value": "1", "disabled": true}
+					]}
+				},
+				"response": [{"name": "OK", "code": 200, "header": [], "body": ""}]
+			},
+			{
+				"name": "Upload",
+				"request": {"method": "POST", "url": "https://api.example.com/upload", "body": {"mode": "formdata"}},
+				"response": [{"name": "OK", "code": 200, "header": [], "body": ""}]
+			}
+		]
+	}`
+	result, err = ParsePostman([]byte(collection))
+	if err != nil {
+		t.Fatalf("ParsePostman failed: %v", err)
+	}
+	input, _ = json.Marshal(result.Responses["/login"].Responses[0].InputBody)
+	if string(input) != `{"user":"ada"}` {
+		t.Errorf("Expected input_body from the enabled form fields, got %s", input)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "formdata") {
+		t.Errorf("Expected a warning for the form-data body, got %v", result.Warnings)
+	}
+}
+
+func TestWriteMocksFileNameCollisions(t *testing.T) {
+	responses := map[string]mock.Response{
+		"/api/users": {Method: "GET", Path: "/api/users", Responses: []mock.ResponseConfig{{Status: 200}}},
+		"/api_users": {Method: "GET", Path: "/api_users", Responses: []mock.ResponseConfig{{Status: 201}}},
+		"/_auth":     {Method: "GET", Path: "/_auth", Responses: []mock.ResponseConfig{{Status: 202}}},
+	}
+
+	dir := t.TempDir()
+	written, err := WriteMocks(dir, responses)
+	if err != nil {
+		t.Fatalf("WriteMocks failed: %v", err)
+	}
+	want := []string{"_auth_2.json", "api_users.json", "api_users_2.json"}
+	if strings.Join(written, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, written)
+	}
+
+	loaded, err := mock.LoadResponses(dir)
+	if err != nil {
+		t.Fatalf("LoadResponses failed: %v", err)
+	}
+	if len(loaded) != 3 {
+		t.Errorf("Expected every endpoint to survive the import, got %v", loaded)
+	}
+}
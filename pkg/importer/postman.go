@@ -0,0 +1,269 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+// postmanCollection represents the parts of a Postman collection v2.1 used
+// for import
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item"`
+	Request  *postmanRequest   `json:"request"`
+	Response []postmanResponse `json:"response"`
+}
+
+type postmanRequest struct {
+	Method string       `json:"method"`
+	Header []header     `json:"header"`
+	URL    postmanURL   `json:"url"`
+	Body   *postmanBody `json:"body"`
+}
+
+type postmanBody struct {
+	Mode       string   `json:"mode"`
+	Raw        string   `json:"raw"`
+	URLEncoded []header `json:"urlencoded"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanResponse struct {
+	Name            string          `json:"name"`
+	OriginalRequest *postmanRequest `json:"originalRequest"`
+	Code            int             `json:"code"`
+	Header          []header        `json:"header"`
+	Body            string          `json:"body"`
+}
+
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// postmanURL accepts both the string and the object form of a request URL
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Path     []json.RawMessage `json:"path"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+// UnmarshalJSON accepts a plain URL string or a URL object
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		u.Raw = raw
+		return nil
+	}
+	type plain postmanURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+var postmanVariablePattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+
+// LoadPostman reads a Postman collection from disk and converts it into mocks
+func LoadPostman(path string) (*Result, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostman(content)
+}
+
+// ParsePostman converts the saved example responses of a Postman collection
+// v2.1 into mocks. Requests without saved examples are skipped because there
+// is no response to replay.
+func ParsePostman(content []byte) (*Result, error) {
+	var collection postmanCollection
+	if err := json.Unmarshal(content, &collection); err != nil {
+		return nil, fmt.Errorf("invalid Postman collection: %v", err)
+	}
+	if collection.Info.Schema != "" && !strings.Contains(collection.Info.Schema, "v2.1") {
+		return nil, fmt.Errorf("unsupported Postman collection schema %s, expected v2.1", collection.Info.Schema)
+	}
+
+	variables := make(map[string]string)
+	for _, v := range collection.Variable {
+		variables[v.Key] = v.Value
+	}
+
+	result := newResult()
+	importPostmanItems(result, collection.Item, "", variables)
+	return result, nil
+}
+
+func importPostmanItems(result *Result, items []postmanItem, folder string, variables map[string]string) {
+	for _, item := range items {
+		name := item.Name
+		if folder != "" {
+			name = folder + " / " + item.Name
+		}
+
+		// Folders nest further items
+		if item.Request == nil {
+			importPostmanItems(result, item.Item, name, variables)
+			continue
+		}
+
+		if len(item.Response) == 0 {
+			result.warnf("skipped %q: no saved example responses", name)
+			continue
+		}
+
+		for _, example := range item.Response {
+			req := item.Request
+			if example.OriginalRequest != nil {
+				req = example.OriginalRequest
+			}
+
+			path, err := req.URL.path(variables)
+			if err != nil {
+				result.warnf("skipped %q example %q: %v", name, example.Name, err)
+				continue
+			}
+			if example.Code == 0 {
+				result.warnf("skipped %q example %q: missing status code", name, example.Name)
+				continue
+			}
+
+			method := req.Method
+			if method == "" {
+				method = "GET"
+			}
+
+			description := name
+			if example.Name != "" {
+				description += " - " + example.Name
+			}
+
+			resp := mock.ResponseConfig{
+				Status:      example.Code,
+				Headers:     responseHeaders(example.Header),
+				Body:        decodeBody(example.Body, headerValue(example.Header, "Content-Type"), ""),
+				Description: description,
+			}
+			if req.Body != nil {
+				resp.InputBody = postmanInputBody(result, description, req, variables)
+			}
+
+			result.add(method, path, resp)
+		}
+	}
+}
+
+// postmanInputBody converts a JSON or form-encoded request body into an
+// input_body matcher, warning about bodies that can't be matched
+func postmanInputBody(result *Result, description string, req *postmanRequest, variables map[string]string) interface{} {
+	body := req.Body
+	switch {
+	case body.Mode == "", body.Mode == "raw" && strings.TrimSpace(body.Raw) == "":
+		return nil
+	case body.Mode == "raw" && (body.Options.Raw.Language == "json" || isJSON(headerValue(req.Header, "Content-Type"))):
+		return decodeBody(substitute(body.Raw, variables), "application/json", "")
+	case body.Mode == "raw" && isForm(headerValue(req.Header, "Content-Type")):
+		form, err := decodeForm(substitute(body.Raw, variables))
+		if err != nil {
+			result.warnf("%q: request body not matched: invalid form data", description)
+			return nil
+		}
+		return form
+	case body.Mode == "urlencoded":
+		values := make(map[string][]string)
+		for _, field := range body.URLEncoded {
+			if !field.Disabled {
+				values[field.Key] = append(values[field.Key], substitute(field.Value, variables))
+			}
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		form := make(map[string]interface{}, len(values))
+		for key, list := range values {
+			form[key] = formField(list)
+		}
+		return form
+	case body.Mode == "raw":
+		result.warnf("%q: request body not matched: unsupported content type %q", description, headerValue(req.Header, "Content-Type"))
+	default:
+		result.warnf("%q: request body not matched: unsupported body mode %q", description, body.Mode)
+	}
+	return nil
+}
+
+// path returns the request path with collection and path variables applied
+func (u postmanURL) path(variables map[string]string) (string, error) {
+	var path string
+	if len(u.Path) > 0 {
+		segments := make([]string, 0, len(u.Path))
+		for _, raw := range u.Path {
+			var segment string
+			if err := json.Unmarshal(raw, &segment); err != nil {
+				// Segments may also be objects of the form {"value": "..."}
+				var obj struct {
+					Value string `json:"value"`
+				}
+				if err := json.Unmarshal(raw, &obj); err != nil {
+					return "", fmt.Errorf("invalid path segment %s", string(raw))
+				}
+				segment = obj.Value
+			}
+			segments = append(segments, segment)
+		}
+		path = "/" + strings.Join(segments, "/")
+	} else {
+		raw := substitute(u.Raw, variables)
+		if raw == "" {
+			return "", fmt.Errorf("request has no URL")
+		}
+		// Drop the scheme and host, which may still hold unresolved variables
+		if i := strings.Index(raw, "://"); i >= 0 {
+			raw = raw[i+3:]
+		}
+		if i := strings.Index(raw, "/"); i >= 0 {
+			raw = raw[i:]
+		} else {
+			raw = "/"
+		}
+		path = strings.SplitN(strings.SplitN(raw, "?", 2)[0], "#", 2)[0]
+	}
+
+	path = substitute(path, variables)
+	for _, v := range u.Variable {
+		if v.Value != "" {
+			path = strings.ReplaceAll(path, ":"+v.Key, v.Value)
+		}
+	}
+	if path == "" {
+		path = "/"
+	}
+	return path, nil
+}
+
+// substitute replaces {{name}} placeholders with collection variables
+func substitute(text string, variables map[string]string) string {
+	return postmanVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := strings.TrimSpace(match[2 : len(match)-2])
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -0,0 +1,229 @@
+package journal
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultLimit is the number of entries kept when no limit is given
+const DefaultLimit = 1000
+
+// Unlimited is a limit keeping every entry, for short-lived servers such as
+// the ones started by tests
+const Unlimited = -1
+
+// Message directions
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+// Entry is a recorded request and the response it received
+type Entry struct {
+	ID         int64       `json:"id"`
+	Time       time.Time   `json:"time"`
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Query      string      `json:"query,omitempty"`
+	Protocol   string      `json:"protocol"`
+	RemoteAddr string      `json:"remote_addr,omitempty"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Status     int         `json:"status"`
+	DurationMS float64     `json:"duration_ms"`
+
+	// Unmatched is set when no mock answered the request
+	Unmatched bool `json:"unmatched,omitempty"`
+
+	// Endpoint is the mocked endpoint that answered, Outcome how its
+	// response was selected and Response describes the response served
+	Endpoint string `json:"endpoint,omitempty"`
+	Outcome  string `json:"outcome,omitempty"`
+	Response string `json:"response,omitempty"`
+
+	// Messages holds the conversation of streaming connections such as
+	// WebSockets
+	Messages []Message `json:"messages,omitempty"`
+}
+
+// Message is a single message exchanged over a streaming connection
+type Message struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Type      string    `json:"type"`
+	Data      string    `json:"data,omitempty"`
+	CloseCode int       `json:"close_code,omitempty"`
+}
+
+// Journal keeps the most recent requests in memory
+type Journal struct {
+	mu          sync.Mutex
+	entries     []*Entry
+	limit       int
+	nextID      int64
+	subscribers map[chan Entry]struct{}
+}
+
+// New creates a journal keeping at most limit entries, or every entry when
+// limit is Unlimited
+func New(limit int) *Journal {
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	return &Journal{limit: limit}
+}
+
+// Record adds an entry, assigning its id and time, and returns the id
+func (j *Journal) Record(entry Entry) int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.nextID++
+	entry.ID = j.nextID
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	j.entries = append(j.entries, &entry)
+	if j.limit > 0 && len(j.entries) > j.limit {
+		j.entries = j.entries[len(j.entries)-j.limit:]
+	}
+	return entry.ID
+}
+
+// Complete records the outcome of the request with the given id and sends
+// the entry to subscribers
+func (j *Journal) Complete(id int64, status int, duration time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e := j.find(id)
+	if e == nil {
+		return
+	}
+	e.Status = status
+	e.DurationMS = float64(duration.Microseconds()) / 1000
+
+	for ch := range j.subscribers {
+		select {
+		case ch <- copyEntry(e):
+		default:
+			// Slow subscribers miss entries rather than hold up requests
+		}
+	}
+}
+
+// SetMatch records the endpoint that answered the request with the given id,
+// how its response was selected and which response was served
+func (j *Journal) SetMatch(id int64, endpoint, outcome, response string) {
+	j.update(id, func(e *Entry) {
+		e.Endpoint = endpoint
+		e.Outcome = outcome
+		e.Response = response
+	})
+}
+
+// Subscribe returns a channel receiving every entry as it completes and a
+// function ending the subscription. Subscribers more than buffer entries
+// behind miss entries.
+func (j *Journal) Subscribe(buffer int) (<-chan Entry, func()) {
+	ch := make(chan Entry, buffer)
+
+	j.mu.Lock()
+	if j.subscribers == nil {
+		j.subscribers = make(map[chan Entry]struct{})
+	}
+	j.subscribers[ch] = struct{}{}
+	j.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			j.mu.Lock()
+			delete(j.subscribers, ch)
+			j.mu.Unlock()
+		})
+	}
+}
+
+// MarkUnmatched flags the entry with the given id as not answered by a mock
+func (j *Journal) MarkUnmatched(id int64) {
+	j.update(id, func(e *Entry) {
+		e.Unmatched = true
+	})
+}
+
+// AddMessage appends a streaming message to the entry with the given id
+func (j *Journal) AddMessage(id int64, msg Message) {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	j.update(id, func(e *Entry) {
+		e.Messages = append(e.Messages, msg)
+	})
+}
+
+// Entries returns a copy of the recorded entries, oldest first
+func (j *Journal) Entries() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]Entry, len(j.entries))
+	for i, e := range j.entries {
+		entries[i] = copyEntry(e)
+	}
+	return entries
+}
+
+// Clear removes all entries
+func (j *Journal) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = nil
+}
+
+// Restore replaces the entries, such as with entries saved by a previous
+// run. New entries are numbered after the restored ones.
+func (j *Journal) Restore(entries []Entry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.limit > 0 && len(entries) > j.limit {
+		entries = entries[len(entries)-j.limit:]
+	}
+	j.entries = make([]*Entry, len(entries))
+	for i := range entries {
+		entry := entries[i]
+		j.entries[i] = &entry
+		if entry.ID > j.nextID {
+			j.nextID = entry.ID
+		}
+	}
+}
+
+func (j *Journal) update(id int64, fn func(*Entry)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if e := j.find(id); e != nil {
+		fn(e)
+	}
+}
+
+// find returns the entry with the given id. The caller must hold j.mu.
+func (j *Journal) find(id int64) *Entry {
+	// Recent entries are updated most, so search from the end
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		if j.entries[i].ID == id {
+			return j.entries[i]
+		}
+	}
+	return nil
+}
+
+// copyEntry copies an entry so it can be read without holding j.mu
+func copyEntry(e *Entry) Entry {
+	entry := *e
+	entry.Messages = append([]Message(nil), e.Messages...)
+	return entry
+}
@@ -0,0 +1,101 @@
+package journal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	j := New(2)
+
+	first := j.Record(Entry{Method: "GET", Path: "/a"})
+	second := j.Record(Entry{Method: "GET", Path: "/ws"})
+	j.Complete(second, 101, 5*time.Millisecond)
+	j.AddMessage(second, Message{Direction: DirectionOut, Type: "text", Data: "hello"})
+	j.MarkUnmatched(first)
+
+	entries := j.Entries()
+	if len(entries) != 2 || entries[0].ID != first || entries[1].Status != 101 {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+	if !entries[0].Unmatched || entries[1].Unmatched {
+		t.Errorf("Expected only the first entry to be unmatched, got %+v", entries)
+	}
+	if len(entries[1].Messages) != 1 || entries[1].Messages[0].Data != "hello" {
+		t.Errorf("Expected recorded message, got %+v", entries[1].Messages)
+	}
+
+	// Returned entries are copies
+	entries[1].Messages[0].Data = "changed"
+	if j.Entries()[1].Messages[0].Data != "hello" {
+		t.Error("Expected Entries to return copies")
+	}
+
+	// The oldest entry is dropped past the limit
+	j.Record(Entry{Method: "POST", Path: "/b"})
+	entries = j.Entries()
+	if len(entries) != 2 || entries[0].ID != second {
+		t.Errorf("Expected oldest entry to be dropped, got %+v", entries)
+	}
+
+	j.Clear()
+	if len(j.Entries()) != 0 {
+		t.Error("Expected journal to be empty after Clear")
+	}
+}
+
+func TestJournalUnlimited(t *testing.T) {
+	j := New(Unlimited)
+	for i := 0; i < DefaultLimit+1; i++ {
+		j.Record(Entry{Method: "GET", Path: "/a"})
+	}
+	if n := len(j.Entries()); n != DefaultLimit+1 {
+		t.Errorf("Expected every entry to be kept, got %d", n)
+	}
+}
+
+func TestJournalRestore(t *testing.T) {
+	j := New(2)
+	j.Restore([]Entry{{ID: 3, Path: "/a"}, {ID: 7, Path: "/b"}, {ID: 9, Path: "/c"}})
+
+	entries := j.Entries()
+	if len(entries) != 2 || entries[0].ID != 7 {
+		t.Fatalf("Expected the newest restored entries, got %+v", entries)
+	}
+	if id := j.Record(Entry{Path: "/d"}); id != 10 {
+		t.Errorf("Expected new entries to follow restored ids, got %d", id)
+	}
+}
+
+func TestJournalSubscribe(t *testing.T) {
+	j := New(0)
+	entries, cancel := j.Subscribe(1)
+
+	id := j.Record(Entry{Method: "GET", Path: "/users"})
+	j.SetMatch(id, "/users", "matched", "Success response")
+	j.Complete(id, 200, time.Millisecond)
+
+	select {
+	case entry := <-entries:
+		if entry.ID != id || entry.Status != 200 || entry.Endpoint != "/users" || entry.Outcome != "matched" || entry.Response != "Success response" {
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+	default:
+		t.Fatal("Expected the completed entry to be sent")
+	}
+
+	// Entries beyond the buffer are dropped instead of blocking
+	j.Complete(j.Record(Entry{Path: "/a"}), 200, 0)
+	j.Complete(j.Record(Entry{Path: "/b"}), 200, 0)
+	if entry := <-entries; entry.Path != "/a" {
+		t.Errorf("Expected /a, got %+v", entry)
+	}
+
+	cancel()
+	j.Complete(j.Record(Entry{Path: "/c"}), 200, 0)
+	select {
+	case entry := <-entries:
+		t.Errorf("Expected no entries after cancel, got %+v", entry)
+	default:
+	}
+}
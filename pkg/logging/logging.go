@@ -0,0 +1,142 @@
+// Package logging builds the server logger from its configuration and
+// redacts sensitive values from logged requests and responses.
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Log outputs
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputNone   = "none"
+)
+
+// Log encodings
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Options configures a logger. The zero value logs INFO and above to stdout
+// in the console format.
+type Options struct {
+	// Level is debug, info, warn or error
+	Level string
+
+	// Format is json or console. When empty, files are written as JSON and
+	// the terminal in the console format.
+	Format string
+
+	// Outputs lists where logs go: stdout, stderr, file or none
+	Outputs []string
+
+	// File is the log file written by the file output
+	File string
+
+	// MaxSizeMB rotates the file once it reaches this size; zero never
+	// rotates
+	MaxSizeMB int
+
+	// MaxBackups and MaxAgeDays limit the rotated files kept; zero keeps
+	// them all
+	MaxBackups int
+	MaxAgeDays int
+
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// New creates a logger writing to every configured output
+func New(opts Options) (*zap.Logger, error) {
+	level := zap.InfoLevel
+	if opts.Level != "" {
+		if err := level.Set(opts.Level); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", opts.Level)
+		}
+	}
+	if opts.Format != "" && opts.Format != FormatJSON && opts.Format != FormatConsole {
+		return nil, fmt.Errorf("invalid log format %q: expected %s or %s", opts.Format, FormatJSON, FormatConsole)
+	}
+
+	outputs := opts.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{OutputStdout}
+	}
+
+	var cores []zapcore.Core
+	for _, output := range outputs {
+		var sink zapcore.WriteSyncer
+		format := opts.Format
+		switch output {
+		case OutputNone:
+			continue
+		case OutputStdout:
+			sink = zapcore.Lock(os.Stdout)
+		case OutputStderr:
+			sink = zapcore.Lock(os.Stderr)
+		case OutputFile:
+			file, err := openFile(opts)
+			if err != nil {
+				return nil, err
+			}
+			sink = file
+			if format == "" {
+				format = FormatJSON
+			}
+		default:
+			return nil, fmt.Errorf("invalid log output %q: expected stdout, stderr, file or none", output)
+		}
+		cores = append(cores, zapcore.NewCore(encoder(format), sink, level))
+	}
+	if len(cores) == 0 {
+		return zap.NewNop(), nil
+	}
+
+	return zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel)), nil
+}
+
+func encoder(format string) zapcore.Encoder {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "timestamp"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if format == FormatJSON {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
+// openFile opens the log file, through a rotating writer when rotation is
+// configured
+func openFile(opts Options) (zapcore.WriteSyncer, error) {
+	if opts.File == "" {
+		return nil, fmt.Errorf("the file log output requires a log file")
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %v", err)
+	}
+
+	if opts.MaxSizeMB > 0 {
+		return zapcore.AddSync(&lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.MaxSizeMB,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAgeDays,
+			Compress:   opts.Compress,
+		}), nil
+	}
+
+	file, err := os.OpenFile(opts.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %v", err)
+	}
+	return zapcore.AddSync(file), nil
+}
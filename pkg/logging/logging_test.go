@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nested", "mock-server.log")
+	logger, err := New(Options{Level: "debug", Outputs: []string{OutputFile}, File: file, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	logger.Debug("debug line")
+	logger.Sync()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Expected a JSON log line, got %q", data)
+	}
+	if entry["msg"] != "debug line" || entry["level"] != "debug" {
+		t.Errorf("Unexpected log entry %v", entry)
+	}
+}
+
+func TestNewLevel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mock-server.log")
+	logger, err := New(Options{Level: "warn", Format: FormatConsole, Outputs: []string{OutputFile}, File: file})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+	logger.Sync()
+
+	data, _ := os.ReadFile(file)
+	if strings.Contains(string(data), "hidden") || !strings.Contains(string(data), "shown") {
+		t.Errorf("Expected only warnings in the log, got %q", data)
+	}
+	if strings.HasPrefix(string(data), "{") {
+		t.Errorf("Expected the console format, got %q", data)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Level: "verbose"},
+		{Format: "xml"},
+		{Outputs: []string{"syslog"}},
+		{Outputs: []string{OutputFile}},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("Expected error for %+v", opts)
+		}
+	}
+}
+
+func TestNewNone(t *testing.T) {
+	logger, err := New(Options{Outputs: []string{OutputNone}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if logger.Core().Enabled(0) {
+		t.Error("Expected a no-op logger")
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor(BodyOptions{MaxBytes: 128, RedactFields: []string{"password", "token"}})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Set("Accept", "application/json")
+	headers := r.Headers(header)
+	if headers["Authorization"] != Redacted || headers["Accept"] != "application/json" {
+		t.Errorf("Unexpected headers %v", headers)
+	}
+
+	body := r.Body([]byte(`{"user":"ada","password":"hunter2","session":{"Token":"abc"}}`), false)
+	if strings.Contains(body, "hunter2") || strings.Contains(body, "abc") || !strings.Contains(body, "ada") {
+		t.Errorf("Expected fields to be redacted, got %s", body)
+	}
+
+	// Truncated JSON can't be decoded, so fields are found by pattern
+	body = r.Body([]byte(`{"user":"ada","password":"hunter2","bio":"`), true)
+	if strings.Contains(body, "hunter2") || !strings.HasSuffix(body, "...(truncated)") {
+		t.Errorf("Expected a redacted truncated body, got %s", body)
+	}
+
+	if body := r.Body([]byte(strings.Repeat("a", 200)), false); len(body) != 128+len("...(truncated)") {
+		t.Errorf("Expected the body to be cut at 128 bytes, got %d bytes", len(body))
+	}
+	if body := r.Body([]byte{0xff, 0xfe, 0x00}, false); body != "[binary body]" {
+		t.Errorf("Expected a binary placeholder, got %q", body)
+	}
+
+	// Redacted copies keep the header form and the whole body
+	redacted := r.RedactHeader(header)
+	if redacted.Get("Authorization") != Redacted || header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Expected a redacted copy of the headers, got %v", redacted)
+	}
+	long := `{"password":"hunter2","bio":"` + strings.Repeat("a", 200) + `"}`
+	if body := string(r.RedactBody([]byte(long))); strings.Contains(body, "hunter2") || len(body) < 200 {
+		t.Errorf("Expected a redacted uncut body, got %s", body)
+	}
+}
@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted replaces sensitive values in logs
+const Redacted = "[REDACTED]"
+
+// DefaultRedactHeaders are redacted when no header rules are configured
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// BodyOptions configures logging of request and response bodies
+type BodyOptions struct {
+	// MaxBytes limits how much of each body is logged
+	MaxBytes int
+
+	// RedactHeaders lists header names whose values are replaced,
+	// DefaultRedactHeaders when empty
+	RedactHeaders []string
+
+	// RedactFields lists JSON field names whose values are replaced at any
+	// depth of a body
+	RedactFields []string
+}
+
+// DefaultMaxBodyBytes is the body size logged when MaxBytes is not set
+const DefaultMaxBodyBytes = 4096
+
+// Redactor applies redaction rules to logged headers and bodies
+type Redactor struct {
+	maxBytes int
+	headers  map[string]bool
+	fields   map[string]bool
+
+	// fieldPattern finds sensitive fields in bodies that can't be decoded,
+	// such as truncated JSON
+	fieldPattern *regexp.Regexp
+}
+
+// NewRedactor creates a redactor for the body logging options. Header and
+// field names are matched case-insensitively.
+func NewRedactor(opts BodyOptions) *Redactor {
+	r := &Redactor{
+		maxBytes: opts.MaxBytes,
+		headers:  make(map[string]bool),
+		fields:   make(map[string]bool),
+	}
+	if r.maxBytes <= 0 {
+		r.maxBytes = DefaultMaxBodyBytes
+	}
+	headers := opts.RedactHeaders
+	if len(headers) == 0 {
+		headers = DefaultRedactHeaders
+	}
+	for _, name := range headers {
+		r.headers[strings.ToLower(name)] = true
+	}
+	var quoted []string
+	for _, name := range opts.RedactFields {
+		r.fields[strings.ToLower(name)] = true
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	if len(quoted) > 0 {
+		r.fieldPattern = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	}
+	return r
+}
+
+// MaxBytes returns how much of a body is logged
+func (r *Redactor) MaxBytes() int {
+	return r.maxBytes
+}
+
+// Headers returns the headers with sensitive values replaced, one entry per
+// header with multiple values joined
+func (r *Redactor) Headers(header http.Header) map[string]string {
+	redacted := make(map[string]string, len(header))
+	for name, values := range header {
+		if r.headers[strings.ToLower(name)] {
+			redacted[name] = Redacted
+			continue
+		}
+		redacted[name] = strings.Join(values, ", ")
+	}
+	return redacted
+}
+
+// RedactHeader returns a copy of the headers with sensitive values replaced,
+// keeping every header in its http.Header form
+func (r *Redactor) RedactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name := range redacted {
+		if r.headers[strings.ToLower(name)] {
+			redacted[name] = []string{Redacted}
+		}
+	}
+	return redacted
+}
+
+// RedactBody returns the body with its sensitive JSON fields replaced and
+// without cutting it
+func (r *Redactor) RedactBody(body []byte) []byte {
+	if len(body) == 0 || len(r.fields) == 0 {
+		return body
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if data, err := json.Marshal(r.redactValue(value)); err == nil {
+			return data
+		}
+		return body
+	}
+	return r.fieldPattern.ReplaceAll(body, []byte(`${1}"`+Redacted+`"`))
+}
+
+// Body returns a body for logging. JSON bodies have their sensitive fields
+// replaced; bodies longer than the limit are cut and marked as truncated.
+func (r *Redactor) Body(body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	body = r.RedactBody(body)
+
+	if len(body) > r.maxBytes {
+		body = body[:r.maxBytes]
+		truncated = true
+	}
+	if !utf8.Valid(body) {
+		return "[binary body]"
+	}
+	if truncated {
+		return string(body) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func (r *Redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = Redacted
+			} else {
+				v[key] = r.redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+	}
+	return value
+}
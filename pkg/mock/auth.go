@@ -0,0 +1,479 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Authentication schemes
+const (
+	AuthAPIKey = "api_key"
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+
+	// AuthNone turns off the folder's default auth for an endpoint
+	AuthNone = "none"
+)
+
+// AuthFile is the file holding the auth requirement of every endpoint in a
+// mocks folder that doesn't declare its own
+const AuthFile = "_auth.json"
+
+// DefaultAPIKeyHeader carries API keys when no header or query parameter is
+// configured
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// authLeeway is the clock skew allowed when checking token expiry
+const authLeeway = time.Minute
+
+var (
+	// ErrMissingCredentials means the request carries no credentials
+	ErrMissingCredentials = errors.New("missing credentials")
+
+	// ErrInvalidCredentials means the credentials are unknown or the token
+	// does not verify
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrForbidden means the credentials are valid but lack the required
+	// claims or scopes
+	ErrForbidden = errors.New("insufficient permissions")
+)
+
+// hmacAlgorithms and keyAlgorithms are the signatures accepted from tokens
+// verified with a secret and with a JWKS file
+var (
+	hmacAlgorithms = []jose.SignatureAlgorithm{jose.HS256, jose.HS384, jose.HS512}
+	keyAlgorithms  = []jose.SignatureAlgorithm{
+		jose.RS256, jose.RS384, jose.RS512,
+		jose.PS256, jose.PS384, jose.PS512,
+		jose.ES256, jose.ES384, jose.ES512,
+		jose.EdDSA,
+	}
+)
+
+// AuthConfig requires requests to carry credentials. Requests without valid
+// credentials get Unauthorized, or a 401 with a JSON error; requests whose
+// credentials lack the required claims or scopes get Forbidden, or a 403.
+type AuthConfig struct {
+	// Type is api_key, basic or bearer, or none to serve the endpoint
+	// without the folder's default auth
+	Type  string `json:"type"`
+	Realm string `json:"realm,omitempty"`
+
+	// Header or Query carry API keys, X-API-Key by default; Keys lists the
+	// accepted keys
+	Header string   `json:"header,omitempty"`
+	Query  string   `json:"query,omitempty"`
+	Keys   []string `json:"keys,omitempty"`
+
+	// Users maps the Basic usernames to their passwords
+	Users map[string]string `json:"users,omitempty"`
+
+	// Bearer tokens are JWTs signed with the HMAC Secret or a key of the
+	// JWKS file, relative to the mocks folder, and must come from Issuer
+	// and be meant for Audience when those are set
+	Secret   string `json:"secret,omitempty"`
+	JWKSFile string `json:"jwks_file,omitempty"`
+	Issuer   string `json:"issuer,omitempty"`
+	Audience string `json:"audience,omitempty"`
+
+	// Claims and Scopes must all be granted by the credentials
+	Claims map[string]interface{} `json:"claims,omitempty"`
+	Scopes []string               `json:"scopes,omitempty"`
+
+	Unauthorized *ResponseConfig `json:"unauthorized,omitempty"`
+	Forbidden    *ResponseConfig `json:"forbidden,omitempty"`
+
+	keys *jose.JSONWebKeySet
+}
+
+// Validate checks the auth settings
+func (c *AuthConfig) Validate() error {
+	switch c.Type {
+	case AuthNone:
+	case AuthAPIKey:
+		if len(c.Keys) == 0 {
+			return fmt.Errorf("api_key auth needs keys")
+		}
+		if c.Header != "" && c.Query != "" {
+			return fmt.Errorf("api_key auth takes a header or a query parameter, not both")
+		}
+	case AuthBasic:
+		if len(c.Users) == 0 {
+			return fmt.Errorf("basic auth needs users")
+		}
+	case AuthBearer:
+		if (c.Secret == "") == (c.JWKSFile == "") {
+			return fmt.Errorf("bearer auth needs either a secret or a jwks_file")
+		}
+	default:
+		return fmt.Errorf("unknown auth type %q, expected api_key, basic, bearer or none", c.Type)
+	}
+	return nil
+}
+
+// load validates the settings and reads the JWKS file, relative to folder
+func (c *AuthConfig) load(folder string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if c.JWKSFile == "" {
+		return nil
+	}
+
+	path := c.JWKSFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(folder, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("jwks_file: %v", err)
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(content, &keys); err != nil {
+		return fmt.Errorf("jwks_file: %v", err)
+	}
+	if len(keys.Keys) == 0 {
+		return fmt.Errorf("jwks_file: no keys")
+	}
+	c.keys = &keys
+	return nil
+}
+
+// loadFolderAuth reads the AuthFile of a mocks folder, returning nil when
+// the folder has none
+func loadFolderAuth(folder string) (*AuthConfig, error) {
+	content, err := os.ReadFile(filepath.Join(folder, AuthFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config AuthConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("%s: %v", AuthFile, err)
+	}
+	if err := config.load(folder); err != nil {
+		return nil, fmt.Errorf("%s: %v", AuthFile, err)
+	}
+	return &config, nil
+}
+
+// Enabled reports whether requests need credentials
+func (c *AuthConfig) Enabled() bool {
+	return c != nil && c.Type != AuthNone
+}
+
+// Challenge returns the WWW-Authenticate header asking for credentials. err
+// is the reason the request was refused.
+func (c *AuthConfig) Challenge(err error) string {
+	realm := c.Realm
+	if realm == "" {
+		realm = "gomock"
+	}
+	switch c.Type {
+	case AuthBasic:
+		return fmt.Sprintf("Basic realm=%q", realm)
+	case AuthBearer:
+		switch {
+		case errors.Is(err, ErrForbidden):
+			return fmt.Sprintf("Bearer realm=%q, error=\"insufficient_scope\"", realm)
+		case errors.Is(err, ErrInvalidCredentials):
+			return fmt.Sprintf("Bearer realm=%q, error=\"invalid_token\"", realm)
+		}
+		return fmt.Sprintf("Bearer realm=%q", realm)
+	}
+	if c.Query != "" {
+		return fmt.Sprintf("ApiKey realm=%q, query=%q", realm, c.Query)
+	}
+	return fmt.Sprintf("ApiKey realm=%q, header=%q", realm, c.header())
+}
+
+// Authenticate checks the credentials of the request and returns the claims
+// they grant: the token claims of bearer auth, the username as sub of basic
+// auth and the key as api_key of API keys. The error wraps
+// ErrMissingCredentials, ErrInvalidCredentials or ErrForbidden.
+func (c *AuthConfig) Authenticate(r *http.Request) (map[string]interface{}, error) {
+	var claims map[string]interface{}
+	var err error
+	switch c.Type {
+	case AuthAPIKey:
+		claims, err = c.authenticateKey(r)
+	case AuthBasic:
+		claims, err = c.authenticateBasic(r)
+	case AuthBearer:
+		claims, err = c.authenticateBearer(r)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for name, want := range c.Claims {
+		if !MatchesClaim(claims[name], want) {
+			return claims, fmt.Errorf("%w: claim %s", ErrForbidden, name)
+		}
+	}
+	granted := Scopes(claims)
+	for _, scope := range c.Scopes {
+		if !contains(granted, scope) {
+			return claims, fmt.Errorf("%w: scope %s", ErrForbidden, scope)
+		}
+	}
+	return claims, nil
+}
+
+func (c *AuthConfig) header() string {
+	if c.Header != "" {
+		return c.Header
+	}
+	return DefaultAPIKeyHeader
+}
+
+func (c *AuthConfig) authenticateKey(r *http.Request) (map[string]interface{}, error) {
+	var key string
+	if c.Query != "" {
+		key = r.URL.Query().Get(c.Query)
+	} else {
+		key = r.Header.Get(c.header())
+	}
+	if key == "" {
+		return nil, ErrMissingCredentials
+	}
+	if !contains(c.Keys, key) {
+		return nil, ErrInvalidCredentials
+	}
+	return map[string]interface{}{"api_key": key}, nil
+}
+
+func (c *AuthConfig) authenticateBasic(r *http.Request) (map[string]interface{}, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrMissingCredentials
+	}
+	if want, known := c.Users[username]; !known || want != password {
+		return nil, ErrInvalidCredentials
+	}
+	return map[string]interface{}{"sub": username}, nil
+}
+
+func (c *AuthConfig) authenticateBearer(r *http.Request) (map[string]interface{}, error) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	algorithms := hmacAlgorithms
+	if c.keys != nil {
+		algorithms = keyAlgorithms
+	}
+	parsed, err := jwt.ParseSigned(strings.TrimSpace(token), algorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	var standard jwt.Claims
+	var claims map[string]interface{}
+	if err := parsed.Claims(c.verificationKey(parsed), &standard, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	expected := jwt.Expected{Issuer: c.Issuer, Time: time.Now()}
+	if c.Audience != "" {
+		expected.AnyAudience = jwt.Audience{c.Audience}
+	}
+	if err := standard.ValidateWithLeeway(expected, authLeeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return claims, nil
+}
+
+// verificationKey returns the key checking the token signature: the secret,
+// or the JWKS key named by the token's kid, or the only key of the set
+func (c *AuthConfig) verificationKey(token *jwt.JSONWebToken) interface{} {
+	if c.keys == nil {
+		return []byte(c.Secret)
+	}
+	var kid string
+	if len(token.Headers) > 0 {
+		kid = token.Headers[0].KeyID
+	}
+	if kid != "" {
+		if keys := c.keys.Key(kid); len(keys) > 0 {
+			return keys[0]
+		}
+		return nil
+	}
+	if len(c.keys.Keys) == 1 {
+		return c.keys.Keys[0]
+	}
+	return c.keys
+}
+
+// Scopes returns the scopes granted by the space separated scope claim or
+// the scp list
+func Scopes(claims map[string]interface{}) []string {
+	var scopes []string
+	if scope, ok := claims["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(scope)...)
+	}
+	switch scp := claims["scp"].(type) {
+	case string:
+		scopes = append(scopes, strings.Fields(scp)...)
+	case []interface{}:
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// MatchesClaim reports whether a claim value equals want, compared as JSON.
+// List claims such as aud or roles match when any element does.
+func MatchesClaim(got, want interface{}) bool {
+	if list, ok := got.([]interface{}); ok {
+		if _, wantList := want.([]interface{}); !wantList {
+			for _, element := range list {
+				if MatchesClaim(element, want) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if got == nil {
+		return want == nil
+	}
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		return false
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		return false
+	}
+	return string(gotJSON) == string(wantJSON)
+}
+
+// ForClaims returns the endpoint restricted to the responses whose
+// input_claims are all granted by the request credentials. Responses that
+// require claims win over those that don't. The endpoint has no responses
+// left when every response requires claims the credentials lack.
+func (r *Response) ForClaims(claims map[string]interface{}) *Response {
+	var matched, unconditional []ResponseConfig
+	for _, resp := range r.Responses {
+		switch {
+		case len(resp.InputClaims) == 0:
+			unconditional = append(unconditional, resp)
+		case resp.MatchesClaims(claims):
+			matched = append(matched, resp)
+		}
+	}
+
+	filtered := *r
+	switch {
+	case len(matched) > 0:
+		filtered.Responses = matched
+	case len(unconditional) == len(r.Responses):
+		return r
+	default:
+		filtered.Responses = unconditional
+	}
+	return &filtered
+}
+
+// MatchesClaims reports whether the credentials grant every input claim
+func (rc *ResponseConfig) MatchesClaims(claims map[string]interface{}) bool {
+	for name, want := range rc.InputClaims {
+		if !MatchesClaim(claims[name], want) {
+			return false
+		}
+	}
+	return true
+}
+
+// claimPattern matches the {{claims.name}} placeholders of response bodies
+// and headers
+var claimPattern = regexp.MustCompile(`\{\{\s*claims\.([A-Za-z0-9_:.\-]+)\s*\}\}`)
+
+// WithClaims returns the response with the {{claims.name}} placeholders of
+// its body and headers replaced by the claims of the request credentials.
+// Placeholders of claims the credentials lack become empty. A string that is
+// only a placeholder takes the claim's JSON value, so numbers and lists keep
+// their type.
+func (rc *ResponseConfig) WithClaims(claims map[string]interface{}) *ResponseConfig {
+	if claims == nil {
+		return rc
+	}
+	expanded := *rc
+	expanded.Body = expandClaims(rc.Body, claims)
+	if len(rc.Headers) > 0 {
+		expanded.Headers = make(map[string]string, len(rc.Headers))
+		for name, value := range rc.Headers {
+			expanded.Headers[name] = expandClaimString(value, claims)
+		}
+	}
+	return &expanded
+}
+
+func expandClaims(value interface{}, claims map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if m := claimPattern.FindStringSubmatch(v); m != nil && m[0] == v {
+			return claims[m[1]]
+		}
+		return expandClaimString(v, claims)
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			expanded[key] = expandClaims(item, claims)
+		}
+		return expanded
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded[i] = expandClaims(item, claims)
+		}
+		return expanded
+	}
+	return value
+}
+
+func expandClaimString(s string, claims map[string]interface{}) string {
+	return claimPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		claim := claims[claimPattern.FindStringSubmatch(placeholder)[1]]
+		if claim == nil {
+			return ""
+		}
+		if s, ok := claim.(string); ok {
+			return s
+		}
+		encoded, err := json.Marshal(claim)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package mock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StubBuilder defines in code which requests a response answers. It is
+// created by On and completed by Reply:
+//
+//	mock.On("POST", "/users").
+//		WithBody(map[string]interface{}{"name": "Ada"}).
+//		WithHeader("Authorization", "Bearer token").
+//		Reply(201).
+//		JSON(map[string]interface{}{"id": 1})
+type StubBuilder struct {
+	method    string
+	path      string
+	config    ResponseConfig
+	rateLimit *RateLimitConfig
+	auth      *AuthConfig
+}
+
+// ReplyBuilder defines the response of a stub
+type ReplyBuilder struct {
+	stub *StubBuilder
+}
+
+// On starts a stub for the method and path
+func On(method, path string) *StubBuilder {
+	return &StubBuilder{method: strings.ToUpper(method), path: path}
+}
+
+// WithBody answers only requests whose body equals the given value, compared
+// as JSON like input_body
+func (b *StubBuilder) WithBody(body interface{}) *StubBuilder {
+	b.config.InputBody = body
+	return b
+}
+
+// WithHeader answers only requests carrying the header value
+func (b *StubBuilder) WithHeader(name, value string) *StubBuilder {
+	if b.config.InputHeaders == nil {
+		b.config.InputHeaders = make(map[string]string)
+	}
+	b.config.InputHeaders[name] = value
+	return b
+}
+
+// InScenario answers only while the scenario is in the given state; an empty
+// state answers in any state
+func (b *StubBuilder) InScenario(name, state string) *StubBuilder {
+	if b.config.Scenario == nil {
+		b.config.Scenario = &ScenarioConfig{}
+	}
+	b.config.Scenario.Name = name
+	b.config.Scenario.State = state
+	return b
+}
+
+// WithProtocol answers only requests made over the protocol, such as HTTP/2
+func (b *StubBuilder) WithProtocol(protocol string) *StubBuilder {
+	b.config.Protocol = protocol
+	return b
+}
+
+// RateLimit limits how often the endpoint of the stub answers. Stubs of a
+// path share one limit; the last one given applies.
+func (b *StubBuilder) RateLimit(config RateLimitConfig) *StubBuilder {
+	b.rateLimit = &config
+	return b
+}
+
+// WithClaims answers only requests whose credentials grant the claim value,
+// like input_claims
+func (b *StubBuilder) WithClaims(name string, value interface{}) *StubBuilder {
+	if b.config.InputClaims == nil {
+		b.config.InputClaims = make(map[string]interface{})
+	}
+	b.config.InputClaims[name] = value
+	return b
+}
+
+// Auth requires credentials for the endpoint of the stub. Stubs of a path
+// share one requirement; the last one given applies.
+func (b *StubBuilder) Auth(config AuthConfig) *StubBuilder {
+	b.auth = &config
+	return b
+}
+
+// Reply completes the stub with the response status
+func (b *StubBuilder) Reply(status int) *ReplyBuilder {
+	b.config.Status = status
+	return &ReplyBuilder{stub: b}
+}
+
+// SetState moves the named scenario to a new state once the response is
+// served. A response belongs to a single scenario, so the name must match the
+// one given to InScenario, if any.
+func (r *ReplyBuilder) SetState(name, state string) *ReplyBuilder {
+	if r.stub.config.Scenario == nil {
+		r.stub.config.Scenario = &ScenarioConfig{}
+	}
+	r.stub.config.Scenario.Name = name
+	r.stub.config.Scenario.SetState = state
+	return r
+}
+
+// Header adds a response header
+func (r *ReplyBuilder) Header(name, value string) *ReplyBuilder {
+	if r.stub.config.Headers == nil {
+		r.stub.config.Headers = make(map[string]string)
+	}
+	r.stub.config.Headers[name] = value
+	return r
+}
+
+// JSON sets a body encoded as JSON
+func (r *ReplyBuilder) JSON(body interface{}) *ReplyBuilder {
+	r.stub.config.Body = body
+	return r
+}
+
+// Text sets a plain text body
+func (r *ReplyBuilder) Text(body string) *ReplyBuilder {
+	r.stub.config.Body = body
+	return r.defaultContentType("text/plain; charset=utf-8")
+}
+
+// XML sets an XML body
+func (r *ReplyBuilder) XML(body string) *ReplyBuilder {
+	r.stub.config.Body = body
+	return r.defaultContentType("application/xml; charset=utf-8")
+}
+
+// Schema generates the body from a JSON Schema for every request
+func (r *ReplyBuilder) Schema(schema interface{}) *ReplyBuilder {
+	r.stub.config.Schema = schema
+	return r
+}
+
+// Fake generates the body from a compact fake data description for every
+// request
+func (r *ReplyBuilder) Fake(fake interface{}) *ReplyBuilder {
+	r.stub.config.Fake = fake
+	return r
+}
+
+// Describe sets the description shown in the endpoints list
+func (r *ReplyBuilder) Describe(description string) *ReplyBuilder {
+	r.stub.config.Description = description
+	return r
+}
+
+// Config returns the response configuration, for settings without a builder
+// method such as streams or pagination
+func (r *ReplyBuilder) Config() *ResponseConfig {
+	return &r.stub.config
+}
+
+func (r *ReplyBuilder) defaultContentType(contentType string) *ReplyBuilder {
+	for name := range r.stub.config.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			return r
+		}
+	}
+	return r.Header("Content-Type", contentType)
+}
+
+// Build groups stubs into endpoints keyed by path, as LoadResponses does for
+// files. Stubs of a path are tried in order and must share its method.
+func Build(stubs ...*ReplyBuilder) (map[string]Response, error) {
+	responses := make(map[string]Response)
+	for _, r := range stubs {
+		stub := r.stub
+		if !strings.HasPrefix(stub.path, "/") {
+			return nil, fmt.Errorf("%s %s: path must start with /", stub.method, stub.path)
+		}
+		endpoint, ok := responses[stub.path]
+		if !ok {
+			endpoint = Response{Method: stub.method, Path: stub.path}
+		} else if endpoint.Method != stub.method {
+			return nil, fmt.Errorf("%s %s: path is already defined for %s", stub.method, stub.path, endpoint.Method)
+		}
+		endpoint.Responses = append(endpoint.Responses, stub.config)
+		if stub.rateLimit != nil {
+			endpoint.RateLimit = stub.rateLimit
+		}
+		if stub.auth != nil {
+			endpoint.Auth = stub.auth
+		}
+		responses[stub.path] = endpoint
+	}
+
+	paths := make([]string, 0, len(responses))
+	for path := range responses {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		endpoint := responses[path]
+		if err := Prepare(".", &endpoint); err != nil {
+			return nil, fmt.Errorf("%s %s: %v", endpoint.Method, path, err)
+		}
+		responses[path] = endpoint
+	}
+	return responses, nil
+}
+
+// MustBuild is like Build but panics on invalid stubs, for use in tests
+func MustBuild(stubs ...*ReplyBuilder) map[string]Response {
+	responses, err := Build(stubs...)
+	if err != nil {
+		panic(err)
+	}
+	return responses
+}
+
+// Merge combines endpoint sets, such as mocks loaded from a folder and mocks
+// built in code. When both define a path for the same method, the responses
+// of overrides are tried first and the base rate limit applies unless
+// overrides set one; otherwise the endpoint of overrides replaces the base
+// one. Neither map is modified.
+func Merge(base, overrides map[string]Response) map[string]Response {
+	merged := make(map[string]Response, len(base)+len(overrides))
+	for path, endpoint := range base {
+		merged[path] = endpoint
+	}
+	for path, endpoint := range overrides {
+		existing, ok := merged[path]
+		if ok && existing.Type == "" && endpoint.Type == "" && existing.Method == endpoint.Method {
+			combined := make([]ResponseConfig, 0, len(endpoint.Responses)+len(existing.Responses))
+			combined = append(combined, endpoint.Responses...)
+			endpoint.Responses = append(combined, existing.Responses...)
+			if endpoint.RateLimit == nil {
+				endpoint.RateLimit = existing.RateLimit
+			}
+			if endpoint.Auth == nil {
+				endpoint.Auth = existing.Auth
+			}
+		}
+		merged[path] = endpoint
+	}
+	return merged
+}
@@ -0,0 +1,118 @@
+package mock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/sachin-duhan/gomock/pkg/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// GraphQLConfig configures a GraphQL endpoint. Requests are validated against
+// the schema when one is given, inline or in a file relative to the mocks
+// folder.
+type GraphQLConfig struct {
+	SchemaFile string `json:"schema_file,omitempty"`
+	SDL        string `json:"sdl,omitempty"`
+
+	once   sync.Once
+	schema *ast.Schema
+	err    error
+}
+
+// Schema returns the parsed schema, or nil when the endpoint has none
+func (c *GraphQLConfig) Schema() (*ast.Schema, error) {
+	c.once.Do(func() {
+		if c.SDL != "" {
+			c.schema, c.err = graphql.LoadSchema(c.SchemaFile, c.SDL)
+		}
+	})
+	return c.schema, c.err
+}
+
+// GraphQLResponse selects a response of a GraphQL endpoint by operation and
+// adds GraphQL errors to it. Empty criteria match any request.
+type GraphQLResponse struct {
+	OperationName string                 `json:"operation_name,omitempty"`
+	Query         string                 `json:"query,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Errors        []interface{}          `json:"errors,omitempty"`
+}
+
+// Matches reports whether the request satisfies the criteria. Queries are
+// compared after normalization and variables only need to contain the
+// configured values.
+func (g *GraphQLResponse) Matches(req *graphql.Request) bool {
+	if g.OperationName != "" && g.OperationName != req.Operation() {
+		return false
+	}
+	if g.Query != "" {
+		want, err := graphql.Normalize(g.Query)
+		if err != nil {
+			return false
+		}
+		got, err := graphql.Normalize(req.Query)
+		if err != nil || got != want {
+			return false
+		}
+	}
+	return graphql.ContainsVariables(req.Variables, g.Variables)
+}
+
+// IsGraphQL reports whether the endpoint is a GraphQL endpoint
+func (r *Response) IsGraphQL() bool {
+	return r.Type == TypeGraphQL
+}
+
+// FindGraphQLResponse returns the first response whose GraphQL criteria match
+// the request. Responses without criteria serve as the default.
+func (r *Response) FindGraphQLResponse(req *graphql.Request) *ResponseConfig {
+	var fallback *ResponseConfig
+	for i := range r.Responses {
+		resp := &r.Responses[i]
+		if resp.GraphQL == nil || resp.GraphQL.OperationName == "" && resp.GraphQL.Query == "" && len(resp.GraphQL.Variables) == 0 {
+			if fallback == nil {
+				fallback = resp
+			}
+			continue
+		}
+		if resp.GraphQL.Matches(req) {
+			return resp
+		}
+	}
+	return fallback
+}
+
+// loadGraphQL reads the schema file of a GraphQL endpoint and checks the
+// schema and the configured queries
+func loadGraphQL(folder string, mock *Response) error {
+	if mock.GraphQL == nil {
+		mock.GraphQL = &GraphQLConfig{}
+	}
+	if mock.GraphQL.SchemaFile != "" {
+		schemaPath := mock.GraphQL.SchemaFile
+		if !filepath.IsAbs(schemaPath) {
+			schemaPath = filepath.Join(folder, schemaPath)
+		}
+		content, err := ioutil.ReadFile(schemaPath)
+		if err != nil {
+			return fmt.Errorf("failed to read GraphQL schema: %v", err)
+		}
+		mock.GraphQL.SDL = string(content)
+	}
+	if _, err := mock.GraphQL.Schema(); err != nil {
+		return fmt.Errorf("invalid GraphQL schema: %v", err)
+	}
+
+	for i, resp := range mock.Responses {
+		if resp.GraphQL == nil || resp.GraphQL.Query == "" {
+			continue
+		}
+		if _, err := graphql.Normalize(resp.GraphQL.Query); err != nil {
+			return fmt.Errorf("response %d: invalid GraphQL query: %v", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package mock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/graphql"
+	"google.golang.org/grpc/codes"
+)
+
+// GRPCResponse describes the gRPC specific parts of a response. The body is
+// the response message written as protobuf JSON.
+type GRPCResponse struct {
+	// Code is the status code name, such as NOT_FOUND, or its number.
+	// Non-OK codes are returned as errors after any stream messages.
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Details are google.protobuf.Any messages in protobuf JSON, for example
+	// {"@type": "type.googleapis.com/google.rpc.BadRequest", ...}
+	Details []interface{} `json:"details,omitempty"`
+
+	// Stream holds the messages of server-streaming methods
+	Stream []StreamChunk `json:"stream,omitempty"`
+
+	// Trailers are sent as trailing metadata; headers become header metadata
+	Trailers map[string]string `json:"trailers,omitempty"`
+}
+
+// IsGRPC reports whether the endpoint is a gRPC method
+func (r *Response) IsGRPC() bool {
+	return r.Type == TypeGRPC
+}
+
+// StatusCode returns the configured status code, OK when unset
+func (g *GRPCResponse) StatusCode() (codes.Code, error) {
+	return ParseCode(g.Code)
+}
+
+// ParseCode parses a gRPC status code given as NOT_FOUND, NotFound or 5
+func ParseCode(name string) (codes.Code, error) {
+	if name == "" {
+		return codes.OK, nil
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 0 || n > int(codes.Unauthenticated) {
+			return codes.Unknown, fmt.Errorf("unknown gRPC status code %d", n)
+		}
+		return codes.Code(n), nil
+	}
+
+	wanted := strings.ReplaceAll(strings.ToLower(name), "_", "")
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.ToLower(c.String()) == wanted {
+			return c, nil
+		}
+	}
+	if wanted == "cancelled" {
+		return codes.Canceled, nil
+	}
+	return codes.Unknown, fmt.Errorf("unknown gRPC status code %q", name)
+}
+
+// MatchFields returns the first response whose input_body is contained in
+// the input, so only the listed fields need to match. Responses without an
+// input_body serve as the default.
+func (r *Response) MatchFields(input interface{}) *ResponseConfig {
+	fields, _ := input.(map[string]interface{})
+	var fallback *ResponseConfig
+	for i := range r.Responses {
+		resp := &r.Responses[i]
+		if resp.InputBody == nil {
+			if fallback == nil {
+				fallback = resp
+			}
+			continue
+		}
+		// Messages are matched field by field like GraphQL variables
+		if want, ok := resp.InputBody.(map[string]interface{}); ok && graphql.ContainsVariables(fields, want) {
+			return resp
+		}
+	}
+	return fallback
+}
+
+// validateGRPC checks a gRPC endpoint definition
+func validateGRPC(mock *Response) error {
+	if strings.Count(mock.Path, "/") != 2 || !strings.HasPrefix(mock.Path, "/") {
+		return fmt.Errorf("grpc endpoints need a path of the form /package.Service/Method, got %q", mock.Path)
+	}
+	for i, resp := range mock.Responses {
+		if resp.GRPC == nil {
+			continue
+		}
+		if _, err := resp.GRPC.StatusCode(); err != nil {
+			return fmt.Errorf("response %d: %v", i, err)
+		}
+		for j, chunk := range resp.GRPC.Stream {
+			if chunk.DelayMS != nil && *chunk.DelayMS < 0 {
+				return fmt.Errorf("response %d: stream message %d: delay_ms must not be negative", i, j)
+			}
+		}
+	}
+	return nil
+}
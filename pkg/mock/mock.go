@@ -2,24 +2,79 @@ package mock
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"math/rand"
+	"net/http"
 	"path/filepath"
 	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/schema"
 )
 
 // Response represents a mock API response configuration
 type Response struct {
 	Method    string           `json:"method"`
 	Path      string           `json:"path,omitempty"`
+	Type      string           `json:"type,omitempty"`
+	Resource  *ResourceConfig  `json:"resource,omitempty"`
+	WebSocket *WebSocketConfig `json:"websocket,omitempty"`
+	GraphQL   *GraphQLConfig   `json:"graphql,omitempty"`
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
+	Auth      *AuthConfig      `json:"auth,omitempty"`
 	Responses []ResponseConfig `json:"responses"`
 }
 
+// Endpoint types
+const (
+	// TypeResource serves a CRUD collection backed by an in-memory store
+	TypeResource = "resource"
+
+	// TypeWebSocket upgrades the connection and replies to incoming messages
+	TypeWebSocket = "websocket"
+
+	// TypeGraphQL matches GraphQL operations and answers with data/errors
+	// envelopes
+	TypeGraphQL = "graphql"
+
+	// TypeGRPC serves a gRPC method; the path is the full method name
+	TypeGRPC = "grpc"
+)
+
+// ResourceConfig configures a CRUD resource endpoint
+type ResourceConfig struct {
+	IDField  string                   `json:"id_field,omitempty"`
+	SeedFile string                   `json:"seed_file,omitempty"`
+	Data     []map[string]interface{} `json:"data,omitempty"`
+}
+
 // ResponseConfig represents a specific response configuration for an endpoint
 type ResponseConfig struct {
-	Status      int         `json:"status"`
-	Body        interface{} `json:"body"`
-	InputBody   interface{} `json:"input_body,omitempty"`
-	Description string      `json:"description,omitempty"`
+	Status       int                    `json:"status"`
+	Headers      map[string]string      `json:"headers,omitempty"`
+	Body         interface{}            `json:"body"`
+	Schema       interface{}            `json:"schema,omitempty"`
+	Fake         interface{}            `json:"fake,omitempty"`
+	Pagination   *PaginationConfig      `json:"pagination,omitempty"`
+	InputBody    interface{}            `json:"input_body,omitempty"`
+	InputHeaders map[string]string      `json:"input_headers,omitempty"`
+	InputClaims  map[string]interface{} `json:"input_claims,omitempty"`
+	Protocol     string                 `json:"protocol,omitempty"`
+	Close        *CloseFrame            `json:"close,omitempty"`
+	Stream       *StreamConfig          `json:"stream,omitempty"`
+	GraphQL      *GraphQLResponse       `json:"graphql,omitempty"`
+	GRPC         *GRPCResponse          `json:"grpc,omitempty"`
+	XML          *XMLResponse           `json:"xml,omitempty"`
+	Scenario     *ScenarioConfig        `json:"scenario,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+
+	// Representations are alternative bodies chosen by the Accept header;
+	// Encodings are the content codings the response may be compressed with
+	Representations []Representation `json:"representations,omitempty"`
+	Encodings       []string         `json:"encodings,omitempty"`
+
+	// bodySchema is the schema compiled by Prepare
+	bodySchema *schema.Schema
 }
 
 // LoadResponses loads mock responses from JSON files in the specified directory
@@ -29,10 +84,16 @@ func LoadResponses(path string) (map[string]Response, error) {
 		return nil, err
 	}
 
+	// The folder's auth applies to the endpoints without their own
+	folderAuth, err := loadFolderAuth(path)
+	if err != nil {
+		return nil, err
+	}
+
 	mockResponses := make(map[string]Response)
 
 	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".json") {
+		if strings.HasSuffix(file.Name(), ".json") && file.Name() != AuthFile {
 			filePath := filepath.Join(path, file.Name())
 			content, err := ioutil.ReadFile(filePath)
 			if err != nil {
@@ -45,6 +106,13 @@ func LoadResponses(path string) (map[string]Response, error) {
 				return nil, err
 			}
 
+			if err := Prepare(path, &mock); err != nil {
+				return nil, fmt.Errorf("%s: %v", file.Name(), err)
+			}
+			if mock.Auth == nil {
+				mock.Auth = folderAuth
+			}
+
 			// Use the path from the JSON file if provided
 			// Otherwise extract the endpoint path from the filename
 			endpoint := mock.Path
@@ -62,6 +130,142 @@ func LoadResponses(path string) (map[string]Response, error) {
 	return mockResponses, nil
 }
 
+// Prepare validates an endpoint definition and loads the files it refers to,
+// relative to folder. LoadResponses prepares every endpoint it reads;
+// endpoints defined elsewhere must be prepared before they are served.
+func Prepare(folder string, mock *Response) error {
+	for i := range mock.Responses {
+		resp := &mock.Responses[i]
+		if err := resp.compileSchema(); err != nil {
+			return err
+		}
+		if resp.Protocol != "" && normalizeProtocol(resp.Protocol) == "" {
+			return fmt.Errorf("unknown protocol %q, expected HTTP/1.0, HTTP/1.1 or HTTP/2", resp.Protocol)
+		}
+		if resp.Pagination != nil {
+			if err := resp.Pagination.Validate(); err != nil {
+				return err
+			}
+		}
+		if resp.Stream != nil {
+			if err := resp.Stream.Validate(); err != nil {
+				return err
+			}
+		}
+		if resp.Close != nil {
+			if err := resp.Close.Validate(); err != nil {
+				return err
+			}
+		}
+		if err := resp.validateNegotiation(); err != nil {
+			return err
+		}
+		if resp.Scenario != nil {
+			if err := resp.Scenario.Validate(); err != nil {
+				return err
+			}
+		}
+		if resp.XML != nil {
+			if err := resp.XML.Validate(); err != nil {
+				return err
+			}
+			if _, ok := resp.Body.(string); resp.Body != nil && !ok {
+				return fmt.Errorf("xml responses need a string body")
+			}
+		}
+	}
+
+	if mock.RateLimit != nil {
+		if err := mock.RateLimit.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if mock.Auth != nil {
+		if err := mock.Auth.load(folder); err != nil {
+			return err
+		}
+	}
+
+	if mock.Type == TypeWebSocket && mock.WebSocket != nil {
+		if err := mock.WebSocket.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if mock.Type == TypeGraphQL {
+		if err := loadGraphQL(folder, mock); err != nil {
+			return err
+		}
+	}
+
+	if mock.Type == TypeGRPC {
+		if err := validateGRPC(mock); err != nil {
+			return err
+		}
+	}
+
+	if mock.Type == TypeResource {
+		if err := loadResourceSeed(folder, mock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileReferences lists the files the endpoint refers to, which Prepare reads
+// from disk
+func (r *Response) FileReferences() []string {
+	var files []string
+	if r.Resource != nil && r.Resource.SeedFile != "" {
+		files = append(files, r.Resource.SeedFile)
+	}
+	if r.GraphQL != nil && r.GraphQL.SchemaFile != "" {
+		files = append(files, r.GraphQL.SchemaFile)
+	}
+	if r.Auth != nil && r.Auth.JWKSFile != "" {
+		files = append(files, r.Auth.JWKSFile)
+	}
+	return files
+}
+
+// IsResource reports whether the endpoint is a CRUD resource
+func (r *Response) IsResource() bool {
+	return r.Type == TypeResource
+}
+
+// IsWebSocket reports whether the endpoint is a WebSocket endpoint
+func (r *Response) IsWebSocket() bool {
+	return r.Type == TypeWebSocket
+}
+
+// loadResourceSeed reads the seed data of a resource endpoint. The seed file
+// path is relative to the mocks folder.
+func loadResourceSeed(folder string, mock *Response) error {
+	if mock.Resource == nil {
+		mock.Resource = &ResourceConfig{}
+	}
+	if mock.Resource.SeedFile == "" {
+		return nil
+	}
+
+	seedPath := mock.Resource.SeedFile
+	if !filepath.IsAbs(seedPath) {
+		seedPath = filepath.Join(folder, seedPath)
+	}
+	content, err := ioutil.ReadFile(seedPath)
+	if err != nil {
+		return fmt.Errorf("failed to read seed file: %v", err)
+	}
+
+	var data []map[string]interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return fmt.Errorf("seed file must contain a JSON array of objects: %v", err)
+	}
+	mock.Resource.Data = append(mock.Resource.Data, data...)
+	return nil
+}
+
 // FindResponse finds the appropriate response based on input body
 func (r *Response) FindResponse(inputBody interface{}) *ResponseConfig {
 	// If no responses defined, return nil
@@ -75,23 +279,179 @@ func (r *Response) FindResponse(inputBody interface{}) *ResponseConfig {
 	}
 
 	// Try to find a response with matching input body
-	for _, resp := range r.Responses {
-		if resp.InputBody != nil {
-			// Convert both to JSON for comparison
-			inputJSON, err := json.Marshal(inputBody)
-			if err != nil {
-				continue
-			}
-			configJSON, err := json.Marshal(resp.InputBody)
-			if err != nil {
-				continue
-			}
-			if string(inputJSON) == string(configJSON) {
-				return &resp
-			}
-		}
+	if resp := r.MatchInput(inputBody); resp != nil {
+		return resp
 	}
 
 	// If no matching input body found, return the last response as default
 	return &r.Responses[len(r.Responses)-1]
 }
+
+// MatchInput returns the first response whose input_body equals the input,
+// or nil when none matches
+func (r *Response) MatchInput(inputBody interface{}) *ResponseConfig {
+	// Convert both to JSON for comparison
+	inputJSON, err := json.Marshal(inputBody)
+	if err != nil {
+		return nil
+	}
+	for i, resp := range r.Responses {
+		if resp.InputBody == nil {
+			continue
+		}
+		configJSON, err := json.Marshal(resp.InputBody)
+		if err != nil {
+			continue
+		}
+		if string(inputJSON) == string(configJSON) {
+			return &r.Responses[i]
+		}
+	}
+	return nil
+}
+
+// ForProtocol returns the endpoint restricted to the responses that apply to
+// the request protocol, as given by http.Request.Proto. Responses pinned to
+// the protocol win over unpinned ones. The endpoint has no responses left
+// when every response is pinned to another protocol.
+func (r *Response) ForProtocol(proto string) *Response {
+	proto = normalizeProtocol(proto)
+
+	var pinned, unpinned []ResponseConfig
+	for _, resp := range r.Responses {
+		switch {
+		case resp.Protocol == "":
+			unpinned = append(unpinned, resp)
+		case resp.MatchesProtocol(proto):
+			pinned = append(pinned, resp)
+		}
+	}
+
+	filtered := *r
+	switch {
+	case len(pinned) > 0:
+		filtered.Responses = pinned
+	case len(unpinned) == len(r.Responses):
+		return r
+	default:
+		filtered.Responses = unpinned
+	}
+	return &filtered
+}
+
+// ForHeaders returns the endpoint restricted to the responses whose
+// input_headers are all present in the request headers. Responses that
+// require headers win over those that don't. The endpoint has no responses
+// left when every response requires headers the request lacks.
+func (r *Response) ForHeaders(header http.Header) *Response {
+	var matched, unconditional []ResponseConfig
+	for _, resp := range r.Responses {
+		switch {
+		case len(resp.InputHeaders) == 0:
+			unconditional = append(unconditional, resp)
+		case resp.MatchesHeaders(header):
+			matched = append(matched, resp)
+		}
+	}
+
+	filtered := *r
+	switch {
+	case len(matched) > 0:
+		filtered.Responses = matched
+	case len(unconditional) == len(r.Responses):
+		return r
+	default:
+		filtered.Responses = unconditional
+	}
+	return &filtered
+}
+
+// MatchesHeaders reports whether the request carries every input header.
+// Names are case-insensitive and values must match exactly.
+func (rc *ResponseConfig) MatchesHeaders(header http.Header) bool {
+	for name, value := range rc.InputHeaders {
+		if header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesProtocol reports whether the response applies to the request
+// protocol. HTTP/1 matches both HTTP/1.0 and HTTP/1.1.
+func (rc *ResponseConfig) MatchesProtocol(proto string) bool {
+	if rc.Protocol == "" {
+		return true
+	}
+	want, got := normalizeProtocol(rc.Protocol), normalizeProtocol(proto)
+	if want == "HTTP/1" {
+		return strings.HasPrefix(got, "HTTP/1")
+	}
+	return want != "" && want == got
+}
+
+// normalizeProtocol maps protocol names and ALPN ids to HTTP/1, HTTP/1.0,
+// HTTP/1.1 or HTTP/2, returning an empty string for unknown protocols
+func normalizeProtocol(proto string) string {
+	switch strings.ToLower(strings.TrimSpace(proto)) {
+	case "http/1", "http/1.x", "h1":
+		return "HTTP/1"
+	case "http/1.0":
+		return "HTTP/1.0"
+	case "http/1.1":
+		return "HTTP/1.1"
+	case "http/2", "http/2.0", "h2", "h2c":
+		return "HTTP/2"
+	}
+	return ""
+}
+
+// IsGenerated reports whether the body is generated from a schema rather
+// than taken from the literal Body
+func (rc *ResponseConfig) IsGenerated() bool {
+	return rc.Schema != nil || rc.Fake != nil
+}
+
+// BodySchema returns the schema used to generate the body, built from either
+// the JSON Schema or the compact fake type description. Prepared responses
+// return the schema compiled once by Prepare.
+func (rc *ResponseConfig) BodySchema() (*schema.Schema, error) {
+	if rc.bodySchema != nil {
+		return rc.bodySchema, nil
+	}
+	return rc.buildSchema()
+}
+
+// compileSchema builds the body schema once so requests don't rebuild it
+func (rc *ResponseConfig) compileSchema() error {
+	s, err := rc.buildSchema()
+	if err != nil {
+		return err
+	}
+	rc.bodySchema = s
+	return nil
+}
+
+func (rc *ResponseConfig) buildSchema() (*schema.Schema, error) {
+	switch {
+	case rc.Schema != nil:
+		s, err := schema.FromValue(rc.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response schema: %v", err)
+		}
+		return s, nil
+	case rc.Fake != nil:
+		return schema.FromDSL(rc.Fake)
+	}
+	return nil, nil
+}
+
+// GenerateBody returns the response body, generating fake data when the
+// response is schema-driven
+func (rc *ResponseConfig) GenerateBody(rng *rand.Rand) (interface{}, error) {
+	s, err := rc.BodySchema()
+	if err != nil || s == nil {
+		return rc.Body, err
+	}
+	return s.Generate(rng), nil
+}
@@ -1,11 +1,23 @@
 package mock
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/sachin-duhan/gomock/pkg/soap"
+	"google.golang.org/grpc/codes"
 )
 
 func TestLoadResponses(t *testing.T) {
@@ -259,3 +271,649 @@ func TestFindResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadResponsesInvalidFake(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gomock-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	content := `{
+		"method": "GET",
+		"responses": [{"status": 200, "fake": {"id": "not-a-type"}}]
+	}`
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "users.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if _, err := LoadResponses(tempDir); err == nil {
+		t.Error("Expected error for unknown fake type, got nil")
+	}
+}
+
+func TestLoadResponsesResourceSeed(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gomock-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.Mkdir(filepath.Join(tempDir, "data"), 0755); err != nil {
+		t.Fatalf("Failed to create data dir: %v", err)
+	}
+	seed := `[{"id": 1, "title": "Dune"}, {"id": 2, "title": "Neuromancer"}]`
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "data", "books.json"), []byte(seed), 0644); err != nil {
+		t.Fatalf("Failed to write seed file: %v", err)
+	}
+	content := `{
+		"type": "resource",
+		"path": "/api/books",
+		"resource": {"seed_file": "data/books.json"}
+	}`
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "books.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	mockResponses, err := LoadResponses(tempDir)
+	if err != nil {
+		t.Fatalf("LoadResponses failed: %v", err)
+	}
+	books, ok := mockResponses["/api/books"]
+	if !ok || !books.IsResource() {
+		t.Fatalf("Expected resource endpoint, got %+v", mockResponses)
+	}
+	if len(books.Resource.Data) != 2 || books.Resource.Data[1]["title"] != "Neuromancer" {
+		t.Errorf("Unexpected seed data: %v", books.Resource.Data)
+	}
+
+	// A missing seed file is reported
+	content = `{"type": "resource", "resource": {"seed_file": "data/missing.json"}}`
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "books.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if _, err := LoadResponses(tempDir); err == nil {
+		t.Error("Expected error for missing seed file")
+	}
+}
+
+func TestForProtocol(t *testing.T) {
+	response := Response{
+		Method: "GET",
+		Responses: []ResponseConfig{
+			{Status: 200, Body: "any"},
+			{Status: 200, Body: "h2", Protocol: "HTTP/2"},
+			{Status: 200, Body: "h1", Protocol: "HTTP/1"},
+		},
+	}
+
+	testCases := []struct {
+		proto        string
+		expectedBody string
+	}{
+		{"HTTP/2.0", "h2"},
+		{"HTTP/1.1", "h1"},
+		{"HTTP/1.0", "h1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.proto, func(t *testing.T) {
+			result := response.ForProtocol(tc.proto).FindResponse(nil)
+			if result == nil || result.Body != tc.expectedBody {
+				t.Errorf("Expected body %q, got %+v", tc.expectedBody, result)
+			}
+		})
+	}
+
+	// Unpinned responses serve protocols without a pinned response
+	response.Responses = response.Responses[:2]
+	if result := response.ForProtocol("HTTP/1.1").FindResponse(nil); result.Body != "any" {
+		t.Errorf("Expected unpinned response for HTTP/1.1, got %+v", result)
+	}
+
+	// Responses pinned to another protocol are never served
+	response.Responses = response.Responses[1:]
+	if filtered := response.ForProtocol("HTTP/1.1"); len(filtered.Responses) != 0 {
+		t.Errorf("Expected no responses for HTTP/1.1, got %+v", filtered.Responses)
+	}
+}
+
+func TestStreamConfigValidate(t *testing.T) {
+	negative := -1
+	invalid := []StreamConfig{
+		{Type: "websocket"},
+		{Type: StreamSSE, Chunks: []StreamChunk{{Data: "x"}}},
+		{Type: StreamNDJSON, Events: []StreamEvent{{Data: "x"}}},
+		{Type: StreamChunked, Chunks: []StreamChunk{{Data: "x", DelayMS: &negative}}},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected error for stream %+v", config)
+		}
+	}
+
+	valid := StreamConfig{Type: StreamChunked, DelayMS: 20, Chunks: []StreamChunk{{Data: "a"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid stream, got %v", err)
+	}
+}
+
+func TestLoadResponsesGraphQL(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gomock-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sdl := "type Query { hello: String }"
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "schema.graphql"), []byte(sdl), 0644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+	content := `{
+		"type": "graphql",
+		"graphql": {"schema_file": "schema.graphql"},
+		"responses": [{"status": 200, "graphql": {"query": "{ hello }"}, "body": {"hello": "world"}}]
+	}`
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "graphql.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	mockResponses, err := LoadResponses(tempDir)
+	if err != nil {
+		t.Fatalf("LoadResponses failed: %v", err)
+	}
+	endpoint := mockResponses["/graphql"]
+	if schema, err := endpoint.GraphQL.Schema(); err != nil || schema.Query == nil {
+		t.Errorf("Expected schema to be loaded, got %v", err)
+	}
+
+	// Invalid queries are reported at load time
+	content = `{"type": "graphql", "responses": [{"graphql": {"query": "{ hello"}}]}`
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "graphql.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if _, err := LoadResponses(tempDir); err == nil {
+		t.Error("Expected error for invalid GraphQL query")
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	for _, name := range []string{"NOT_FOUND", "NotFound", "not_found", "5"} {
+		if code, err := ParseCode(name); err != nil || code != codes.NotFound {
+			t.Errorf("ParseCode(%q) = %v, %v", name, code, err)
+		}
+	}
+	if code, err := ParseCode("CANCELLED"); err != nil || code != codes.Canceled {
+		t.Errorf("ParseCode(CANCELLED) = %v, %v", code, err)
+	}
+	for _, name := range []string{"NOPE", "17"} {
+		if _, err := ParseCode(name); err == nil {
+			t.Errorf("Expected error for code %q", name)
+		}
+	}
+}
+
+func TestFindXMLResponse(t *testing.T) {
+	namespaces := map[string]string{"u": "urn:users"}
+	endpoint := Response{
+		Method: "POST",
+		Responses: []ResponseConfig{
+			{Status: 200, Body: "<ok/>"},
+			{
+				Status: 500,
+				XML: &XMLResponse{
+					SOAPAction: "urn:users/GetUser",
+					XPath:      []string{"//u:id = '404'"},
+					Namespaces: namespaces,
+					Fault:      &SOAPFault{Code: "Client", Message: "Unknown user"},
+				},
+			},
+			{
+				Status: 200,
+				XML:    &XMLResponse{SOAPAction: "urn:users/GetUser", SOAP: "1.1"},
+				Body:   "<u:User/>",
+			},
+		},
+	}
+
+	parse := func(action, id string) *soap.Request {
+		body := `<Envelope><Body><GetUser xmlns="urn:users"><id>` + id + `</id></GetUser></Body></Envelope>`
+		req, _ := http.NewRequest("POST", "/soap", strings.NewReader(body))
+		req.Header.Set("SOAPAction", action)
+		parsed, err := soap.ParseRequest(req)
+		if err != nil {
+			t.Fatalf("ParseRequest failed: %v", err)
+		}
+		return parsed
+	}
+
+	if resp := endpoint.FindXMLResponse(parse("urn:users/GetUser", "404")); resp == nil || resp.Status != 500 {
+		t.Errorf("Expected fault response, got %+v", resp)
+	}
+	if resp := endpoint.FindXMLResponse(parse("urn:users/GetUser", "1")); resp == nil || resp.XML == nil || resp.XML.Fault != nil {
+		t.Errorf("Expected user response, got %+v", resp)
+	}
+	if resp := endpoint.FindXMLResponse(parse("urn:users/DeleteUser", "1")); resp == nil || resp.Body != "<ok/>" {
+		t.Errorf("Expected default response, got %+v", resp)
+	}
+
+	if version := endpoint.Responses[1].XML.Version(); version != soap.Version11 {
+		t.Errorf("Expected faults to default to SOAP 1.1, got %q", version)
+	}
+	if err := (&XMLResponse{XPath: []string{"//["}}).Validate(); err == nil {
+		t.Error("Expected error for invalid xpath")
+	}
+	if err := (&XMLResponse{SOAP: "2.0"}).Validate(); err == nil {
+		t.Error("Expected error for unknown SOAP version")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	responses, err := Build(
+		On("post", "/users").
+			WithBody(map[string]interface{}{"name": "Ada"}).
+			WithHeader("Authorization", "Bearer token").
+			Reply(201).
+			Header("Location", "/users/1").
+			JSON(map[string]interface{}{"id": 1}),
+		On("POST", "/users").Reply(400).JSON(map[string]interface{}{"error": "invalid"}),
+		On("GET", "/health").Reply(200).Text("ok"),
+	)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	users := responses["/users"]
+	if users.Method != "POST" || len(users.Responses) != 2 {
+		t.Fatalf("Unexpected /users endpoint %+v", users)
+	}
+	created := users.Responses[0]
+	if created.Status != 201 || created.InputHeaders["Authorization"] != "Bearer token" || created.Headers["Location"] != "/users/1" {
+		t.Errorf("Unexpected response %+v", created)
+	}
+	if health := responses["/health"].Responses[0]; health.Headers["Content-Type"] != "text/plain; charset=utf-8" {
+		t.Errorf("Expected text content type, got %v", health.Headers)
+	}
+
+	if _, err := Build(On("GET", "/users").Reply(200), On("POST", "/users").Reply(201)); err == nil {
+		t.Error("Expected error for conflicting methods")
+	}
+	if _, err := Build(On("GET", "/fake").Reply(200).Fake("unknown(")); err == nil {
+		t.Error("Expected error for invalid fake description")
+	}
+
+	limited := MustBuild(On("GET", "/search").RateLimit(RateLimitConfig{Limit: 10, WindowMS: 1000}).Reply(200))
+	if limit := limited["/search"].RateLimit; limit == nil || limit.Limit != 10 {
+		t.Errorf("Expected the rate limit on the endpoint, got %+v", limit)
+	}
+	if _, err := Build(On("GET", "/search").RateLimit(RateLimitConfig{Limit: 10}).Reply(200)); err == nil {
+		t.Error("Expected error for a rate limit without a window")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := map[string]Response{
+		"/users":  {Method: "POST", Responses: []ResponseConfig{{Status: 400}}},
+		"/orders": {Method: "GET", Responses: []ResponseConfig{{Status: 200}}},
+	}
+	overrides := MustBuild(
+		On("POST", "/users").WithBody(map[string]interface{}{"name": "Ada"}).Reply(201),
+		On("DELETE", "/orders").Reply(204),
+	)
+
+	merged := Merge(base, overrides)
+	if users := merged["/users"]; len(users.Responses) != 2 || users.Responses[0].Status != 201 || users.Responses[1].Status != 400 {
+		t.Errorf("Expected override responses first, got %+v", users.Responses)
+	}
+	if orders := merged["/orders"]; orders.Method != "DELETE" {
+		t.Errorf("Expected endpoint with another method to be replaced, got %+v", orders)
+	}
+	if len(base["/users"].Responses) != 1 {
+		t.Error("Expected base to be unchanged")
+	}
+
+	// The rate limit and auth of a file survive stubs added on top
+	base["/users"] = Response{
+		Method:    "POST",
+		RateLimit: &RateLimitConfig{Limit: 1, WindowMS: 1000},
+		Auth:      &AuthConfig{Type: AuthBasic, Users: map[string]string{"ada": "pw"}},
+	}
+	if users := Merge(base, overrides)["/users"]; users.RateLimit == nil || users.Auth == nil {
+		t.Error("Expected the base rate limit and auth to be kept")
+	}
+}
+
+func TestForHeaders(t *testing.T) {
+	endpoint := Response{
+		Method: "GET",
+		Responses: []ResponseConfig{
+			{Status: 200},
+			{Status: 403, InputHeaders: map[string]string{"X-Role": "guest"}},
+		},
+	}
+
+	header := http.Header{}
+	header.Set("x-role", "guest")
+	if got := endpoint.ForHeaders(header); len(got.Responses) != 1 || got.Responses[0].Status != 403 {
+		t.Errorf("Expected header-matched response, got %+v", got.Responses)
+	}
+	if got := endpoint.ForHeaders(http.Header{}); len(got.Responses) != 1 || got.Responses[0].Status != 200 {
+		t.Errorf("Expected unconditional response, got %+v", got.Responses)
+	}
+
+	// Responses requiring headers are never served without them
+	endpoint.Responses = endpoint.Responses[1:]
+	if got := endpoint.ForHeaders(http.Header{}); len(got.Responses) != 0 {
+		t.Errorf("Expected no responses, got %+v", got.Responses)
+	}
+}
+
+func TestForScenarios(t *testing.T) {
+	endpoint := Response{
+		Method: "GET",
+		Responses: []ResponseConfig{
+			{Status: 200},
+			{Status: 404, Scenario: &ScenarioConfig{Name: "cart", State: "emptied"}},
+		},
+	}
+
+	states := map[string]string{"cart": "emptied"}
+	state := func(name string) string { return states[name] }
+	if got := endpoint.ForScenarios(state); len(got.Responses) != 1 || got.Responses[0].Status != 404 {
+		t.Errorf("Expected scenario response, got %+v", got.Responses)
+	}
+	states["cart"] = ScenarioStarted
+	if got := endpoint.ForScenarios(state); len(got.Responses) != 1 || got.Responses[0].Status != 200 {
+		t.Errorf("Expected unconditional response, got %+v", got.Responses)
+	}
+
+	// Responses for other states are never served
+	endpoint.Responses = endpoint.Responses[1:]
+	if got := endpoint.ForScenarios(state); len(got.Responses) != 0 {
+		t.Errorf("Expected no responses, got %+v", got.Responses)
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	for _, invalid := range []RateLimitConfig{
+		{Limit: 0, WindowMS: 1000},
+		{Limit: 5},
+		{Limit: 5, WindowMS: 1000, Algorithm: "leaky_bucket"},
+		{Limit: 5, WindowMS: 1000, Key: "header:"},
+		{Limit: 5, WindowMS: 1000, Key: "cookie"},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+
+	config := RateLimitConfig{Limit: 5, WindowMS: 1000, Key: "header:X-Api-Key"}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Api-Key", "key-1")
+	if key := config.ClientKey(req); key != "key-1" {
+		t.Errorf("Expected the header value as key, got %q", key)
+	}
+
+	config.Key = RateLimitKeyIP
+	if key := config.ClientKey(req); key != "10.0.0.1" {
+		t.Errorf("Expected the client IP as key, got %q", key)
+	}
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+	if key := config.ClientKey(req); key != "203.0.113.7" {
+		t.Errorf("Expected the forwarded client IP as key, got %q", key)
+	}
+
+	config.Key = ""
+	if key := config.ClientKey(req); key != "" {
+		t.Errorf("Expected a shared key, got %q", key)
+	}
+}
+
+// signToken signs claims as a JWT with the key
+func signToken(t *testing.T, alg jose.SignatureAlgorithm, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestAuthConfig(t *testing.T) {
+	for _, invalid := range []AuthConfig{
+		{Type: "oauth"},
+		{Type: AuthAPIKey},
+		{Type: AuthAPIKey, Keys: []string{"k"}, Header: "X-Key", Query: "key"},
+		{Type: AuthBasic},
+		{Type: AuthBearer},
+		{Type: AuthBearer, Secret: "s", JWKSFile: "jwks.json"},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+
+	request := func(header, value string) *http.Request {
+		req, _ := http.NewRequest("GET", "/orders?key=q-1", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		return req
+	}
+
+	apiKey := AuthConfig{Type: AuthAPIKey, Keys: []string{"key-1"}}
+	if _, err := apiKey.Authenticate(request("", "")); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("Expected missing credentials, got %v", err)
+	}
+	if _, err := apiKey.Authenticate(request("X-API-Key", "key-2")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected invalid credentials, got %v", err)
+	}
+	if claims, err := apiKey.Authenticate(request("X-API-Key", "key-1")); err != nil || claims["api_key"] != "key-1" {
+		t.Errorf("Expected the key as claim, got %v %v", claims, err)
+	}
+	apiKey = AuthConfig{Type: AuthAPIKey, Query: "key", Keys: []string{"q-1"}}
+	if _, err := apiKey.Authenticate(request("", "")); err != nil {
+		t.Errorf("Expected the query key to be accepted, got %v", err)
+	}
+
+	basic := AuthConfig{Type: AuthBasic, Users: map[string]string{"ada": "secret"}}
+	req := request("", "")
+	req.SetBasicAuth("ada", "wrong")
+	if _, err := basic.Authenticate(req); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected invalid credentials, got %v", err)
+	}
+	req.SetBasicAuth("ada", "secret")
+	if claims, err := basic.Authenticate(req); err != nil || claims["sub"] != "ada" {
+		t.Errorf("Expected the username as sub, got %v %v", claims, err)
+	}
+	if got := basic.Challenge(ErrMissingCredentials); got != `Basic realm="gomock"` {
+		t.Errorf("Unexpected challenge %q", got)
+	}
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	bearer := AuthConfig{
+		Type:     AuthBearer,
+		Secret:   string(secret),
+		Issuer:   "https://issuer.test",
+		Audience: "orders",
+		Claims:   map[string]interface{}{"role": "admin"},
+		Scopes:   []string{"orders:read"},
+	}
+	token := func(claims map[string]interface{}) *http.Request {
+		base := map[string]interface{}{
+			"sub": "user-1",
+			"iss": "https://issuer.test",
+			"aud": []string{"orders", "billing"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range claims {
+			base[name] = value
+		}
+		return request("Authorization", "Bearer "+signToken(t, jose.HS256, secret, base))
+	}
+
+	claims, err := bearer.Authenticate(token(map[string]interface{}{"role": "admin", "scope": "orders:read orders:write"}))
+	if err != nil || claims["sub"] != "user-1" {
+		t.Errorf("Expected the token claims, got %v %v", claims, err)
+	}
+	if _, err := bearer.Authenticate(token(map[string]interface{}{"role": "viewer", "scope": "orders:read"})); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected a missing claim to be forbidden, got %v", err)
+	}
+	if _, err := bearer.Authenticate(token(map[string]interface{}{"role": "admin", "scp": []string{"orders:write"}})); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected a missing scope to be forbidden, got %v", err)
+	}
+	if _, err := bearer.Authenticate(token(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected an expired token to be invalid, got %v", err)
+	}
+	if _, err := bearer.Authenticate(token(map[string]interface{}{"iss": "https://other.test"})); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected another issuer to be invalid, got %v", err)
+	}
+	forged := request("Authorization", "Bearer "+signToken(t, jose.HS256, []byte("another secret of 32 bytes long!"), map[string]interface{}{"sub": "x"}))
+	if _, err := bearer.Authenticate(forged); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a token signed with another secret to be invalid, got %v", err)
+	}
+	if got := bearer.Challenge(ErrForbidden); !strings.Contains(got, `error="insufficient_scope"`) {
+		t.Errorf("Unexpected challenge %q", got)
+	}
+}
+
+func TestLoadResponsesFolderAuth(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	jwks, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: key.Public(), KeyID: "key-1", Algorithm: string(jose.ES256), Use: "sig"},
+	}})
+	files := map[string]string{
+		"jwks.json":   string(jwks),
+		AuthFile:      `{"type": "bearer", "jwks_file": "jwks.json"}`,
+		"users.json":  `{"method": "GET", "responses": [{"status": 200}]}`,
+		"status.json": `{"method": "GET", "auth": {"type": "none"}, "responses": [{"status": 200}]}`,
+		"admin.json":  `{"method": "GET", "auth": {"type": "basic", "users": {"root": "pw"}}, "responses": [{"status": 200}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	responses, err := LoadResponses(dir)
+	if err != nil {
+		t.Fatalf("Failed to load responses: %v", err)
+	}
+	if _, ok := responses["/_auth"]; ok {
+		t.Errorf("Expected %s not to be served as an endpoint", AuthFile)
+	}
+	users := responses["/users"]
+	if users.Auth == nil || users.Auth.Type != AuthBearer {
+		t.Fatalf("Expected the folder auth, got %+v", users.Auth)
+	}
+	if responses["/status"].Auth.Enabled() || responses["/admin"].Auth.Type != AuthBasic {
+		t.Errorf("Expected endpoints to keep their own auth")
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{}).WithHeader(jose.HeaderKey("kid"), "key-1"))
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(map[string]interface{}{"sub": "user-1"}).Serialize()
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if claims, err := users.Auth.Authenticate(req); err != nil || claims["sub"] != "user-1" {
+		t.Errorf("Expected the token to verify against the JWKS, got %v %v", claims, err)
+	}
+
+	// HMAC tokens are not accepted in place of the JWKS keys
+	req.Header.Set("Authorization", "Bearer "+signToken(t, jose.HS256, []byte("0123456789abcdef0123456789abcdef"), map[string]interface{}{"sub": "x"}))
+	if _, err := users.Auth.Authenticate(req); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected an HMAC token to be invalid, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, AuthFile), []byte(`{"type": "bearer", "jwks_file": "missing.json"}`), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", AuthFile, err)
+	}
+	if _, err := LoadResponses(dir); err == nil {
+		t.Error("Expected an error for a missing JWKS file")
+	}
+}
+
+func TestClaims(t *testing.T) {
+	claims := map[string]interface{}{
+		"sub":   "user-1",
+		"roles": []interface{}{"admin", "billing"},
+		"level": float64(3),
+	}
+	endpoint := Response{
+		Method: "GET",
+		Responses: []ResponseConfig{
+			{Status: 200},
+			{Status: 200, InputClaims: map[string]interface{}{"roles": "admin", "level": 3}, Description: "admin"},
+		},
+	}
+	if got := endpoint.ForClaims(claims); len(got.Responses) != 1 || got.Responses[0].Description != "admin" {
+		t.Errorf("Expected claim-matched response, got %+v", got.Responses)
+	}
+	if got := endpoint.ForClaims(map[string]interface{}{"roles": []interface{}{"viewer"}}); len(got.Responses) != 1 || got.Responses[0].Description != "" {
+		t.Errorf("Expected unconditional response, got %+v", got.Responses)
+	}
+
+	// Responses requiring claims are never served without them
+	gated := Response{Method: "GET", Responses: endpoint.Responses[1:]}
+	if got := gated.ForClaims(map[string]interface{}{"roles": []interface{}{"viewer"}}); len(got.Responses) != 0 {
+		t.Errorf("Expected no responses, got %+v", got.Responses)
+	}
+
+	response := ResponseConfig{
+		Status:  200,
+		Headers: map[string]string{"X-User": "{{claims.sub}}"},
+		Body: map[string]interface{}{
+			"greeting": "Hello {{ claims.sub }}",
+			"roles":    "{{claims.roles}}",
+			"missing":  "[{{claims.email}}]",
+		},
+	}
+	expanded := response.WithClaims(claims)
+	body := expanded.Body.(map[string]interface{})
+	if body["greeting"] != "Hello user-1" || expanded.Headers["X-User"] != "user-1" || body["missing"] != "[]" {
+		t.Errorf("Expected placeholders to be replaced, got %v %v", body, expanded.Headers)
+	}
+	if roles, ok := body["roles"].([]interface{}); !ok || len(roles) != 2 {
+		t.Errorf("Expected a whole placeholder to keep the claim type, got %#v", body["roles"])
+	}
+	if response.Body.(map[string]interface{})["greeting"] != "Hello {{ claims.sub }}" {
+		t.Error("Expected the configured response to be unchanged")
+	}
+}
+
+func TestPrepareCompilesSchema(t *testing.T) {
+	endpoint := Response{
+		Method:    "GET",
+		Responses: []ResponseConfig{{Status: 200, Fake: map[string]interface{}{"id": "uuid"}}},
+	}
+	if err := Prepare(".", &endpoint); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+
+	// Requests reuse the schema compiled by Prepare
+	first, err := endpoint.Responses[0].BodySchema()
+	if err != nil || first == nil {
+		t.Fatalf("Expected a schema, got %v %v", first, err)
+	}
+	resp := endpoint.Responses[0]
+	if second, _ := resp.BodySchema(); second != first {
+		t.Error("Expected the compiled schema to be reused")
+	}
+}
@@ -0,0 +1,331 @@
+package mock
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pagination styles
+const (
+	PaginationPage   = "page"
+	PaginationOffset = "offset"
+	PaginationCursor = "cursor"
+)
+
+// PaginationConfig makes a list response paginated. The items are taken from
+// the body itself when it is an array, or from the array at Field when the
+// body is an object.
+type PaginationConfig struct {
+	Style       string `json:"style"`
+	Field       string `json:"field,omitempty"`
+	MetaField   string `json:"meta_field,omitempty"`
+	PageSize    int    `json:"page_size,omitempty"`
+	MaxPageSize int    `json:"max_page_size,omitempty"`
+	LinkHeader  bool   `json:"link_header,omitempty"`
+}
+
+// ErrInvalidPageQuery is returned when the pagination query parameters of a
+// request are invalid
+var ErrInvalidPageQuery = errors.New("invalid pagination query")
+
+const (
+	defaultPageSize  = 10
+	defaultMetaField = "pagination"
+	cursorPrefix     = "offset:"
+)
+
+// Validate checks the pagination settings
+func (p *PaginationConfig) Validate() error {
+	switch p.Style {
+	case PaginationPage, PaginationOffset, PaginationCursor:
+	default:
+		return fmt.Errorf("unknown pagination style %q, expected page, offset or cursor", p.Style)
+	}
+	if p.PageSize < 0 || p.MaxPageSize < 0 {
+		return fmt.Errorf("pagination sizes must not be negative")
+	}
+	return nil
+}
+
+// Paginate returns the requested page of the body and the headers describing
+// it. Query parameters depend on the style:
+//
+//	page:   ?page=2&per_page=20
+//	offset: ?offset=40&limit=20
+//	cursor: ?cursor=<token>&limit=20
+//
+// requestURL is used to build Link header targets.
+func (p *PaginationConfig) Paginate(body interface{}, query url.Values, requestURL *url.URL) (interface{}, http.Header, error) {
+	items, container, err := p.items(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limitParam := "limit"
+	if p.Style == PaginationPage {
+		limitParam = "per_page"
+	}
+	limit, err := p.limit(query.Get(limitParam), limitParam)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	total := len(items)
+	offset, err := p.offset(query, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Offsets past the items give an empty page, still described as the
+	// page requested; end is capped without adding past total so huge
+	// limits can't overflow
+	start := min(offset, total)
+	end := total
+	if limit < total-start {
+		end = start + limit
+	}
+	page := items[start:end]
+
+	var prev, next *int
+	if offset > 0 {
+		prevOffset := offset - limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		prev = &prevOffset
+	}
+	if end < total {
+		next = &end
+	}
+
+	meta := p.meta(offset, limit, total, prev, next)
+	headers := http.Header{}
+	headers.Set("X-Total-Count", strconv.Itoa(total))
+	if p.Style == PaginationCursor && next != nil {
+		headers.Set("X-Next-Cursor", encodeCursor(*next))
+	}
+	if p.LinkHeader && requestURL != nil {
+		if link := p.linkHeader(requestURL, limit, total, prev, next); link != "" {
+			headers.Set("Link", link)
+		}
+	}
+
+	if container == nil {
+		return page, headers, nil
+	}
+	result := make(map[string]interface{}, len(container)+1)
+	for key, value := range container {
+		result[key] = value
+	}
+	result[p.Field] = page
+	metaField := p.MetaField
+	if metaField == "" {
+		metaField = defaultMetaField
+	}
+	result[metaField] = meta
+	return result, headers, nil
+}
+
+// items extracts the array to paginate and, for object bodies, the object
+// holding it
+func (p *PaginationConfig) items(body interface{}) ([]interface{}, map[string]interface{}, error) {
+	if p.Field == "" {
+		items, ok := body.([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("paginated body must be an array, or set pagination.field")
+		}
+		return items, nil, nil
+	}
+
+	obj, ok := body.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("paginated body must be an object with a %q array", p.Field)
+	}
+	items, ok := obj[p.Field].([]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("paginated body field %q must be an array", p.Field)
+	}
+	return items, obj, nil
+}
+
+func (p *PaginationConfig) limit(value, param string) (int, error) {
+	limit := p.PageSize
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	if value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("%w: %s must be a positive integer", ErrInvalidPageQuery, param)
+		}
+		limit = n
+	}
+	if p.MaxPageSize > 0 && limit > p.MaxPageSize {
+		limit = p.MaxPageSize
+	}
+	return limit, nil
+}
+
+func (p *PaginationConfig) offset(query url.Values, limit int) (int, error) {
+	switch p.Style {
+	case PaginationPage:
+		value := query.Get("page")
+		if value == "" {
+			return 0, nil
+		}
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return 0, fmt.Errorf("%w: page must be a positive integer", ErrInvalidPageQuery)
+		}
+		if page-1 > math.MaxInt/limit {
+			return 0, fmt.Errorf("%w: page is out of range", ErrInvalidPageQuery)
+		}
+		return (page - 1) * limit, nil
+	case PaginationOffset:
+		value := query.Get("offset")
+		if value == "" {
+			return 0, nil
+		}
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, fmt.Errorf("%w: offset must be a non-negative integer", ErrInvalidPageQuery)
+		}
+		return offset, nil
+	case PaginationCursor:
+		value := query.Get("cursor")
+		if value == "" {
+			return 0, nil
+		}
+		offset, err := decodeCursor(value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid cursor", ErrInvalidPageQuery)
+		}
+		return offset, nil
+	}
+	return 0, fmt.Errorf("unknown pagination style %q", p.Style)
+}
+
+// meta builds the pagination metadata embedded in object bodies
+func (p *PaginationConfig) meta(offset, limit, total int, prev, next *int) map[string]interface{} {
+	switch p.Style {
+	case PaginationPage:
+		meta := map[string]interface{}{
+			"page":        offset/limit + 1,
+			"per_page":    limit,
+			"total":       total,
+			"total_pages": pageCount(total, limit),
+			"prev_page":   nil,
+			"next_page":   nil,
+		}
+		if prev != nil {
+			meta["prev_page"] = *prev/limit + 1
+		}
+		if next != nil {
+			meta["next_page"] = *next/limit + 1
+		}
+		return meta
+	case PaginationOffset:
+		meta := map[string]interface{}{
+			"offset":      offset,
+			"limit":       limit,
+			"total":       total,
+			"prev_offset": nil,
+			"next_offset": nil,
+		}
+		if prev != nil {
+			meta["prev_offset"] = *prev
+		}
+		if next != nil {
+			meta["next_offset"] = *next
+		}
+		return meta
+	}
+
+	meta := map[string]interface{}{
+		"limit":       limit,
+		"total":       total,
+		"has_more":    next != nil,
+		"prev_cursor": nil,
+		"next_cursor": nil,
+	}
+	if prev != nil {
+		meta["prev_cursor"] = encodeCursor(*prev)
+	}
+	if next != nil {
+		meta["next_cursor"] = encodeCursor(*next)
+	}
+	return meta
+}
+
+// linkHeader builds an RFC 8288 Link header with first, prev, next and last
+// relations. Cursor pagination only has prev and next.
+func (p *PaginationConfig) linkHeader(requestURL *url.URL, limit, total int, prev, next *int) string {
+	var links []string
+	add := func(rel string, offset int) {
+		u := *requestURL
+		q := u.Query()
+		switch p.Style {
+		case PaginationPage:
+			q.Set("page", strconv.Itoa(offset/limit+1))
+			q.Set("per_page", strconv.Itoa(limit))
+		case PaginationOffset:
+			q.Set("offset", strconv.Itoa(offset))
+			q.Set("limit", strconv.Itoa(limit))
+		case PaginationCursor:
+			q.Set("cursor", encodeCursor(offset))
+			q.Set("limit", strconv.Itoa(limit))
+		}
+		u.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel))
+	}
+
+	if p.Style != PaginationCursor {
+		add("first", 0)
+	}
+	if prev != nil {
+		add("prev", *prev)
+	}
+	if next != nil {
+		add("next", *next)
+	}
+	if p.Style != PaginationCursor && total > 0 {
+		add("last", (total-1)/limit*limit)
+	}
+	return strings.Join(links, ", ")
+}
+
+// pageCount returns the number of pages of limit items needed for total
+// items, without overflowing for huge limits
+func pageCount(total, limit int) int {
+	pages := total / limit
+	if total%limit != 0 {
+		pages++
+	}
+	return pages
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	text := string(data)
+	if !strings.HasPrefix(text, cursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(text, cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
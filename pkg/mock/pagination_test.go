@@ -0,0 +1,244 @@
+package mock
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func numbers(n int) []interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = float64(i + 1)
+	}
+	return items
+}
+
+func TestPaginatePageStyle(t *testing.T) {
+	p := &PaginationConfig{Style: PaginationPage, Field: "data", PageSize: 10, LinkHeader: true}
+	body := map[string]interface{}{"data": numbers(25), "object": "list"}
+	requestURL, _ := url.Parse("http://localhost:8080/items?page=2&sort=asc")
+
+	result, headers, err := p.Paginate(body, requestURL.Query(), requestURL)
+	if err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+
+	obj := result.(map[string]interface{})
+	data := obj["data"].([]interface{})
+	if len(data) != 10 || data[0] != float64(11) {
+		t.Errorf("Expected items 11-20, got %v", data)
+	}
+	if obj["object"] != "list" {
+		t.Error("Expected other body fields to be kept")
+	}
+	meta := obj["pagination"].(map[string]interface{})
+	if meta["page"] != 2 || meta["total_pages"] != 3 || meta["prev_page"] != 1 || meta["next_page"] != 3 {
+		t.Errorf("Unexpected metadata: %v", meta)
+	}
+
+	if headers.Get("X-Total-Count") != "25" {
+		t.Errorf("Expected X-Total-Count 25, got %q", headers.Get("X-Total-Count"))
+	}
+	link := headers.Get("Link")
+	for _, want := range []string{
+		`<http://localhost:8080/items?page=1&per_page=10&sort=asc>; rel="first"`,
+		`<http://localhost:8080/items?page=3&per_page=10&sort=asc>; rel="next"`,
+		`<http://localhost:8080/items?page=3&per_page=10&sort=asc>; rel="last"`,
+	} {
+		if !strings.Contains(link, want) {
+			t.Errorf("Expected Link header to contain %s, got %s", want, link)
+		}
+	}
+
+	// The original body is not modified
+	if len(body["data"].([]interface{})) != 25 {
+		t.Error("Expected the configured body to be unchanged")
+	}
+}
+
+func TestPaginateOffsetStyle(t *testing.T) {
+	p := &PaginationConfig{Style: PaginationOffset, MaxPageSize: 5}
+
+	result, _, err := p.Paginate(numbers(12), url.Values{"offset": {"10"}, "limit": {"50"}}, nil)
+	if err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+	items := result.([]interface{})
+	if len(items) != 2 || items[0] != float64(11) {
+		t.Errorf("Expected last two items, got %v", items)
+	}
+
+	result, _, _ = p.Paginate(numbers(12), url.Values{"offset": {"40"}}, nil)
+	if len(result.([]interface{})) != 0 {
+		t.Errorf("Expected empty page past the end, got %v", result)
+	}
+}
+
+func TestPaginatePastTheEnd(t *testing.T) {
+	requestURL, _ := url.Parse("http://localhost:8080/items")
+	testCases := []struct {
+		name  string
+		style string
+		query url.Values
+		meta  map[string]interface{}
+		links []string
+	}{
+		{
+			name:  "page",
+			style: PaginationPage,
+			query: url.Values{"page": {"4"}},
+			meta:  map[string]interface{}{"page": 4, "prev_page": 3, "next_page": nil, "total_pages": 3},
+			links: []string{
+				`<http://localhost:8080/items?page=3&per_page=10>; rel="prev"`,
+				`<http://localhost:8080/items?page=3&per_page=10>; rel="last"`,
+			},
+		},
+		{
+			name:  "offset",
+			style: PaginationOffset,
+			query: url.Values{"offset": {"40"}},
+			meta:  map[string]interface{}{"offset": 40, "prev_offset": 30, "next_offset": nil},
+			links: []string{
+				`<http://localhost:8080/items?limit=10&offset=30>; rel="prev"`,
+				`<http://localhost:8080/items?limit=10&offset=20>; rel="last"`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &PaginationConfig{Style: tc.style, Field: "data", LinkHeader: true}
+			result, headers, err := p.Paginate(map[string]interface{}{"data": numbers(25)}, tc.query, requestURL)
+			if err != nil {
+				t.Fatalf("Paginate failed: %v", err)
+			}
+			obj := result.(map[string]interface{})
+			if data := obj["data"].([]interface{}); len(data) != 0 {
+				t.Errorf("Expected an empty page, got %v", data)
+			}
+			meta := obj["pagination"].(map[string]interface{})
+			for key, want := range tc.meta {
+				if meta[key] != want {
+					t.Errorf("Expected %s %v, got %v", key, want, meta[key])
+				}
+			}
+			link := headers.Get("Link")
+			for _, want := range tc.links {
+				if !strings.Contains(link, want) {
+					t.Errorf("Expected Link header to contain %s, got %s", want, link)
+				}
+			}
+			if strings.Contains(link, `rel="next"`) {
+				t.Errorf("Expected no next link, got %s", link)
+			}
+		})
+	}
+}
+
+func TestPaginateCursorStyle(t *testing.T) {
+	p := &PaginationConfig{Style: PaginationCursor, Field: "items", PageSize: 4}
+	body := map[string]interface{}{"items": numbers(10)}
+
+	var seen []interface{}
+	query := url.Values{}
+	for page := 0; page < 5; page++ {
+		result, headers, err := p.Paginate(body, query, nil)
+		if err != nil {
+			t.Fatalf("Paginate failed: %v", err)
+		}
+		obj := result.(map[string]interface{})
+		seen = append(seen, obj["items"].([]interface{})...)
+
+		meta := obj["pagination"].(map[string]interface{})
+		if meta["has_more"] == false {
+			if headers.Get("X-Next-Cursor") != "" {
+				t.Error("Expected no next cursor on the last page")
+			}
+			break
+		}
+		query = url.Values{"cursor": {meta["next_cursor"].(string)}}
+	}
+	if len(seen) != 10 || seen[9] != float64(10) {
+		t.Errorf("Expected to walk all 10 items, got %v", seen)
+	}
+}
+
+func TestPaginateErrors(t *testing.T) {
+	p := &PaginationConfig{Style: PaginationPage}
+
+	for _, query := range []url.Values{
+		{"page": {"0"}},
+		{"per_page": {"abc"}},
+	} {
+		if _, _, err := p.Paginate(numbers(3), query, nil); !errors.Is(err, ErrInvalidPageQuery) {
+			t.Errorf("Expected invalid query error for %v, got %v", query, err)
+		}
+	}
+
+	cursor := &PaginationConfig{Style: PaginationCursor}
+	if _, _, err := cursor.Paginate(numbers(3), url.Values{"cursor": {"garbage"}}, nil); !errors.Is(err, ErrInvalidPageQuery) {
+		t.Errorf("Expected invalid cursor error, got %v", err)
+	}
+
+	if _, _, err := p.Paginate(map[string]interface{}{}, url.Values{}, nil); err == nil || errors.Is(err, ErrInvalidPageQuery) {
+		t.Errorf("Expected configuration error for a non-array body, got %v", err)
+	}
+
+	if err := (&PaginationConfig{Style: "links"}).Validate(); err == nil {
+		t.Error("Expected error for unknown style")
+	}
+}
+
+func TestPaginateExtremeValues(t *testing.T) {
+	items := numbers(5)
+
+	page := &PaginationConfig{Style: PaginationPage}
+	for _, query := range []string{"page=9223372036854775807", "page=9223372036854775807&per_page=2"} {
+		values, _ := url.ParseQuery(query)
+		if _, _, err := page.Paginate(items, values, nil); !errors.Is(err, ErrInvalidPageQuery) {
+			t.Errorf("%s: expected an invalid page error, got %v", query, err)
+		}
+	}
+	values, _ := url.ParseQuery("page=2&per_page=9223372036854775807")
+	result, _, err := page.Paginate(items, values, nil)
+	if err != nil || len(result.([]interface{})) != 0 {
+		t.Errorf("Expected page 2 of a huge page size to be empty, got %v %v", result, err)
+	}
+	values, _ = url.ParseQuery("per_page=9223372036854775807")
+	paged := &PaginationConfig{Style: PaginationPage, Field: "data"}
+	result, _, err = paged.Paginate(map[string]interface{}{"data": items}, values, nil)
+	if err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+	meta := result.(map[string]interface{})["pagination"].(map[string]interface{})
+	if meta["total_pages"] != 1 || len(result.(map[string]interface{})["data"].([]interface{})) != 5 {
+		t.Errorf("Expected every item on one page, got %v", result)
+	}
+
+	offset := &PaginationConfig{Style: PaginationOffset}
+	for _, query := range []string{
+		"offset=9223372036854775800",
+		"offset=9223372036854775800&limit=100",
+		"offset=3&limit=9223372036854775807",
+	} {
+		values, _ := url.ParseQuery(query)
+		result, _, err := offset.Paginate(items, values, nil)
+		if err != nil {
+			t.Fatalf("%s: Paginate failed: %v", query, err)
+		}
+		want := 0
+		if strings.HasPrefix(query, "offset=3") {
+			want = 2
+		}
+		if got := len(result.([]interface{})); got != want {
+			t.Errorf("%s: expected %d items, got %d", query, want, got)
+		}
+	}
+
+	cursor := &PaginationConfig{Style: PaginationCursor}
+	values, _ = url.ParseQuery("cursor=" + encodeCursor(9223372036854775807))
+	if result, _, err := cursor.Paginate(items, values, nil); err != nil || len(result.([]interface{})) != 0 {
+		t.Errorf("Expected an empty page for a cursor past the end, got %v %v", result, err)
+	}
+}
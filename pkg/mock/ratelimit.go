@@ -0,0 +1,94 @@
+package mock
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Rate limiting algorithms
+const (
+	RateLimitTokenBucket = "token_bucket"
+	RateLimitFixedWindow = "fixed_window"
+)
+
+// Rate limit keys other than a header
+const (
+	// RateLimitKeyIP counts the requests of each client IP separately
+	RateLimitKeyIP = "ip"
+
+	// rateLimitKeyHeader prefixes the header counting requests by its
+	// value, such as "header:X-Api-Key"
+	rateLimitKeyHeader = "header:"
+)
+
+// RateLimitConfig limits how often an endpoint answers. Requests over the
+// limit get Response, or a 429 with a JSON error, along with Retry-After and
+// X-RateLimit-* headers.
+type RateLimitConfig struct {
+	// Algorithm is token_bucket (the default), which allows bursts of Limit
+	// requests and refills over the window, or fixed_window, which allows
+	// Limit requests per window
+	Algorithm string `json:"algorithm,omitempty"`
+	Limit     int    `json:"limit"`
+	WindowMS  int    `json:"window_ms"`
+
+	// Key shares the limit between all clients when empty, or gives each
+	// client IP ("ip") or header value ("header:X-Api-Key") its own
+	Key string `json:"key,omitempty"`
+
+	// Response is served when the limit is exceeded
+	Response *ResponseConfig `json:"response,omitempty"`
+}
+
+// Validate checks the rate limit settings
+func (c *RateLimitConfig) Validate() error {
+	switch c.Algorithm {
+	case "", RateLimitTokenBucket, RateLimitFixedWindow:
+	default:
+		return fmt.Errorf("unknown rate limit algorithm %q, expected token_bucket or fixed_window", c.Algorithm)
+	}
+	if c.Limit <= 0 || c.WindowMS <= 0 {
+		return fmt.Errorf("rate limits need a positive limit and window_ms")
+	}
+	if c.Key != "" && c.Key != RateLimitKeyIP {
+		if header, ok := strings.CutPrefix(c.Key, rateLimitKeyHeader); !ok || header == "" {
+			return fmt.Errorf("unknown rate limit key %q, expected ip or header:<name>", c.Key)
+		}
+	}
+	return nil
+}
+
+// Window returns the length of a rate limit window
+func (c *RateLimitConfig) Window() time.Duration {
+	return time.Duration(c.WindowMS) * time.Millisecond
+}
+
+// ClientKey returns the key whose requests are counted together. Client IPs
+// are taken from X-Forwarded-For when a proxy set it. Requests without the
+// key header share one limit.
+func (c *RateLimitConfig) ClientKey(r *http.Request) string {
+	return c.KeyFor(r.Header, r.RemoteAddr)
+}
+
+// KeyFor is ClientKey for calls known by their headers and remote address,
+// such as gRPC calls
+func (c *RateLimitConfig) KeyFor(header http.Header, remoteAddr string) string {
+	if name, ok := strings.CutPrefix(c.Key, rateLimitKeyHeader); ok {
+		return header.Get(name)
+	}
+	if c.Key != RateLimitKeyIP {
+		return ""
+	}
+	if forwarded := header.Get("X-Forwarded-For"); forwarded != "" {
+		client, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(client)
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
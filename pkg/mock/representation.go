@@ -0,0 +1,57 @@
+package mock
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+
+	"github.com/sachin-duhan/gomock/pkg/negotiate"
+)
+
+// Representation is one of the forms a response can take, chosen by the
+// request's Accept header. JSON content types encode the body as JSON,
+// string bodies are sent verbatim and binary formats such as protobuf are
+// given base64 encoded.
+type Representation struct {
+	ContentType string      `json:"content_type"`
+	Body        interface{} `json:"body,omitempty"`
+	BodyBase64  string      `json:"body_base64,omitempty"`
+}
+
+// Content returns the raw body of a base64 representation
+func (r *Representation) Content() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(r.BodyBase64)
+}
+
+// ContentTypes lists the content types of the representations in order
+func (c *ResponseConfig) ContentTypes() []string {
+	types := make([]string, len(c.Representations))
+	for i, rep := range c.Representations {
+		types[i] = rep.ContentType
+	}
+	return types
+}
+
+// validateNegotiation checks the representations and content codings of a
+// response
+func (c *ResponseConfig) validateNegotiation() error {
+	for i, rep := range c.Representations {
+		if _, _, err := mime.ParseMediaType(rep.ContentType); err != nil {
+			return fmt.Errorf("representation %d: invalid content_type %q", i, rep.ContentType)
+		}
+		if rep.BodyBase64 != "" {
+			if rep.Body != nil {
+				return fmt.Errorf("representation %d: set either body or body_base64", i)
+			}
+			if _, err := rep.Content(); err != nil {
+				return fmt.Errorf("representation %d: invalid body_base64: %v", i, err)
+			}
+		}
+	}
+	for _, encoding := range c.Encodings {
+		if !negotiate.Supported(encoding) {
+			return fmt.Errorf("unknown encoding %q, expected gzip, br or deflate", encoding)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package mock
+
+import "fmt"
+
+// ScenarioStarted is the state every scenario is in until a response moves it
+const ScenarioStarted = "Started"
+
+// ScenarioConfig ties a response to the state of a named scenario, so the same
+// request can be answered differently as a workflow progresses
+type ScenarioConfig struct {
+	Name string `json:"name"`
+
+	// State restricts the response to the scenario being in this state
+	State string `json:"state,omitempty"`
+
+	// SetState moves the scenario to a new state once the response is served
+	SetState string `json:"set_state,omitempty"`
+}
+
+// Validate checks the scenario config
+func (c *ScenarioConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("scenario name is required")
+	}
+	return nil
+}
+
+// ForScenarios returns the endpoint restricted to the responses that apply to
+// the current scenario states, as returned by state. Responses requiring the
+// current state win over responses without a state requirement. The endpoint
+// has no responses left when every response requires a state other than the
+// current one.
+func (r *Response) ForScenarios(state func(name string) string) *Response {
+	var pinned, unpinned []ResponseConfig
+	for _, resp := range r.Responses {
+		switch {
+		case resp.Scenario == nil || resp.Scenario.State == "":
+			unpinned = append(unpinned, resp)
+		case resp.Scenario.State == state(resp.Scenario.Name):
+			pinned = append(pinned, resp)
+		}
+	}
+
+	filtered := *r
+	switch {
+	case len(pinned) > 0:
+		filtered.Responses = pinned
+	case len(unpinned) == len(r.Responses):
+		return r
+	default:
+		filtered.Responses = unpinned
+	}
+	return &filtered
+}
+
+// Scenarios lists the names of the scenarios the endpoint refers to
+func (r *Response) Scenarios() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, resp := range r.Responses {
+		if resp.Scenario != nil && !seen[resp.Scenario.Name] {
+			seen[resp.Scenario.Name] = true
+			names = append(names, resp.Scenario.Name)
+		}
+	}
+	return names
+}
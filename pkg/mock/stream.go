@@ -0,0 +1,78 @@
+package mock
+
+import "fmt"
+
+// Stream types
+const (
+	StreamSSE     = "sse"
+	StreamChunked = "chunked"
+	StreamNDJSON  = "ndjson"
+)
+
+// StreamConfig turns a response into a stream written piece by piece.
+// Server-Sent Events use Events, chunked and NDJSON streams use Chunks.
+type StreamConfig struct {
+	Type   string        `json:"type"`
+	Events []StreamEvent `json:"events,omitempty"`
+	Chunks []StreamChunk `json:"chunks,omitempty"`
+
+	// DelayMS is the default pause before each event or chunk
+	DelayMS int `json:"delay_ms,omitempty"`
+}
+
+// StreamEvent is a single Server-Sent Event. String data is sent verbatim,
+// other values as JSON.
+type StreamEvent struct {
+	ID      string      `json:"id,omitempty"`
+	Event   string      `json:"event,omitempty"`
+	Data    interface{} `json:"data"`
+	Retry   int         `json:"retry,omitempty"`
+	DelayMS *int        `json:"delay_ms,omitempty"`
+}
+
+// StreamChunk is a piece of a chunked or NDJSON stream. In chunked streams
+// string data is sent verbatim and other values as JSON; in NDJSON streams
+// every chunk is a JSON line.
+type StreamChunk struct {
+	Data    interface{} `json:"data"`
+	DelayMS *int        `json:"delay_ms,omitempty"`
+}
+
+// Validate checks the stream type and delays
+func (c *StreamConfig) Validate() error {
+	switch c.Type {
+	case StreamSSE:
+		if len(c.Chunks) > 0 {
+			return fmt.Errorf("sse streams use events, not chunks")
+		}
+	case StreamChunked, StreamNDJSON:
+		if len(c.Events) > 0 {
+			return fmt.Errorf("%s streams use chunks, not events", c.Type)
+		}
+	default:
+		return fmt.Errorf("unknown stream type %q, expected sse, chunked or ndjson", c.Type)
+	}
+
+	if c.DelayMS < 0 {
+		return fmt.Errorf("stream delay_ms must not be negative")
+	}
+	for i, event := range c.Events {
+		if event.Retry < 0 || event.DelayMS != nil && *event.DelayMS < 0 {
+			return fmt.Errorf("stream event %d: retry and delay_ms must not be negative", i)
+		}
+	}
+	for i, chunk := range c.Chunks {
+		if chunk.DelayMS != nil && *chunk.DelayMS < 0 {
+			return fmt.Errorf("stream chunk %d: delay_ms must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// Delay returns the pause before an item, falling back to the stream default
+func (c *StreamConfig) Delay(itemDelay *int) int {
+	if itemDelay != nil {
+		return *itemDelay
+	}
+	return c.DelayMS
+}
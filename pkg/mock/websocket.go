@@ -0,0 +1,64 @@
+package mock
+
+import "fmt"
+
+// WebSocketConfig scripts the server side of a WebSocket endpoint. Incoming
+// messages are answered from the endpoint responses using input_body
+// matching; the scripted messages below are sent without being asked.
+type WebSocketConfig struct {
+	// OnConnect messages are sent once the connection is upgraded, each after
+	// its own delay and optionally repeated on an interval
+	OnConnect []ScriptedMessage `json:"on_connect,omitempty"`
+
+	// Close closes the connection with the given code after AfterMS
+	// milliseconds
+	Close *CloseFrame `json:"close,omitempty"`
+}
+
+// ScriptedMessage is a message pushed by the server
+type ScriptedMessage struct {
+	Body    interface{} `json:"body"`
+	DelayMS int         `json:"delay_ms,omitempty"`
+
+	// IntervalMS repeats the message on a timer; Repeat limits the number of
+	// repetitions, zero repeating until the connection closes
+	IntervalMS int `json:"interval_ms,omitempty"`
+	Repeat     int `json:"repeat,omitempty"`
+}
+
+// CloseFrame describes how the server closes a WebSocket connection. On a
+// response it closes the connection after the reply is sent.
+type CloseFrame struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason,omitempty"`
+	AfterMS int    `json:"after_ms,omitempty"`
+}
+
+// Validate checks the scripted messages and close frame
+func (c *WebSocketConfig) Validate() error {
+	for i, msg := range c.OnConnect {
+		if msg.DelayMS < 0 || msg.IntervalMS < 0 || msg.Repeat < 0 {
+			return fmt.Errorf("websocket on_connect[%d]: delays and repeats must not be negative", i)
+		}
+	}
+	if c.Close != nil {
+		return c.Close.Validate()
+	}
+	return nil
+}
+
+// Validate checks that the close code can be sent by a server. 1000-2999 are
+// defined by the protocol and 3000-4999 are free for applications.
+func (c *CloseFrame) Validate() error {
+	switch {
+	case c.Code == 1004 || c.Code == 1005 || c.Code == 1006 || c.Code == 1015:
+		return fmt.Errorf("websocket close code %d is reserved", c.Code)
+	case c.Code < 1000 || c.Code > 4999:
+		return fmt.Errorf("websocket close code %d is out of range 1000-4999", c.Code)
+	case c.AfterMS < 0:
+		return fmt.Errorf("websocket close after_ms must not be negative")
+	case len(c.Reason) > 123:
+		return fmt.Errorf("websocket close reason must be at most 123 bytes")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/sachin-duhan/gomock/pkg/soap"
+)
+
+// XMLResponse selects a response to an XML or SOAP request and shapes the
+// XML it returns. Empty criteria match any XML request.
+type XMLResponse struct {
+	// SOAPAction matches the SOAPAction header, or the action parameter of
+	// a SOAP 1.2 Content-Type
+	SOAPAction string `json:"soap_action,omitempty"`
+
+	// XPath predicates must all hold for the request body, for example
+	// //u:GetUser/u:id = '42'. Prefixes are resolved with Namespaces.
+	XPath      []string          `json:"xpath,omitempty"`
+	Namespaces map[string]string `json:"namespaces,omitempty"`
+
+	// SOAP wraps the body in a SOAP envelope of the given version, 1.1 or
+	// 1.2. Responses with a fault default to 1.1.
+	SOAP  string     `json:"soap,omitempty"`
+	Fault *SOAPFault `json:"fault,omitempty"`
+}
+
+// SOAPFault is returned in place of the body. Detail is raw XML.
+type SOAPFault struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// HasCriteria reports whether the response selects requests
+func (x *XMLResponse) HasCriteria() bool {
+	return x.SOAPAction != "" || len(x.XPath) > 0
+}
+
+// Matches reports whether the request satisfies the SOAP action and every
+// XPath predicate
+func (x *XMLResponse) Matches(req *soap.Request) bool {
+	if x.SOAPAction != "" && x.SOAPAction != req.Action {
+		return false
+	}
+	for _, predicate := range x.XPath {
+		expr, err := soap.Compile(predicate, x.Namespaces)
+		if err != nil || !req.Matches(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Version returns the SOAP version of the response, empty for plain XML
+func (x *XMLResponse) Version() string {
+	if x.SOAP == "" && x.Fault != nil {
+		return soap.Version11
+	}
+	return x.SOAP
+}
+
+// Validate checks the SOAP version and XPath predicates
+func (x *XMLResponse) Validate() error {
+	if x.SOAP != "" && x.SOAP != soap.Version11 && x.SOAP != soap.Version12 {
+		return fmt.Errorf("unknown SOAP version %q, expected 1.1 or 1.2", x.SOAP)
+	}
+	for _, predicate := range x.XPath {
+		if _, err := soap.Compile(predicate, x.Namespaces); err != nil {
+			return fmt.Errorf("invalid xpath %q: %v", predicate, err)
+		}
+	}
+	return nil
+}
+
+// FindXMLResponse returns the first response whose XML criteria match the
+// request. Responses without criteria serve as the default.
+func (r *Response) FindXMLResponse(req *soap.Request) *ResponseConfig {
+	var fallback *ResponseConfig
+	for i := range r.Responses {
+		resp := &r.Responses[i]
+		if resp.XML == nil || !resp.XML.HasCriteria() {
+			if fallback == nil {
+				fallback = resp
+			}
+			continue
+		}
+		if resp.XML.Matches(req) {
+			return resp
+		}
+	}
+	return fallback
+}
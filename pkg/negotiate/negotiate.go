@@ -0,0 +1,148 @@
+package negotiate
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Supported content codings
+const (
+	Gzip    = "gzip"
+	Brotli  = "br"
+	Deflate = "deflate"
+)
+
+// clause is one entry of an Accept or Accept-Encoding header
+type clause struct {
+	value string
+	q     float64
+}
+
+// parseHeader splits a header into values and quality factors. Values are
+// lowercased and media type parameters other than q are dropped.
+func parseHeader(header string) []clause {
+	var clauses []clause
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(fields[0]))
+		if value == "" {
+			continue
+		}
+		c := clause{value: value, q: 1}
+		for _, param := range fields[1:] {
+			name, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(name), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					c.q = q
+				}
+			}
+		}
+		clauses = append(clauses, c)
+	}
+	return clauses
+}
+
+// ContentType picks the offered content type that best fits an Accept header
+// and returns its index. The most specific matching media range sets the
+// quality of an offer; ties go to the earlier offer. Without an Accept header
+// the first offer is chosen. ok is false when no offer is acceptable.
+func ContentType(accept string, offers []string) (index int, ok bool) {
+	if len(offers) == 0 {
+		return -1, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return 0, true
+	}
+
+	ranges := parseHeader(accept)
+	best, bestQ := -1, 0.0
+	for i, offer := range offers {
+		mediaType, _, err := mime.ParseMediaType(offer)
+		if err != nil {
+			continue
+		}
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := matchMediaRange(r.value, mediaType)
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if specificity >= 0 && q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	return best, best >= 0
+}
+
+// matchMediaRange returns how specific a media range match is: 2 for an
+// exact type, 1 for type/*, 0 for */* and -1 when the range does not match
+func matchMediaRange(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*" || mediaRange == "*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Encoding picks the content coding for an Accept-Encoding header among the
+// offered ones. The client's highest quality wins and ties go to the earlier
+// offer. An empty result means the response is sent uncompressed.
+func Encoding(acceptEncoding string, offers []string) string {
+	clauses := parseHeader(acceptEncoding)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, found := 0.0, false
+		for _, c := range clauses {
+			if c.value == offer {
+				q, found = c.q, true
+				break
+			}
+			if c.value == "*" && !found {
+				q = c.q
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Supported reports whether a content coding can be produced
+func Supported(encoding string) bool {
+	return encoding == Gzip || encoding == Brotli || encoding == Deflate
+}
+
+// Writer is a compressing writer that can flush buffered data
+type Writer interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// NewWriter returns a writer compressing to w with the given content coding
+func NewWriter(w io.Writer, encoding string) (Writer, error) {
+	switch encoding {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Brotli:
+		return brotli.NewWriter(w), nil
+	case Deflate:
+		// HTTP deflate is the zlib format
+		return zlib.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported content coding %q", encoding)
+	}
+}
@@ -0,0 +1,85 @@
+package negotiate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestContentType(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/plain; charset=utf-8"}
+	tests := []struct {
+		accept string
+		want   int
+		ok     bool
+	}{
+		{"", 0, true},
+		{"*/*", 0, true},
+		{"application/xml", 1, true},
+		{"text/*", 2, true},
+		{"application/xml;q=0.5, application/json;q=0.9", 0, true},
+		{"application/*;q=0.2, application/xml", 1, true},
+		{"*/*;q=0.1, application/json;q=0", 1, true},
+		{"image/png", -1, false},
+		{"application/json;q=0", -1, false},
+	}
+	for _, tt := range tests {
+		got, ok := ContentType(tt.accept, offers)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ContentType(%q) = %d, %v, want %d, %v", tt.accept, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	offers := []string{Brotli, Gzip}
+	tests := map[string]string{
+		"":                         "",
+		"identity":                 "",
+		"gzip":                     Gzip,
+		"gzip, deflate, br":        Brotli,
+		"br;q=0.5, gzip":           Gzip,
+		"*":                        Brotli,
+		"*, br;q=0":                Gzip,
+		"deflate":                  "",
+		"GZIP;q=0.8, identity;q=1": Gzip,
+	}
+	for accept, want := range tests {
+		if got := Encoding(accept, offers); got != want {
+			t.Errorf("Encoding(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	readers := map[string]func(io.Reader) (io.Reader, error){
+		Gzip:    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		Deflate: func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		Brotli:  func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for encoding, newReader := range readers {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, encoding)
+		if err != nil {
+			t.Fatalf("NewWriter(%s) failed: %v", encoding, err)
+		}
+		w.Write([]byte("hello"))
+		w.Close()
+
+		r, err := newReader(&buf)
+		if err != nil {
+			t.Fatalf("%s reader failed: %v", encoding, err)
+		}
+		if data, err := io.ReadAll(r); err != nil || string(data) != "hello" {
+			t.Errorf("%s round trip = %q, %v", encoding, data, err)
+		}
+	}
+
+	if _, err := NewWriter(io.Discard, "compress"); err == nil {
+		t.Error("Expected error for unsupported coding")
+	}
+}
@@ -0,0 +1,318 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec represents the parts of an OpenAPI 3 document used for validation
+type Spec struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	routes []route
+}
+
+// Components holds the reusable objects referenced from operations
+type Components struct {
+	Schemas       map[string]*schema.Schema `json:"schemas,omitempty"`
+	Parameters    map[string]*Parameter     `json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody   `json:"requestBodies,omitempty"`
+	Responses     map[string]*ResponseSpec  `json:"responses,omitempty"`
+}
+
+// PathItem represents the operations available on a single path
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+}
+
+// Operation represents a single API operation on a path
+type Operation struct {
+	OperationID string                   `json:"operationId,omitempty"`
+	Parameters  []*Parameter             `json:"parameters,omitempty"`
+	RequestBody *RequestBody             `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseSpec `json:"responses,omitempty"`
+}
+
+// Parameter represents a path, query or header parameter
+type Parameter struct {
+	Ref      string         `json:"$ref,omitempty"`
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *schema.Schema `json:"schema,omitempty"`
+}
+
+// RequestBody represents the documented request body of an operation
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty"`
+}
+
+// ResponseSpec represents a documented response of an operation
+type ResponseSpec struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema for a single content type
+type MediaType struct {
+	Schema *schema.Schema `json:"schema,omitempty"`
+}
+
+// route is a compiled path template such as /users/{id}
+type route struct {
+	template string
+	pattern  *regexp.Regexp
+	params   []string
+	item     *PathItem
+}
+
+var templateParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// Load reads an OpenAPI document from a JSON or YAML file
+func Load(path string) (*Spec, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" {
+		var doc interface{}
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %v", path, err)
+		}
+		if content, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("failed to convert %s to JSON: %v", path, err)
+		}
+	}
+
+	return Parse(content)
+}
+
+// Parse decodes an OpenAPI document from JSON and resolves its references
+func Parse(content []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %v", err)
+	}
+	if err := spec.resolve(); err != nil {
+		return nil, err
+	}
+	spec.compileRoutes()
+	return &spec, nil
+}
+
+// FindOperation returns the operation documented for the given method and
+// request path along with the values of its path parameters
+func (s *Spec) FindOperation(method, path string) (*Operation, *PathItem, map[string]string) {
+	for _, rt := range s.routes {
+		match := rt.pattern.FindStringSubmatch(path)
+		if match == nil {
+			continue
+		}
+		op := rt.item.operation(method)
+		if op == nil {
+			continue
+		}
+		params := make(map[string]string, len(rt.params))
+		for i, name := range rt.params {
+			params[name] = match[i+1]
+		}
+		return op, rt.item, params
+	}
+	return nil, nil, nil
+}
+
+func (p *PathItem) operation(method string) *Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return p.Get
+	case "PUT":
+		return p.Put
+	case "POST":
+		return p.Post
+	case "DELETE":
+		return p.Delete
+	case "PATCH":
+		return p.Patch
+	case "HEAD":
+		return p.Head
+	case "OPTIONS":
+		return p.Options
+	}
+	return nil
+}
+
+func (p *PathItem) operations() []*Operation {
+	return []*Operation{p.Get, p.Put, p.Post, p.Delete, p.Patch, p.Head, p.Options}
+}
+
+// compileRoutes turns path templates into regular expressions. Literal
+// paths are ordered before templated ones so /users/me wins over /users/{id}.
+func (s *Spec) compileRoutes() {
+	templates := make([]string, 0, len(s.Paths))
+	for template := range s.Paths {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		pi := strings.Count(templates[i], "{")
+		pj := strings.Count(templates[j], "{")
+		if pi != pj {
+			return pi < pj
+		}
+		return templates[i] < templates[j]
+	})
+
+	for _, template := range templates {
+		var params []string
+		expr := "^"
+		last := 0
+		for _, loc := range templateParam.FindAllStringSubmatchIndex(template, -1) {
+			expr += regexp.QuoteMeta(template[last:loc[0]]) + "([^/]+)"
+			params = append(params, template[loc[2]:loc[3]])
+			last = loc[1]
+		}
+		expr += regexp.QuoteMeta(template[last:]) + "$"
+
+		s.routes = append(s.routes, route{
+			template: template,
+			pattern:  regexp.MustCompile(expr),
+			params:   params,
+			item:     s.Paths[template],
+		})
+	}
+}
+
+// resolve links every component reference used by the document
+func (s *Spec) resolve() error {
+	lookup := func(ref string) (*schema.Schema, error) {
+		name, ok := schema.LocalRefName(ref, "#/components/schemas/")
+		if !ok {
+			return nil, fmt.Errorf("unsupported schema reference %s", ref)
+		}
+		target, exists := s.Components.Schemas[name]
+		if !exists {
+			return nil, fmt.Errorf("unresolved schema reference %s", ref)
+		}
+		return target, nil
+	}
+
+	resolveSchema := func(sch *schema.Schema) error {
+		return schema.ResolveRefs(sch, lookup)
+	}
+	resolveContent := func(content map[string]*MediaType) error {
+		for _, media := range content {
+			if media != nil {
+				if err := resolveSchema(media.Schema); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, sch := range s.Components.Schemas {
+		if err := resolveSchema(sch); err != nil {
+			return err
+		}
+	}
+
+	for template, item := range s.Paths {
+		if item == nil {
+			return fmt.Errorf("path %s has no operations", template)
+		}
+		params, err := s.resolveParameters(item.Parameters)
+		if err != nil {
+			return err
+		}
+		item.Parameters = params
+
+		for _, op := range item.operations() {
+			if op == nil {
+				continue
+			}
+			if op.Parameters, err = s.resolveParameters(op.Parameters); err != nil {
+				return err
+			}
+			for _, param := range op.Parameters {
+				if err := resolveSchema(param.Schema); err != nil {
+					return err
+				}
+			}
+			if op.RequestBody != nil && op.RequestBody.Ref != "" {
+				name, _ := schema.LocalRefName(op.RequestBody.Ref, "#/components/requestBodies/")
+				body, ok := s.Components.RequestBodies[name]
+				if !ok {
+					return fmt.Errorf("unresolved request body reference %s", op.RequestBody.Ref)
+				}
+				op.RequestBody = body
+			}
+			if op.RequestBody != nil {
+				if err := resolveContent(op.RequestBody.Content); err != nil {
+					return err
+				}
+			}
+			for status, resp := range op.Responses {
+				if resp != nil && resp.Ref != "" {
+					name, _ := schema.LocalRefName(resp.Ref, "#/components/responses/")
+					target, ok := s.Components.Responses[name]
+					if !ok {
+						return fmt.Errorf("unresolved response reference %s", resp.Ref)
+					}
+					op.Responses[status] = target
+					resp = target
+				}
+				if resp != nil {
+					if err := resolveContent(resp.Content); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, param := range item.Parameters {
+			if err := resolveSchema(param.Schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Spec) resolveParameters(params []*Parameter) ([]*Parameter, error) {
+	resolved := make([]*Parameter, 0, len(params))
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		if param.Ref != "" {
+			name, _ := schema.LocalRefName(param.Ref, "#/components/parameters/")
+			target, ok := s.Components.Parameters[name]
+			if !ok {
+				return nil, fmt.Errorf("unresolved parameter reference %s", param.Ref)
+			}
+			param = target
+		}
+		resolved = append(resolved, param)
+	}
+	return resolved, nil
+}
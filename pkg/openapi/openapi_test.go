@@ -0,0 +1,319 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+)
+
+const testSpec = `
+openapi: 3.0.3
+paths:
+  /users:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            maximum: 100
+      responses:
+        "200":
+          description: List of users
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [users]
+                properties:
+                  users:
+                    type: array
+                    items:
+                      $ref: "#/components/schemas/User"
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/NewUser"
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+        4XX:
+          description: Client error
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      parameters:
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+    NewUser:
+      type: object
+      required: [name, email]
+      properties:
+        name:
+          type: string
+          minLength: 1
+        email:
+          type: string
+          format: email
+`
+
+func loadTestSpec(t *testing.T) *Spec {
+	tempDir, err := ioutil.TempDir("", "gomock-openapi")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	specPath := filepath.Join(tempDir, "openapi.yaml")
+	if err := ioutil.WriteFile(specPath, []byte(testSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	spec, err := Load(specPath)
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	return spec
+}
+
+func TestValidateRequest(t *testing.T) {
+	spec := loadTestSpec(t)
+
+	testCases := []struct {
+		name       string
+		method     string
+		target     string
+		headers    map[string]string
+		body       interface{}
+		violations []string
+	}{
+		{
+			name:   "Valid query parameter",
+			method: "GET",
+			target: "/users?limit=10",
+		},
+		{
+			name:       "Query parameter with wrong type",
+			method:     "GET",
+			target:     "/users?limit=abc",
+			violations: []string{"query limit"},
+		},
+		{
+			name:       "Query parameter above maximum",
+			method:     "GET",
+			target:     "/users?limit=500",
+			violations: []string{"query limit"},
+		},
+		{
+			name:    "Valid path and header parameters",
+			method:  "GET",
+			target:  "/users/42",
+			headers: map[string]string{"X-Tenant": "acme"},
+		},
+		{
+			name:       "Invalid path parameter and missing header",
+			method:     "GET",
+			target:     "/users/abc",
+			violations: []string{"path id", "header X-Tenant"},
+		},
+		{
+			name:   "Valid request body",
+			method: "POST",
+			target: "/users",
+			body:   map[string]interface{}{"name": "Test User", "email": "test@example.com"},
+		},
+		{
+			name:       "Missing required body",
+			method:     "POST",
+			target:     "/users",
+			violations: []string{"body"},
+		},
+		{
+			name:       "Body schema violations",
+			method:     "POST",
+			target:     "/users",
+			body:       map[string]interface{}{"name": "", "email": "nope"},
+			violations: []string{"body /email", "body /name"},
+		},
+		{
+			name:   "Undocumented operation is not validated",
+			method: "DELETE",
+			target: "/users",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.target, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+
+			violations := spec.ValidateRequest(req, tc.body)
+			if len(violations) != len(tc.violations) {
+				t.Fatalf("Expected %d violations, got %d: %v", len(tc.violations), len(violations), violations)
+			}
+			for i, prefix := range tc.violations {
+				if !strings.HasPrefix(violations[i].Error(), prefix) {
+					t.Errorf("Expected violation %d to start with %q, got %q", i, prefix, violations[i].Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMocks(t *testing.T) {
+	spec := loadTestSpec(t)
+
+	responses := map[string]mock.Response{
+		"/users": {
+			Method: "GET",
+			Responses: []mock.ResponseConfig{
+				{Status: 200, Body: map[string]interface{}{"users": []interface{}{map[string]interface{}{"id": 1, "name": "Test User"}}}},
+				{Status: 500, Body: map[string]interface{}{"error": "boom"}},
+			},
+		},
+		"/users/1": {
+			Method: "GET",
+			Responses: []mock.ResponseConfig{
+				{Status: 200, Body: map[string]interface{}{"id": "one"}},
+			},
+		},
+		"/orders": {
+			Method: "GET",
+			Responses: []mock.ResponseConfig{
+				{Status: 200, Body: []interface{}{}},
+			},
+		},
+	}
+
+	violations := spec.ValidateMocks(responses)
+	expected := []string{
+		"GET /orders: response: operation GET /orders is not documented",
+		"GET /users (status 500): response: status 500 is not documented",
+		"GET /users/1 (status 200): response /name: required property is missing",
+		"GET /users/1 (status 200): response /id: expected integer, got string",
+	}
+	if len(violations) != len(expected) {
+		t.Fatalf("Expected %d violations, got %d: %v", len(expected), len(violations), violations)
+	}
+	for i, want := range expected {
+		if got := violations[i].Error(); got != want {
+			t.Errorf("Violation %d: expected %q, got %q", i, want, got)
+		}
+	}
+
+	if !violations[0].Warning || violations[1].Warning {
+		t.Errorf("Expected only the undocumented operation to be a warning, got %+v", violations)
+	}
+
+	// Status ranges such as 4XX are honoured
+	if v := spec.ValidateResponse("POST", "/users", 409, "", nil); len(v) != 0 {
+		t.Errorf("Expected 409 to match 4XX, got %v", v)
+	}
+}
+
+func TestValidateResponseContentType(t *testing.T) {
+	spec := loadTestSpec(t)
+
+	// JSON bodies are checked against the schema whatever their parameters
+	if v := spec.ValidateResponse("GET", "/users/1", 200, "application/json; charset=utf-8", map[string]interface{}{"id": "one"}); len(v) == 0 {
+		t.Error("Expected the JSON body to be checked against the schema")
+	}
+
+	// Other bodies are not, and must be documented
+	v := spec.ValidateResponse("GET", "/users/1", 200, "text/plain", "one")
+	if len(v) != 1 || v[0].Message != "content type text/plain is not documented for status 200" {
+		t.Errorf("Expected an undocumented content type, got %v", v)
+	}
+
+	responses := map[string]mock.Response{
+		"/users/1": {
+			Method: "GET",
+			Responses: []mock.ResponseConfig{
+				{Status: 200, Headers: map[string]string{"content-type": "text/csv"}, Body: "id,name"},
+				{Status: 200, Representations: []mock.Representation{
+					{ContentType: "application/json", Body: map[string]interface{}{"id": 1, "name": "Ada"}},
+					{ContentType: "application/xml", Body: "<user/>"},
+				}},
+			},
+		},
+	}
+	var got []string
+	for _, violation := range spec.ValidateMocks(responses) {
+		got = append(got, violation.Error())
+	}
+	expected := []string{
+		"GET /users/1 (status 200): response: content type text/csv is not documented for status 200",
+		"GET /users/1 (status 200): response: content type application/xml is not documented for status 200",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestContentMediaType(t *testing.T) {
+	jsonMedia, formMedia, anyText := &MediaType{}, &MediaType{}, &MediaType{}
+	content := map[string]*MediaType{
+		"application/json":                  jsonMedia,
+		"application/x-www-form-urlencoded": formMedia,
+		"text/*":                            anyText,
+	}
+
+	tests := []struct {
+		contentType string
+		want        *MediaType
+	}{
+		{"", jsonMedia},
+		{"application/json; charset=utf-8", jsonMedia},
+		{"application/merge-patch+json", jsonMedia},
+		{"application/x-www-form-urlencoded", formMedia},
+		{"text/xml", anyText},
+		{"application/xml", nil},
+	}
+	for _, tc := range tests {
+		if got := contentMediaType(content, tc.contentType); got != tc.want {
+			t.Errorf("Content-Type %q: picked the wrong representation", tc.contentType)
+		}
+	}
+}
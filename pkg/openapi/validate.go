@@ -0,0 +1,336 @@
+package openapi
+
+import (
+	"fmt"
+	"math/rand"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/schema"
+)
+
+// Violation describes a mismatch between traffic and the OpenAPI document
+type Violation struct {
+	In      string `json:"in"`
+	Name    string `json:"name,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (v Violation) Error() string {
+	location := v.In
+	if v.Name != "" {
+		location += " " + v.Name
+	}
+	if v.Path != "" {
+		location += " " + v.Path
+	}
+	return location + ": " + v.Message
+}
+
+// MockViolation ties a violation to the mock response that caused it
+type MockViolation struct {
+	Endpoint    string    `json:"endpoint"`
+	Method      string    `json:"method"`
+	Status      int       `json:"status"`
+	Description string    `json:"description,omitempty"`
+	Violation   Violation `json:"violation"`
+
+	// Warning is set for endpoints the document does not describe, which
+	// are expected when mocking against a partial spec
+	Warning bool `json:"warning,omitempty"`
+}
+
+// Error implements the error interface
+func (v MockViolation) Error() string {
+	if v.Warning {
+		return fmt.Sprintf("%s %s: %s", v.Method, v.Endpoint, v.Violation.Error())
+	}
+	return fmt.Sprintf("%s %s (status %d): %s", v.Method, v.Endpoint, v.Status, v.Violation.Error())
+}
+
+// Documents reports whether the spec describes the given method and path
+func (s *Spec) Documents(method, path string) bool {
+	op, _, _ := s.FindOperation(method, path)
+	return op != nil
+}
+
+// ValidateRequest checks the parameters and parsed JSON body of a request
+// against the documented operation. Requests for operations that are not in
+// the document are not validated.
+func (s *Spec) ValidateRequest(r *http.Request, body interface{}) []Violation {
+	op, item, pathParams := s.FindOperation(r.Method, r.URL.Path)
+	if op == nil {
+		return nil
+	}
+
+	var violations []Violation
+	query := r.URL.Query()
+	for _, param := range mergeParameters(item.Parameters, op.Parameters) {
+		var values []string
+		switch param.In {
+		case "path":
+			if value, ok := pathParams[param.Name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[param.Name]
+		case "header":
+			values = r.Header.Values(param.Name)
+		default:
+			continue
+		}
+
+		if len(values) == 0 {
+			if param.Required || param.In == "path" {
+				violations = append(violations, Violation{In: param.In, Name: param.Name, Message: "required parameter is missing"})
+			}
+			continue
+		}
+		if param.Schema == nil {
+			continue
+		}
+		value := coerceParameter(param.Schema, values)
+		for _, err := range param.Schema.Validate(value) {
+			violations = append(violations, Violation{In: param.In, Name: param.Name, Path: err.Path, Message: err.Message})
+		}
+	}
+
+	if op.RequestBody == nil {
+		return violations
+	}
+	if body == nil {
+		if op.RequestBody.Required {
+			violations = append(violations, Violation{In: "body", Message: "request body is required"})
+		}
+		return violations
+	}
+	if media := contentMediaType(op.RequestBody.Content, r.Header.Get("Content-Type")); media != nil && media.Schema != nil {
+		for _, err := range media.Schema.Validate(body) {
+			violations = append(violations, Violation{In: "body", Path: err.Path, Message: err.Message})
+		}
+	}
+	return violations
+}
+
+// ValidateResponse checks a response status and body against the documented
+// responses of the operation. The body is checked against the representation
+// of its Content-Type, JSON when empty. Only JSON bodies are checked against
+// the schema; other bodies are only checked to be documented.
+func (s *Spec) ValidateResponse(method, path string, status int, contentType string, body interface{}) []Violation {
+	op, _, _ := s.FindOperation(method, path)
+	if op == nil {
+		return []Violation{{In: "response", Message: fmt.Sprintf("operation %s %s is not documented", method, path)}}
+	}
+
+	resp := findResponse(op.Responses, status)
+	if resp == nil {
+		return []Violation{{In: "response", Message: fmt.Sprintf("status %d is not documented", status)}}
+	}
+
+	media := contentMediaType(resp.Content, contentType)
+	if media == nil {
+		if contentType != "" && len(resp.Content) > 0 {
+			return []Violation{{In: "response", Message: fmt.Sprintf("content type %s is not documented for status %d", contentType, status)}}
+		}
+		return nil
+	}
+	if media.Schema == nil || !isJSONContentType(contentType) {
+		return nil
+	}
+
+	var violations []Violation
+	for _, err := range media.Schema.Validate(body) {
+		violations = append(violations, Violation{In: "response", Path: err.Path, Message: err.Message})
+	}
+	return violations
+}
+
+// ValidateMocks checks every configured response body against the documented
+// response schema of its endpoint. Endpoints missing from the document are
+// reported once, as a warning.
+func (s *Spec) ValidateMocks(responses map[string]mock.Response) []MockViolation {
+	endpoints := make([]string, 0, len(responses))
+	for endpoint := range responses {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	var violations []MockViolation
+	for _, endpoint := range endpoints {
+		m := responses[endpoint]
+		if !s.Documents(m.Method, endpoint) {
+			violations = append(violations, MockViolation{
+				Endpoint:  endpoint,
+				Method:    m.Method,
+				Violation: Violation{In: "response", Message: fmt.Sprintf("operation %s %s is not documented", m.Method, endpoint)},
+				Warning:   true,
+			})
+			continue
+		}
+		for _, resp := range m.Responses {
+			// Schema-driven responses are checked using a generated sample
+			body, err := resp.GenerateBody(rand.New(rand.NewSource(1)))
+			if err != nil {
+				violations = append(violations, MockViolation{
+					Endpoint:    endpoint,
+					Method:      m.Method,
+					Status:      resp.Status,
+					Description: resp.Description,
+					Violation:   Violation{In: "response", Message: err.Error()},
+				})
+				continue
+			}
+			// Negotiated responses are checked in each of their forms
+			var found []Violation
+			if len(resp.Representations) == 0 {
+				found = s.ValidateResponse(m.Method, endpoint, resp.Status, mockContentType(resp), body)
+			}
+			for _, rep := range resp.Representations {
+				found = append(found, s.ValidateResponse(m.Method, endpoint, resp.Status, rep.ContentType, rep.Body)...)
+			}
+			for _, v := range found {
+				violations = append(violations, MockViolation{
+					Endpoint:    endpoint,
+					Method:      m.Method,
+					Status:      resp.Status,
+					Description: resp.Description,
+					Violation:   v,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// mergeParameters combines path-level and operation-level parameters, with
+// operation-level definitions overriding ones with the same name and location
+func mergeParameters(pathParams, opParams []*Parameter) []*Parameter {
+	merged := make([]*Parameter, 0, len(pathParams)+len(opParams))
+	for _, p := range pathParams {
+		overridden := false
+		for _, o := range opParams {
+			if o.Name == p.Name && o.In == p.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, p)
+		}
+	}
+	return append(merged, opParams...)
+}
+
+// coerceParameter converts raw string parameter values to the JSON type the
+// schema expects so they can be validated like body values
+func coerceParameter(sch *schema.Schema, values []string) interface{} {
+	resolved := sch.Resolve()
+	if resolved.Type.Has("array") {
+		var raw []string
+		for _, value := range values {
+			raw = append(raw, strings.Split(value, ",")...)
+		}
+		items := make([]interface{}, len(raw))
+		for i, value := range raw {
+			if resolved.Items != nil {
+				items[i] = coerceScalar(resolved.Items.Resolve(), value)
+			} else {
+				items[i] = value
+			}
+		}
+		return items
+	}
+	return coerceScalar(resolved, values[0])
+}
+
+func coerceScalar(sch *schema.Schema, value string) interface{} {
+	switch {
+	case sch.Type.Has("integer"), sch.Type.Has("number"):
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case sch.Type.Has("boolean"):
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func findResponse(responses map[string]*ResponseSpec, status int) *ResponseSpec {
+	code := strconv.Itoa(status)
+	if resp, ok := responses[code]; ok {
+		return resp
+	}
+	if resp, ok := responses[code[:1]+"XX"]; ok {
+		return resp
+	}
+	if resp, ok := responses[code[:1]+"xx"]; ok {
+		return resp
+	}
+	return responses["default"]
+}
+
+// contentMediaType picks the representation matching the Content-Type of a
+// request or response, falling back to the JSON one for JSON or untyped bodies
+func contentMediaType(content map[string]*MediaType, contentType string) *MediaType {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" {
+		return jsonMediaType(content)
+	}
+	if media, ok := content[mediaType]; ok {
+		return media
+	}
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		if media, ok := content[major+"/*"]; ok {
+			return media
+		}
+	}
+	if isJSONContentType(contentType) {
+		return jsonMediaType(content)
+	}
+	return content["*/*"]
+}
+
+// isJSONContentType reports whether a Content-Type is JSON. Empty ones are,
+// since mock bodies are served as JSON by default.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// mockContentType returns the Content-Type a mock response is served with,
+// empty for JSON
+func mockContentType(resp mock.ResponseConfig) string {
+	for name, value := range resp.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			return value
+		}
+	}
+	if resp.XML != nil {
+		return "application/xml"
+	}
+	return ""
+}
+
+// jsonMediaType picks the JSON representation from a content map
+func jsonMediaType(content map[string]*MediaType) *MediaType {
+	if media, ok := content["application/json"]; ok {
+		return media
+	}
+	for contentType, media := range content {
+		if strings.HasSuffix(contentType, "+json") {
+			return media
+		}
+	}
+	return content["*/*"]
+}
@@ -0,0 +1,195 @@
+// Package ratelimit counts requests against token bucket and fixed window
+// limits, to simulate the rate limits of real APIs.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxKeys bounds the number of keys tracked. Idle keys are dropped first,
+// then the least recently used ones, since keys such as client IPs may be
+// chosen by clients.
+const maxKeys = 10000
+
+// Result describes the limit after a request
+type Result struct {
+	Allowed bool
+
+	// Limit is the number of requests allowed per window and Remaining the
+	// number still allowed now
+	Limit     int
+	Remaining int
+
+	// Reset is the time until the limit is fully restored
+	Reset time.Duration
+
+	// RetryAfter is the time until the next request is allowed, zero when
+	// this one was
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether requests sharing a key are allowed
+type Limiter interface {
+	Allow(key string) Result
+}
+
+// TokenBucket allows bursts of up to limit requests and refills limit tokens
+// evenly over each window
+type TokenBucket struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a token bucket limiter. Keys start with a full
+// bucket.
+func NewTokenBucket(limit int, window time.Duration) *TokenBucket {
+	return &TokenBucket{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of key
+func (l *TokenBucket) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxKeys {
+			l.prune(now)
+		}
+		b = &bucket{tokens: float64(l.limit), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	result := Result{Limit: l.limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = l.duration(float64(l.limit) - b.tokens)
+	return result
+}
+
+// refill returns the tokens of b at now
+func (l *TokenBucket) refill(b *bucket, now time.Time) float64 {
+	rate := float64(l.limit) / float64(l.window)
+	return math.Min(float64(l.limit), b.tokens+float64(now.Sub(b.last))*rate)
+}
+
+// duration returns the time taken to refill tokens
+func (l *TokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(l.window) / float64(l.limit)))
+}
+
+// prune drops the buckets that refilled completely, as they behave like new
+// ones, or the least recently used bucket when every bucket is in use
+func (l *TokenBucket) prune(now time.Time) {
+	var oldest string
+	var oldestUse time.Time
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.limit) {
+			delete(l.buckets, key)
+		} else if oldestUse.IsZero() || b.last.Before(oldestUse) {
+			oldest, oldestUse = key, b.last
+		}
+	}
+	if len(l.buckets) >= maxKeys {
+		delete(l.buckets, oldest)
+	}
+}
+
+// FixedWindow allows limit requests per window. Windows are aligned to the
+// clock, so a key's count resets at the same time for every key.
+type FixedWindow struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+type window struct {
+	start time.Time
+	count int
+	last  time.Time
+}
+
+// NewFixedWindow creates a fixed window limiter
+func NewFixedWindow(limit int, length time.Duration) *FixedWindow {
+	return &FixedWindow{
+		limit:   limit,
+		window:  length,
+		now:     time.Now,
+		windows: make(map[string]*window),
+	}
+}
+
+// Allow counts a request of key in the current window
+func (l *FixedWindow) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	start := now.Truncate(l.window)
+	w, ok := l.windows[key]
+	if !ok {
+		if len(l.windows) >= maxKeys {
+			l.prune(start)
+		}
+		w = &window{}
+		l.windows[key] = w
+	}
+	if !w.start.Equal(start) {
+		w.start, w.count = start, 0
+	}
+	w.last = now
+
+	result := Result{Limit: l.limit, Reset: start.Add(l.window).Sub(now)}
+	if w.count < l.limit {
+		w.count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = result.Reset
+	}
+	result.Remaining = l.limit - w.count
+	return result
+}
+
+// prune drops the windows that ended, or the least recently used window
+// when every window is current
+func (l *FixedWindow) prune(start time.Time) {
+	var oldest string
+	var oldestUse time.Time
+	for key, w := range l.windows {
+		if w.start.Before(start) {
+			delete(l.windows, key)
+		} else if oldestUse.IsZero() || w.last.Before(oldestUse) {
+			oldest, oldestUse = key, w.last
+		}
+	}
+	if len(l.windows) >= maxKeys {
+		delete(l.windows, oldest)
+	}
+}
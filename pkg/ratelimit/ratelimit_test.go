@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source advanced by the tests
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func TestTokenBucket(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	l := NewTokenBucket(2, time.Second)
+	l.now = c.now
+
+	for i := 0; i < 2; i++ {
+		if r := l.Allow("a"); !r.Allowed || r.Remaining != 1-i {
+			t.Fatalf("Expected request %d to be allowed, got %+v", i, r)
+		}
+	}
+	r := l.Allow("a")
+	if r.Allowed || r.RetryAfter != 500*time.Millisecond || r.Reset != time.Second {
+		t.Errorf("Expected to wait 500ms for a token, got %+v", r)
+	}
+
+	// Keys have their own buckets
+	if r := l.Allow("b"); !r.Allowed {
+		t.Errorf("Expected another key to be allowed, got %+v", r)
+	}
+
+	// Tokens refill over the window
+	c.t = c.t.Add(500 * time.Millisecond)
+	if r := l.Allow("a"); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("Expected a refilled token, got %+v", r)
+	}
+}
+
+func TestFixedWindow(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0).Add(250 * time.Millisecond)}
+	l := NewFixedWindow(2, time.Second)
+	l.now = c.now
+
+	l.Allow("a")
+	if r := l.Allow("a"); !r.Allowed || r.Remaining != 0 || r.Reset != 750*time.Millisecond {
+		t.Fatalf("Expected the second request to be allowed, got %+v", r)
+	}
+	if r := l.Allow("a"); r.Allowed || r.RetryAfter != 750*time.Millisecond {
+		t.Errorf("Expected to wait for the next window, got %+v", r)
+	}
+
+	// The count resets when the window ends
+	c.t = c.t.Add(750 * time.Millisecond)
+	if r := l.Allow("a"); !r.Allowed || r.Remaining != 1 {
+		t.Errorf("Expected a new window, got %+v", r)
+	}
+}
+
+func TestMaxKeys(t *testing.T) {
+	c := &clock{t: time.Unix(1000, 0)}
+	buckets := NewTokenBucket(1, time.Minute)
+	buckets.now = c.now
+	windows := NewFixedWindow(1, time.Minute)
+	windows.now = c.now
+
+	// Keys stay in use, so the least recently used ones are evicted
+	for i := 0; i <= maxKeys; i++ {
+		c.t = c.t.Add(time.Millisecond)
+		key := strconv.Itoa(i)
+		buckets.Allow(key)
+		windows.Allow(key)
+	}
+	if len(buckets.buckets) != maxKeys || len(windows.windows) != maxKeys {
+		t.Errorf("Expected %d keys, got %d and %d", maxKeys, len(buckets.buckets), len(windows.windows))
+	}
+	if _, ok := buckets.buckets["0"]; ok {
+		t.Error("Expected the least recently used bucket to be evicted")
+	}
+	if _, ok := windows.windows["0"]; ok {
+		t.Error("Expected the least recently used window to be evicted")
+	}
+}
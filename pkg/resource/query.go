@@ -0,0 +1,255 @@
+package resource
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query describes filtering, sorting and pagination of a collection listing.
+// It follows the json-server conventions:
+//
+//	?role=admin&age_gte=18     filter by equality and range operators
+//	?_sort=name&_order=desc    sort by one or more comma separated fields
+//	?_page=2&_limit=10         page through the results
+//	?_start=20&_end=30         slice the results
+type Query struct {
+	Filters []Filter
+	Sort    []string
+	Order   []string
+	Page    int
+	Limit   int
+	Start   int
+	End     int
+}
+
+// Filter matches a field against a value using an operator
+type Filter struct {
+	Field    string
+	Operator string
+	Values   []string
+}
+
+var filterOperators = []string{"_gte", "_lte", "_ne", "_like"}
+
+// ParseQuery builds a query from URL query parameters
+func ParseQuery(values url.Values) (Query, error) {
+	var q Query
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values.Get(key)
+		var err error
+		switch key {
+		case "_sort":
+			q.Sort = strings.Split(value, ",")
+		case "_order":
+			q.Order = strings.Split(value, ",")
+		case "_page":
+			q.Page, err = positiveInt(key, value)
+		case "_limit":
+			q.Limit, err = positiveInt(key, value)
+		case "_start":
+			q.Start, err = nonNegativeInt(key, value)
+		case "_end":
+			q.End, err = nonNegativeInt(key, value)
+		default:
+			if strings.HasPrefix(key, "_") {
+				continue
+			}
+			q.Filters = append(q.Filters, parseFilter(key, values[key]))
+		}
+		if err != nil {
+			return Query{}, err
+		}
+	}
+
+	for _, order := range q.Order {
+		if order != "asc" && order != "desc" {
+			return Query{}, fmt.Errorf("_order must be asc or desc, got %q", order)
+		}
+	}
+	return q, nil
+}
+
+func parseFilter(key string, values []string) Filter {
+	for _, op := range filterOperators {
+		if strings.HasSuffix(key, op) {
+			return Filter{Field: strings.TrimSuffix(key, op), Operator: op[1:], Values: values}
+		}
+	}
+	return Filter{Field: key, Operator: "eq", Values: values}
+}
+
+func (q Query) matches(item map[string]interface{}) bool {
+	for _, f := range q.Filters {
+		if !f.matches(lookup(item, f.Field)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) matches(value interface{}) bool {
+	text := FormatID(value)
+	switch f.Operator {
+	case "eq":
+		// Repeated parameters match any of the values
+		for _, want := range f.Values {
+			if text == want {
+				return true
+			}
+		}
+		return false
+	case "ne":
+		for _, want := range f.Values {
+			if text == want {
+				return false
+			}
+		}
+		return true
+	case "like":
+		return strings.Contains(strings.ToLower(text), strings.ToLower(f.Values[0]))
+	case "gte":
+		return compareValues(value, f.Values[0]) >= 0
+	case "lte":
+		return compareValues(value, f.Values[0]) <= 0
+	}
+	return false
+}
+
+func (q Query) sort(items []map[string]interface{}) {
+	if len(q.Sort) == 0 {
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		for k, field := range q.Sort {
+			c := compareFields(lookup(items[i], field), lookup(items[j], field))
+			if c == 0 {
+				continue
+			}
+			if k < len(q.Order) && q.Order[k] == "desc" {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// paginate slices the page out of items. Offsets are clamped to the items
+// without multiplying or adding past their length, so huge page numbers
+// return an empty page rather than overflow.
+func (q Query) paginate(items []map[string]interface{}) []map[string]interface{} {
+	n := len(items)
+	start, end := 0, n
+	switch {
+	case q.Page > 0:
+		limit := q.Limit
+		if limit == 0 {
+			limit = 10
+		}
+		start = n
+		if q.Page-1 <= n/limit {
+			start = min((q.Page-1)*limit, n)
+		}
+		end = clampedAdd(start, limit, n)
+	case q.Limit > 0 || q.Start > 0 || q.End > 0:
+		start = min(q.Start, n)
+		if q.End > 0 {
+			end = min(q.End, n)
+		}
+		if q.Limit > 0 {
+			end = clampedAdd(start, q.Limit, n)
+		}
+	}
+
+	if end < start {
+		end = start
+	}
+	return items[start:end]
+}
+
+// clampedAdd returns a+b capped at ceiling, for non-negative a <= ceiling
+// and b, without overflowing
+func clampedAdd(a, b, ceiling int) int {
+	if b > ceiling-a {
+		return ceiling
+	}
+	return a + b
+}
+
+// lookup reads a possibly nested field using dot notation, e.g. address.city
+func lookup(item map[string]interface{}, field string) interface{} {
+	var current interface{} = item
+	for _, part := range strings.Split(field, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[part]
+	}
+	return current
+}
+
+// compareValues compares a field value with a query string, numerically when
+// both are numbers
+func compareValues(value interface{}, text string) int {
+	if n, ok := value.(float64); ok {
+		if want, err := strconv.ParseFloat(text, 64); err == nil {
+			return compareFloat(n, want)
+		}
+	}
+	return strings.Compare(FormatID(value), text)
+}
+
+func compareFields(a, b interface{}) int {
+	an, aNum := a.(float64)
+	bn, bNum := b.(float64)
+	if aNum && bNum {
+		return compareFloat(an, bn)
+	}
+	// Missing values sort first
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(FormatID(a), FormatID(b))
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func positiveInt(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+func nonNegativeInt(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", key, value)
+	}
+	return n, nil
+}
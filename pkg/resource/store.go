@@ -0,0 +1,239 @@
+package resource
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// ErrConflict is returned when creating an item whose id already exists
+var ErrConflict = errors.New("item already exists")
+
+// Store is an in-memory collection of JSON objects identified by an id field
+type Store struct {
+	mu      sync.RWMutex
+	idField string
+	seed    []map[string]interface{}
+	items   []map[string]interface{}
+	nextID  int64
+}
+
+// NewStore creates a store holding a copy of the seed items
+func NewStore(idField string, seed []map[string]interface{}) *Store {
+	if idField == "" {
+		idField = "id"
+	}
+	s := &Store{
+		idField: idField,
+		seed:    copyItems(seed),
+	}
+	s.Reset()
+	return s
+}
+
+// IDField returns the name of the field holding item ids
+func (s *Store) IDField() string {
+	return s.idField
+}
+
+// Reset restores the store to its seed data
+func (s *Store) Reset() {
+	s.Restore(s.seed)
+}
+
+// Items returns a copy of every item in the store, in order
+func (s *Store) Items() []map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyItems(s.items)
+}
+
+// Restore replaces the items of the store, such as with items saved by a
+// previous run. The seed data is kept for later resets.
+func (s *Store) Restore(items []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = copyItems(items)
+	s.nextID = 1
+	for _, item := range s.items {
+		if n, ok := item[s.idField].(float64); ok && n == math.Trunc(n) && int64(n) >= s.nextID {
+			s.nextID = int64(n) + 1
+		}
+	}
+}
+
+// Len returns the number of items in the store
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items)
+}
+
+// List returns the items matching the query and the total number of matches
+// before pagination
+func (s *Store) List(q Query) ([]map[string]interface{}, int) {
+	s.mu.RLock()
+	matched := make([]map[string]interface{}, 0, len(s.items))
+	for _, item := range s.items {
+		if q.matches(item) {
+			matched = append(matched, copyItem(item))
+		}
+	}
+	s.mu.RUnlock()
+
+	q.sort(matched)
+	return q.paginate(matched), len(matched)
+}
+
+// Get returns the item with the given id
+func (s *Store) Get(id string) (map[string]interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.indexOf(id); i >= 0 {
+		return copyItem(s.items[i]), true
+	}
+	return nil, false
+}
+
+// Create adds an item, assigning an id when the item has none. Numeric ids
+// continue from the highest seeded id, otherwise a random hex id is used.
+func (s *Store) Create(item map[string]interface{}) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item = copyItem(item)
+	if id, ok := item[s.idField]; ok && id != nil {
+		if s.indexOf(FormatID(id)) >= 0 {
+			return nil, ErrConflict
+		}
+		if n, ok := id.(float64); ok && int64(n) >= s.nextID {
+			s.nextID = int64(n) + 1
+		}
+	} else {
+		item[s.idField] = s.newID()
+	}
+
+	s.items = append(s.items, item)
+	return copyItem(item), nil
+}
+
+// Replace overwrites the item with the given id, keeping its id
+func (s *Store) Replace(id string, item map[string]interface{}) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return nil, false
+	}
+	item = copyItem(item)
+	item[s.idField] = s.items[i][s.idField]
+	s.items[i] = item
+	return copyItem(item), true
+}
+
+// Patch merges the given fields into the item with the given id
+func (s *Store) Patch(id string, fields map[string]interface{}) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return nil, false
+	}
+	for key, value := range copyItem(fields) {
+		if key != s.idField {
+			s.items[i][key] = value
+		}
+	}
+	return copyItem(s.items[i]), true
+}
+
+// Delete removes the item with the given id
+func (s *Store) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i < 0 {
+		return false
+	}
+	s.items = append(s.items[:i], s.items[i+1:]...)
+	return true
+}
+
+func (s *Store) indexOf(id string) int {
+	for i, item := range s.items {
+		if FormatID(item[s.idField]) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// newID returns a numeric id when the store uses numeric ids, otherwise a
+// random string id
+func (s *Store) newID() interface{} {
+	numeric := true
+	for _, item := range s.items {
+		if _, ok := item[s.idField].(float64); !ok {
+			numeric = false
+			break
+		}
+	}
+	if numeric {
+		id := s.nextID
+		s.nextID++
+		return float64(id)
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		id := s.nextID
+		s.nextID++
+		return strconv.FormatInt(id, 10)
+	}
+	return hex.EncodeToString(b)
+}
+
+// FormatID converts an id value to the string form used in URLs
+func FormatID(id interface{}) string {
+	switch v := id.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", id)
+}
+
+func copyItems(items []map[string]interface{}) []map[string]interface{} {
+	copied := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		copied[i] = copyItem(item)
+	}
+	return copied
+}
+
+// copyItem deep-copies an item through JSON so callers never share state
+// with the store
+func copyItem(item map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return item
+	}
+	var copied map[string]interface{}
+	if err := json.Unmarshal(data, &copied); err != nil || copied == nil {
+		return map[string]interface{}{}
+	}
+	return copied
+}
@@ -0,0 +1,158 @@
+package resource
+
+import (
+	"net/url"
+	"testing"
+)
+
+func seedUsers() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"id": float64(1), "name": "Alice", "role": "admin", "age": float64(34), "address": map[string]interface{}{"city": "Berlin"}},
+		{"id": float64(2), "name": "Bob", "role": "member", "age": float64(27), "address": map[string]interface{}{"city": "Paris"}},
+		{"id": float64(3), "name": "Carol", "role": "member", "age": float64(41), "address": map[string]interface{}{"city": "Berlin"}},
+	}
+}
+
+func TestStoreCRUD(t *testing.T) {
+	store := NewStore("", seedUsers())
+
+	if item, ok := store.Get("2"); !ok || item["name"] != "Bob" {
+		t.Fatalf("Expected Bob, got %v", item)
+	}
+	if _, ok := store.Get("42"); ok {
+		t.Error("Expected missing item")
+	}
+
+	created, err := store.Create(map[string]interface{}{"name": "Dave"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created["id"] != float64(4) {
+		t.Errorf("Expected numeric id 4, got %v", created["id"])
+	}
+	if _, err := store.Create(map[string]interface{}{"id": float64(1)}); err != ErrConflict {
+		t.Errorf("Expected conflict, got %v", err)
+	}
+
+	replaced, ok := store.Replace("4", map[string]interface{}{"id": float64(99), "name": "David"})
+	if !ok || replaced["id"] != float64(4) || replaced["name"] != "David" {
+		t.Errorf("Expected replace to keep the id, got %v", replaced)
+	}
+
+	patched, ok := store.Patch("1", map[string]interface{}{"role": "owner"})
+	if !ok || patched["role"] != "owner" || patched["name"] != "Alice" {
+		t.Errorf("Expected patch to merge fields, got %v", patched)
+	}
+
+	// Returned items are copies
+	patched["name"] = "Mallory"
+	if item, _ := store.Get("1"); item["name"] != "Alice" {
+		t.Error("Expected store to be unaffected by changes to returned items")
+	}
+
+	if !store.Delete("2") || store.Delete("2") {
+		t.Error("Expected delete to succeed once")
+	}
+	if store.Len() != 3 {
+		t.Errorf("Expected 3 items, got %d", store.Len())
+	}
+
+	store.Reset()
+	if store.Len() != 3 {
+		t.Errorf("Expected seed data after reset, got %d items", store.Len())
+	}
+	if item, _ := store.Get("1"); item["role"] != "admin" {
+		t.Errorf("Expected seed values after reset, got %v", item)
+	}
+}
+
+func TestStoreStringIDs(t *testing.T) {
+	store := NewStore("slug", []map[string]interface{}{{"slug": "intro", "title": "Intro"}})
+
+	created, err := store.Create(map[string]interface{}{"title": "Next"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	slug, ok := created["slug"].(string)
+	if !ok || slug == "" {
+		t.Fatalf("Expected generated string id, got %v", created["slug"])
+	}
+	if _, ok := store.Get(slug); !ok {
+		t.Error("Expected created item to be retrievable")
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	store := NewStore("id", seedUsers())
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+		total    int
+	}{
+		{name: "All items", query: "", expected: []string{"Alice", "Bob", "Carol"}, total: 3},
+		{name: "Equality filter", query: "role=member", expected: []string{"Bob", "Carol"}, total: 2},
+		{name: "Nested filter", query: "address.city=Berlin", expected: []string{"Alice", "Carol"}, total: 2},
+		{name: "Range filter", query: "age_gte=30&age_lte=40", expected: []string{"Alice"}, total: 1},
+		{name: "Like and not-equal filters", query: "name_like=o&role_ne=admin", expected: []string{"Bob", "Carol"}, total: 2},
+		{name: "Sort descending", query: "_sort=age&_order=desc", expected: []string{"Carol", "Alice", "Bob"}, total: 3},
+		{name: "Page", query: "_sort=name&_page=2&_limit=2", expected: []string{"Carol"}, total: 3},
+		{name: "Slice", query: "_start=1&_end=2", expected: []string{"Bob"}, total: 3},
+		{name: "Page past the end", query: "_page=5&_limit=2", expected: []string{}, total: 3},
+		{name: "Huge page", query: "_page=9223372036854775807&_limit=10", expected: []string{}, total: 3},
+		{name: "Huge limit", query: "_page=2&_limit=9223372036854775807", expected: []string{}, total: 3},
+		{name: "Huge start and limit", query: "_start=9223372036854775800&_limit=100", expected: []string{}, total: 3},
+		{name: "Start with huge limit", query: "_sort=name&_start=1&_limit=9223372036854775807", expected: []string{"Bob", "Carol"}, total: 3},
+		{name: "Huge end", query: "_sort=name&_start=2&_end=9223372036854775807", expected: []string{"Carol"}, total: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tc.query)
+			query, err := ParseQuery(values)
+			if err != nil {
+				t.Fatalf("ParseQuery failed: %v", err)
+			}
+
+			items, total := store.List(query)
+			if total != tc.total {
+				t.Errorf("Expected total %d, got %d", tc.total, total)
+			}
+			if len(items) != len(tc.expected) {
+				t.Fatalf("Expected %d items, got %d", len(tc.expected), len(items))
+			}
+			for i, name := range tc.expected {
+				if items[i]["name"] != name {
+					t.Errorf("Item %d: expected %s, got %v", i, name, items[i]["name"])
+				}
+			}
+		})
+	}
+
+	for _, query := range []string{"_page=0", "_limit=abc", "_order=sideways", "_start=-1"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := ParseQuery(values); err == nil {
+			t.Errorf("Expected error for query %q", query)
+		}
+	}
+}
+
+func TestStoreRestore(t *testing.T) {
+	store := NewStore("", seedUsers())
+	store.Restore([]map[string]interface{}{{"id": float64(8), "name": "Erin"}})
+
+	items := store.Items()
+	if len(items) != 1 || items[0]["name"] != "Erin" {
+		t.Fatalf("Expected restored items, got %v", items)
+	}
+	created, _ := store.Create(map[string]interface{}{"name": "Frank"})
+	if created["id"] != float64(9) {
+		t.Errorf("Expected id 9 after restored ids, got %v", created["id"])
+	}
+
+	store.Reset()
+	if store.Len() != 3 {
+		t.Errorf("Expected reset to restore the seed data, got %d items", store.Len())
+	}
+}
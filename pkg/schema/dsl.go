@@ -0,0 +1,178 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dslFormats maps compact type names to the string format they generate
+var dslFormats = map[string]string{
+	"uuid":       "uuid",
+	"email":      "email",
+	"name":       "name",
+	"first_name": "first-name",
+	"last_name":  "last-name",
+	"username":   "username",
+	"phone":      "phone",
+	"city":       "city",
+	"country":    "country",
+	"company":    "company",
+	"address":    "address",
+	"url":        "uri",
+	"hostname":   "hostname",
+	"ipv4":       "ipv4",
+	"ipv6":       "ipv6",
+	"date":       "date",
+	"datetime":   "date-time",
+	"time":       "time",
+	"word":       "word",
+	"sentence":   "sentence",
+}
+
+// FromDSL converts a compact type description into a schema. The description
+// mirrors the shape of the body:
+//
+//   - objects describe objects, with every property required
+//   - arrays are [item] or [item, min, max] for 1-5 or min-max items
+//   - strings name a type: uuid, email, name, first_name, last_name, username,
+//     phone, city, country, company, address, url, hostname, ipv4, ipv6, date,
+//     datetime, time, word, sentence, string, string(min,max), int,
+//     int(min,max), float, float(min,max), bool or enum(a,b,c)
+//   - numbers, booleans and null are returned as-is
+func FromDSL(value interface{}) (*Schema, error) {
+	return fromDSL(normalize(value), "")
+}
+
+func fromDSL(value interface{}, path string) (*Schema, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema, len(v))}
+		for key, child := range v {
+			prop, err := fromDSL(child, path+"/"+key)
+			if err != nil {
+				return nil, err
+			}
+			s.Properties[key] = prop
+			s.Required = append(s.Required, key)
+		}
+		sort.Strings(s.Required)
+		return s, nil
+
+	case []interface{}:
+		if len(v) != 1 && len(v) != 3 {
+			return nil, fmt.Errorf("%s: array must be [item] or [item, min, max]", dslPath(path))
+		}
+		items, err := fromDSL(v[0], path+"/0")
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{Type: Types{"array"}, Items: items}
+		if len(v) == 3 {
+			min, minOK := v[1].(float64)
+			max, maxOK := v[2].(float64)
+			if !minOK || !maxOK || min < 0 || max < min {
+				return nil, fmt.Errorf("%s: array bounds must be numbers with 0 <= min <= max", dslPath(path))
+			}
+			minItems, maxItems := int(min), int(max)
+			s.MinItems, s.MaxItems = &minItems, &maxItems
+		}
+		return s, nil
+
+	case string:
+		return parseDSLType(v, path)
+	}
+	return &Schema{Const: value}, nil
+}
+
+// parseDSLType parses a type name with optional arguments, e.g. int(1,10)
+func parseDSLType(text, path string) (*Schema, error) {
+	name, args := strings.TrimSpace(text), []string(nil)
+	if open := strings.Index(name, "("); open >= 0 {
+		if !strings.HasSuffix(name, ")") {
+			return nil, fmt.Errorf("%s: missing closing parenthesis in %q", dslPath(path), text)
+		}
+		for _, arg := range strings.Split(name[open+1:len(name)-1], ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+		name = strings.TrimSpace(name[:open])
+	}
+
+	if format, ok := dslFormats[name]; ok && args == nil {
+		return &Schema{Type: Types{"string"}, Format: format}, nil
+	}
+
+	switch name {
+	case "string":
+		s := &Schema{Type: Types{"string"}}
+		if args != nil {
+			min, max, err := intRange(args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", dslPath(path), err)
+			}
+			minLen, maxLen := int(min), int(max)
+			s.MinLength, s.MaxLength = &minLen, &maxLen
+		}
+		return s, nil
+	case "int", "integer", "float", "number":
+		typ := "integer"
+		if name == "float" || name == "number" {
+			typ = "number"
+		}
+		s := &Schema{Type: Types{typ}}
+		if args != nil {
+			min, max, err := numberRange(args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", dslPath(path), err)
+			}
+			s.Minimum, s.Maximum = &min, &max
+		}
+		return s, nil
+	case "bool", "boolean":
+		return &Schema{Type: Types{"boolean"}}, nil
+	case "enum":
+		if len(args) == 0 || args[0] == "" {
+			return nil, fmt.Errorf("%s: enum needs at least one value", dslPath(path))
+		}
+		s := &Schema{Type: Types{"string"}}
+		for _, arg := range args {
+			s.Enum = append(s.Enum, arg)
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("%s: unknown fake type %q", dslPath(path), text)
+}
+
+func numberRange(args []string) (float64, float64, error) {
+	if len(args) != 2 {
+		return 0, 0, fmt.Errorf("expected (min,max), got %d arguments", len(args))
+	}
+	min, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid minimum %q", args[0])
+	}
+	max, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid maximum %q", args[1])
+	}
+	if max < min {
+		return 0, 0, fmt.Errorf("maximum %v is less than minimum %v", max, min)
+	}
+	return min, max, nil
+}
+
+func intRange(args []string) (float64, float64, error) {
+	min, max, err := numberRange(args)
+	if err == nil && min < 0 {
+		err = fmt.Errorf("minimum length must not be negative")
+	}
+	return min, max, err
+}
+
+func dslPath(path string) string {
+	if path == "" {
+		return "fake"
+	}
+	return "fake" + path
+}
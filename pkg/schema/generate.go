@@ -0,0 +1,380 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	firstNames = []string{"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda", "David", "Elizabeth", "William", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Aisha", "Wei", "Priya", "Mateo", "Yuki", "Olga"}
+	lastNames  = []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Taylor", "Thomas", "Moore", "Jackson", "Martin", "Lee", "Khan", "Chen", "Patel", "Kowalski"}
+	cities     = []string{"New York", "London", "Tokyo", "Berlin", "Paris", "Sydney", "Toronto", "Mumbai", "Sao Paulo", "Cape Town", "Seoul", "Madrid", "Amsterdam", "Chicago", "Singapore"}
+	countries  = []string{"United States", "United Kingdom", "Japan", "Germany", "France", "Australia", "Canada", "India", "Brazil", "South Africa", "South Korea", "Spain", "Netherlands", "Singapore"}
+	companies  = []string{"Acme Corp", "Globex", "Initech", "Umbrella", "Stark Industries", "Wayne Enterprises", "Hooli", "Vandelay Industries", "Soylent", "Cyberdyne"}
+	domains    = []string{"example.com", "example.org", "example.net", "mail.test", "corp.test"}
+	streets    = []string{"Main St", "Oak Ave", "Maple Dr", "Cedar Ln", "Park Rd", "Elm St", "Pine Ct", "Lakeview Blvd"}
+	words      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim", "minim", "veniam", "quis", "nostrud"}
+)
+
+// generatorEpoch anchors generated dates so seeded output is reproducible
+var generatorEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Generate produces a random value that satisfies the schema. The same rng
+// seed always yields the same value.
+func (s *Schema) Generate(rng *rand.Rand) interface{} {
+	return s.generate(rng, "", 0)
+}
+
+// maxDepth stops recursive schemas from generating unbounded values
+const maxDepth = 8
+
+func (s *Schema) generate(rng *rand.Rand, name string, depth int) interface{} {
+	s = s.Resolve()
+	if s == nil {
+		return nil
+	}
+
+	if s.Const != nil {
+		return s.Const
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[rng.Intn(len(s.Enum))]
+	}
+	if len(s.AllOf) > 0 {
+		return s.generateAllOf(rng, name, depth)
+	}
+	if len(s.OneOf) > 0 {
+		return s.OneOf[rng.Intn(len(s.OneOf))].generate(rng, name, depth)
+	}
+	if len(s.AnyOf) > 0 {
+		return s.AnyOf[rng.Intn(len(s.AnyOf))].generate(rng, name, depth)
+	}
+
+	switch s.generatedType() {
+	case "object":
+		if depth >= maxDepth {
+			return map[string]interface{}{}
+		}
+		return s.generateObject(rng, depth)
+	case "array":
+		if depth >= maxDepth {
+			return []interface{}{}
+		}
+		return s.generateArray(rng, name, depth)
+	case "integer":
+		return s.generateInteger(rng)
+	case "number":
+		return s.generateNumber(rng)
+	case "boolean":
+		return rng.Intn(2) == 1
+	case "null":
+		return nil
+	}
+	return s.generateString(rng, name)
+}
+
+// generatedType picks the type to generate, inferring it when not declared
+func (s *Schema) generatedType() string {
+	for _, typ := range s.Type {
+		if typ != "null" {
+			return typ
+		}
+	}
+	if len(s.Type) > 0 {
+		return "null"
+	}
+	switch {
+	case len(s.Properties) > 0:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	case s.Minimum != nil || s.Maximum != nil:
+		return "number"
+	}
+	return "string"
+}
+
+func (s *Schema) generateAllOf(rng *rand.Rand, name string, depth int) interface{} {
+	merged := map[string]interface{}{}
+	var last interface{}
+	for _, sub := range s.AllOf {
+		last = sub.generate(rng, name, depth)
+		if obj, ok := last.(map[string]interface{}); ok {
+			for key, value := range obj {
+				merged[key] = value
+			}
+		}
+	}
+	if len(s.Properties) > 0 {
+		for key, value := range s.generateObject(rng, depth) {
+			merged[key] = value
+		}
+	}
+	if len(merged) == 0 {
+		return last
+	}
+	return merged
+}
+
+func (s *Schema) generateObject(rng *rand.Rand, depth int) map[string]interface{} {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	obj := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		obj[name] = s.Properties[name].generate(rng, name, depth+1)
+	}
+	return obj
+}
+
+func (s *Schema) generateArray(rng *rand.Rand, name string, depth int) []interface{} {
+	min, max := 1, 5
+	if s.MinItems != nil {
+		min = *s.MinItems
+		if max < min {
+			max = min + 4
+		}
+	}
+	if s.MaxItems != nil {
+		max = *s.MaxItems
+		if min > max {
+			min = max
+		}
+	}
+
+	count := min
+	if max > min {
+		count += rng.Intn(max - min + 1)
+	}
+
+	items := make([]interface{}, count)
+	for i := range items {
+		if s.Items == nil {
+			items[i] = randomWord(rng)
+			continue
+		}
+		items[i] = s.Items.generate(rng, singular(name), depth+1)
+	}
+	return items
+}
+
+func (s *Schema) generateInteger(rng *rand.Rand) float64 {
+	min, max := s.numberBounds(1, 1000)
+	low, high := int64(math.Ceil(min)), int64(math.Floor(max))
+	if high < low {
+		return float64(low)
+	}
+	value := low + rng.Int63n(high-low+1)
+	if s.MultipleOf != nil && *s.MultipleOf >= 1 {
+		step := int64(*s.MultipleOf)
+		value = value / step * step
+		if value < low {
+			value += step
+		}
+	}
+	return float64(value)
+}
+
+func (s *Schema) generateNumber(rng *rand.Rand) float64 {
+	min, max := s.numberBounds(0, 1000)
+	value := min + rng.Float64()*(max-min)
+	return math.Round(value*100) / 100
+}
+
+// numberBounds returns the inclusive range to generate numbers in
+func (s *Schema) numberBounds(defaultMin, defaultMax float64) (float64, float64) {
+	min, max := defaultMin, defaultMax
+	if s.Minimum != nil {
+		min = *s.Minimum
+		if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.Flag {
+			min++
+		}
+	}
+	if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.Value != nil {
+		min = *s.ExclusiveMinimum.Value + 1
+	}
+	if s.Maximum != nil {
+		max = *s.Maximum
+		if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.Flag {
+			max--
+		}
+	}
+	if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.Value != nil {
+		max = *s.ExclusiveMaximum.Value - 1
+	}
+	if s.Minimum != nil && s.Maximum == nil && max < min {
+		max = min + defaultMax
+	}
+	if s.Maximum != nil && s.Minimum == nil && min > max {
+		min = max - defaultMax
+	}
+	return min, max
+}
+
+func (s *Schema) generateString(rng *rand.Rand, name string) string {
+	value := fakeString(rng, s.Format, name)
+	if s.MinLength != nil {
+		for len([]rune(value)) < *s.MinLength {
+			value += string(rune('a' + rng.Intn(26)))
+		}
+	}
+	if s.MaxLength != nil && len([]rune(value)) > *s.MaxLength {
+		value = string([]rune(value)[:*s.MaxLength])
+	}
+	return value
+}
+
+// fakeString generates a realistic string from the format or, when there is
+// none, from the property name
+func fakeString(rng *rand.Rand, format, name string) string {
+	if format == "" {
+		format = formatFromName(name)
+	}
+
+	switch format {
+	case "uuid":
+		return randomUUID(rng)
+	case "email":
+		first := strings.ToLower(pick(rng, firstNames))
+		last := strings.ToLower(pick(rng, lastNames))
+		return fmt.Sprintf("%s.%s@%s", first, last, pick(rng, domains))
+	case "name":
+		return pick(rng, firstNames) + " " + pick(rng, lastNames)
+	case "first-name":
+		return pick(rng, firstNames)
+	case "last-name":
+		return pick(rng, lastNames)
+	case "username":
+		return strings.ToLower(pick(rng, firstNames)) + fmt.Sprintf("%d", rng.Intn(1000))
+	case "date":
+		return randomTime(rng).Format("2006-01-02")
+	case "date-time":
+		return randomTime(rng).Format(time.RFC3339)
+	case "time":
+		return randomTime(rng).Format("15:04:05")
+	case "uri", "url":
+		return fmt.Sprintf("https://%s/%s", pick(rng, domains), randomWord(rng))
+	case "hostname":
+		return randomWord(rng) + "." + pick(rng, domains)
+	case "ipv4":
+		return fmt.Sprintf("%d.%d.%d.%d", 10+rng.Intn(200), rng.Intn(256), rng.Intn(256), 1+rng.Intn(254))
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x:%x", rng.Intn(0xffff), rng.Intn(0xffff))
+	case "phone":
+		return fmt.Sprintf("+1-%03d-%03d-%04d", 200+rng.Intn(800), rng.Intn(1000), rng.Intn(10000))
+	case "city":
+		return pick(rng, cities)
+	case "country":
+		return pick(rng, countries)
+	case "company":
+		return pick(rng, companies)
+	case "address":
+		return fmt.Sprintf("%d %s", 1+rng.Intn(9999), pick(rng, streets))
+	case "word":
+		return randomWord(rng)
+	case "sentence":
+		return randomSentence(rng)
+	}
+	return randomWord(rng)
+}
+
+// formatFromName guesses a fake format from common property names
+func formatFromName(name string) string {
+	key := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	switch key {
+	case "id", "uuid", "guid":
+		return "uuid"
+	case "email", "emailaddress", "mail":
+		return "email"
+	case "name", "fullname", "displayname":
+		return "name"
+	case "firstname", "givenname":
+		return "first-name"
+	case "lastname", "surname", "familyname":
+		return "last-name"
+	case "username", "login", "handle":
+		return "username"
+	case "phone", "phonenumber", "mobile":
+		return "phone"
+	case "city":
+		return "city"
+	case "country":
+		return "country"
+	case "company", "organization", "organisation":
+		return "company"
+	case "address", "street":
+		return "address"
+	case "url", "website", "homepage", "link", "avatar", "image":
+		return "uri"
+	case "host", "hostname", "domain":
+		return "hostname"
+	case "ip", "ipaddress":
+		return "ipv4"
+	case "description", "summary", "bio", "comment", "message", "title", "text":
+		return "sentence"
+	}
+	switch {
+	case strings.HasSuffix(key, "email"):
+		return "email"
+	case strings.HasSuffix(key, "date"):
+		return "date"
+	case strings.HasSuffix(name, "_at") || strings.HasSuffix(name, "At") || strings.HasSuffix(key, "time") || strings.HasSuffix(key, "timestamp"):
+		return "date-time"
+	case strings.HasSuffix(key, "url"):
+		return "uri"
+	case strings.HasSuffix(key, "name"):
+		return "name"
+	}
+	return ""
+}
+
+func randomUUID(rng *rand.Rand) string {
+	b := make([]byte, 16)
+	rng.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func randomTime(rng *rand.Rand) time.Time {
+	return generatorEpoch.Add(time.Duration(rng.Int63n(int64(3 * 365 * 24 * time.Hour)))).Truncate(time.Second)
+}
+
+func randomWord(rng *rand.Rand) string {
+	return pick(rng, words)
+}
+
+func randomSentence(rng *rand.Rand) string {
+	count := 4 + rng.Intn(8)
+	parts := make([]string, count)
+	for i := range parts {
+		parts[i] = randomWord(rng)
+	}
+	sentence := strings.Join(parts, " ")
+	runes := []rune(sentence)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes) + "."
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.Intn(len(values))]
+}
+
+// singular turns a collection property name into a hint for its items
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"email": {"type": "string", "format": "email"},
+			"name": {"type": "string"},
+			"age": {"type": "integer", "minimum": 18, "maximum": 90},
+			"score": {"type": "number", "minimum": 0, "maximum": 1},
+			"role": {"type": "string", "enum": ["admin", "member"]},
+			"active": {"type": "boolean"},
+			"created_at": {"type": "string", "format": "date-time"},
+			"birthday": {"type": "string", "format": "date"},
+			"code": {"type": "string", "minLength": 12, "maxLength": 12},
+			"tags": {"type": "array", "items": {"type": "string"}, "minItems": 2, "maxItems": 3}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		value := s.Generate(rand.New(rand.NewSource(seed)))
+		if errs := s.Validate(value); len(errs) != 0 {
+			t.Fatalf("Seed %d: generated value %v does not match its schema: %v", seed, value, errs)
+		}
+	}
+
+	// The same seed yields the same value
+	first := s.Generate(rand.New(rand.NewSource(42)))
+	second := s.Generate(rand.New(rand.NewSource(42)))
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected identical output for the same seed, got %v and %v", first, second)
+	}
+
+	// Property names give realistic strings when no format is declared
+	name := first.(map[string]interface{})["name"].(string)
+	if !strings.Contains(name, " ") {
+		t.Errorf("Expected a full name, got %q", name)
+	}
+}
+
+func TestFromDSL(t *testing.T) {
+	var dsl interface{}
+	err := json.Unmarshal([]byte(`{
+		"id": "uuid",
+		"email": "email",
+		"age": "int(18,30)",
+		"status": "enum(active,disabled)",
+		"signed_up": "datetime",
+		"version": 2,
+		"orders": [{"total": "float(1,100)", "sku": "string(8,8)"}, 2, 4]
+	}`), &dsl)
+	if err != nil {
+		t.Fatalf("Failed to parse DSL: %v", err)
+	}
+
+	s, err := FromDSL(dsl)
+	if err != nil {
+		t.Fatalf("FromDSL failed: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 20; i++ {
+		value := s.Generate(rng)
+		if errs := s.Validate(value); len(errs) != 0 {
+			t.Fatalf("Generated value %v does not match the DSL: %v", value, errs)
+		}
+		obj := value.(map[string]interface{})
+		if obj["version"] != float64(2) {
+			t.Errorf("Expected literal values to be kept, got %v", obj["version"])
+		}
+		if orders := obj["orders"].([]interface{}); len(orders) < 2 || len(orders) > 4 {
+			t.Errorf("Expected 2-4 orders, got %d", len(orders))
+		}
+	}
+
+	invalid := []interface{}{
+		"unknown",
+		"int(5,1)",
+		"enum()",
+		[]interface{}{"word", 3.0},
+		map[string]interface{}{"nested": "int(1"},
+	}
+	for _, value := range invalid {
+		if _, err := FromDSL(value); err == nil {
+			t.Errorf("Expected error for DSL %v", value)
+		}
+	}
+}
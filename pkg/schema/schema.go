@@ -0,0 +1,239 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema represents the subset of JSON Schema used by OpenAPI documents
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Additional        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *Bound             `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *Bound             `json:"exclusiveMaximum,omitempty"`
+	MultipleOf           *float64           `json:"multipleOf,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+
+	resolved *Schema
+}
+
+// Types holds the schema "type" keyword, which may be a single name or a list
+type Types []string
+
+// UnmarshalJSON accepts both "string" and ["string", "null"]
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// MarshalJSON writes a single type as a plain string
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Has reports whether the given type name is allowed
+func (t Types) Has(name string) bool {
+	for _, typ := range t {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Additional holds "additionalProperties", which is either a boolean or a schema
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalJSON accepts both true/false and a schema object
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		a.Allowed = allowed
+		return nil
+	}
+	a.Allowed = true
+	a.Schema = &Schema{}
+	return json.Unmarshal(data, a.Schema)
+}
+
+// MarshalJSON writes the schema when present, otherwise the boolean
+func (a Additional) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+// Bound holds exclusiveMinimum/exclusiveMaximum, a boolean in OpenAPI 3.0
+// and a number in OpenAPI 3.1 / JSON Schema 2020-12
+type Bound struct {
+	Flag  bool
+	Value *float64
+}
+
+// UnmarshalJSON accepts both boolean and numeric bounds
+func (b *Bound) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Flag); err == nil {
+		return nil
+	}
+	var value float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("exclusive bound must be a boolean or a number")
+	}
+	b.Value = &value
+	return nil
+}
+
+// MarshalJSON writes the bound in the form it was read
+func (b Bound) MarshalJSON() ([]byte, error) {
+	if b.Value != nil {
+		return json.Marshal(*b.Value)
+	}
+	return json.Marshal(b.Flag)
+}
+
+// Parse decodes a schema from JSON
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FromValue converts an already decoded JSON value (e.g. a map from a mock
+// file) into a schema
+func FromValue(value interface{}) (*Schema, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Resolve returns the schema a $ref points to, or the schema itself.
+// ResolveRefs rejects reference cycles, so the chain always ends.
+func (s *Schema) Resolve() *Schema {
+	for s != nil && s.resolved != nil {
+		s = s.resolved
+	}
+	return s
+}
+
+// ResolveRefs walks the schema tree and links every $ref to the schema
+// returned by lookup. References that only lead back to themselves, such as
+// A referring to B referring to A, are an error.
+func ResolveRefs(root *Schema, lookup func(ref string) (*Schema, error)) error {
+	return resolveRefs(root, lookup, make(map[*Schema]bool))
+}
+
+func resolveRefs(s *Schema, lookup func(ref string) (*Schema, error), seen map[*Schema]bool) error {
+	if s == nil || seen[s] {
+		return nil
+	}
+	seen[s] = true
+
+	if s.Ref != "" && s.resolved == nil {
+		target, err := lookup(s.Ref)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return fmt.Errorf("unresolved reference %s", s.Ref)
+		}
+		s.resolved = target
+		if refCycle(s) {
+			return fmt.Errorf("circular reference %s", s.Ref)
+		}
+		if err := resolveRefs(target, lookup, seen); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range s.children() {
+		if err := resolveRefs(child, lookup, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refCycle reports whether following the $ref links from s leads back to a
+// schema already visited
+func refCycle(s *Schema) bool {
+	visited := make(map[*Schema]bool)
+	for ; s != nil; s = s.resolved {
+		if visited[s] {
+			return true
+		}
+		visited[s] = true
+	}
+	return false
+}
+
+func (s *Schema) children() []*Schema {
+	var children []*Schema
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		children = append(children, s.AdditionalProperties.Schema)
+	}
+	if s.Items != nil {
+		children = append(children, s.Items)
+	}
+	if s.Not != nil {
+		children = append(children, s.Not)
+	}
+	children = append(children, s.AllOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.OneOf...)
+	return children
+}
+
+// LocalRefName returns the last segment of a local reference such as
+// "#/components/schemas/User"
+func LocalRefName(ref, prefix string) (string, bool) {
+	if !strings.HasPrefix(ref, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, prefix), true
+}
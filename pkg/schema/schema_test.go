@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	userSchema, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["id", "email"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"email": {"type": "string", "format": "email"},
+			"role": {"type": "string", "enum": ["admin", "member"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"nickname": {"type": ["string", "null"], "maxLength": 5}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		value      interface{}
+		errorPaths []string
+	}{
+		{
+			name:  "Valid object",
+			value: map[string]interface{}{"id": 1, "email": "a@example.com", "role": "admin", "tags": []interface{}{"x"}, "nickname": nil},
+		},
+		{
+			name:       "Missing required property",
+			value:      map[string]interface{}{"id": 1},
+			errorPaths: []string{"/email"},
+		},
+		{
+			name:       "Wrong types and formats",
+			value:      map[string]interface{}{"id": 1.5, "email": "not-an-email"},
+			errorPaths: []string{"/email", "/id"},
+		},
+		{
+			name:       "Enum, array and additional property violations",
+			value:      map[string]interface{}{"id": 2, "email": "a@example.com", "role": "owner", "tags": []interface{}{"a", "b", 3}, "extra": true},
+			errorPaths: []string{"/extra", "/role", "/tags", "/tags/2"},
+		},
+		{
+			name:       "Minimum violation",
+			value:      map[string]interface{}{"id": 0, "email": "a@example.com"},
+			errorPaths: []string{"/id"},
+		},
+		{
+			name:       "Not an object",
+			value:      "hello",
+			errorPaths: []string{""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := userSchema.Validate(tc.value)
+			if len(errs) != len(tc.errorPaths) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tc.errorPaths), len(errs), errs)
+			}
+			for i, path := range tc.errorPaths {
+				if errs[i].Path != path {
+					t.Errorf("Expected error %d at %q, got %q (%s)", i, path, errs[i].Path, errs[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	definitions := map[string]*Schema{}
+	node, err := Parse([]byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"children": {"type": "array", "items": {"$ref": "#/definitions/Node"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	definitions["Node"] = node
+
+	root := &Schema{Ref: "#/definitions/Node"}
+	err = ResolveRefs(root, func(ref string) (*Schema, error) {
+		name, _ := LocalRefName(ref, "#/definitions/")
+		return definitions[name], nil
+	})
+	if err != nil {
+		t.Fatalf("ResolveRefs failed: %v", err)
+	}
+
+	valid := map[string]interface{}{
+		"name":     "root",
+		"children": []interface{}{map[string]interface{}{"name": "leaf"}},
+	}
+	if errs := root.Validate(valid); len(errs) != 0 {
+		t.Errorf("Expected recursive value to be valid, got %v", errs)
+	}
+
+	invalid := map[string]interface{}{
+		"children": []interface{}{map[string]interface{}{"name": 5}},
+	}
+	errs := root.Validate(invalid)
+	if len(errs) != 1 || errs[0].Path != "/children/0/name" {
+		t.Errorf("Expected one error at /children/0/name, got %v", errs)
+	}
+
+	missing := &Schema{Ref: "#/definitions/Missing"}
+	if err := ResolveRefs(missing, func(string) (*Schema, error) { return nil, nil }); err == nil {
+		t.Error("Expected error for unresolved reference")
+	}
+
+	// References that only point at each other never reach a schema
+	cyclic := map[string]*Schema{
+		"A":    {Ref: "#/definitions/B"},
+		"B":    {Ref: "#/definitions/A"},
+		"Self": {Ref: "#/definitions/Self"},
+	}
+	lookup := func(ref string) (*Schema, error) {
+		name, _ := LocalRefName(ref, "#/definitions/")
+		return cyclic[name], nil
+	}
+	for _, ref := range []string{"#/definitions/A", "#/definitions/Self"} {
+		if err := ResolveRefs(&Schema{Ref: ref}, lookup); err == nil || !strings.Contains(err.Error(), "circular") {
+			t.Errorf("Expected a circular reference error for %s, got %v", ref, err)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidationError describes a single schema violation
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+var (
+	patternCache sync.Map
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Validate checks a decoded JSON value against the schema and returns every
+// violation found. An empty result means the value is valid.
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	return s.validate("", normalize(value))
+}
+
+// normalize converts Go values (e.g. ints from mock literals) to the shapes
+// produced by encoding/json so that type checks behave consistently
+func normalize(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, bool, string, float64:
+		return value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return value
+	}
+	return out
+}
+
+func (s *Schema) validate(path string, value interface{}) []ValidationError {
+	s = s.Resolve()
+	if s == nil {
+		return nil
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type.Has("null") || len(s.Type) == 0 && len(s.AllOf)+len(s.AnyOf)+len(s.OneOf) == 0 {
+			return nil
+		}
+	}
+
+	var errs []ValidationError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), TypeName(value))
+		return errs
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		fail("value %s is not one of the allowed values", describe(value))
+	}
+	if s.Const != nil && !equalValues(s.Const, value) {
+		fail("value must be %s", describe(s.Const))
+	}
+
+	switch v := value.(type) {
+	case string:
+		errs = append(errs, s.validateString(path, v)...)
+	case float64:
+		errs = append(errs, s.validateNumber(path, v)...)
+	case []interface{}:
+		errs = append(errs, s.validateArray(path, v)...)
+	case map[string]interface{}:
+		errs = append(errs, s.validateObject(path, v)...)
+	}
+
+	for _, sub := range s.AllOf {
+		errs = append(errs, sub.validate(path, value)...)
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			if len(sub.validate(path, value)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("value does not match any of the allowed schemas")
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, sub := range s.OneOf {
+			if len(sub.validate(path, value)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			fail("value must match exactly one schema, matched %d", matches)
+		}
+	}
+	if s.Not != nil && len(s.Not.validate(path, value)) == 0 {
+		fail("value must not match the excluded schema")
+	}
+
+	return errs
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	for _, typ := range s.Type {
+		switch typ {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := value.(float64); ok && n == math.Trunc(n) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Schema) validateString(path, value string) []ValidationError {
+	var errs []ValidationError
+	length := len([]rune(value))
+	if s.MinLength != nil && length < *s.MinLength {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("length must be at least %d", *s.MinLength)})
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("length must be at most %d", *s.MaxLength)})
+	}
+	if s.Pattern != "" {
+		if re, err := compilePattern(s.Pattern); err == nil && !re.MatchString(value) {
+			errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("value does not match pattern %s", s.Pattern)})
+		}
+	}
+	if s.Format != "" && !validFormat(s.Format, value) {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("value is not a valid %s", s.Format)})
+	}
+	return errs
+}
+
+func (s *Schema) validateNumber(path string, value float64) []ValidationError {
+	var errs []ValidationError
+	if s.Minimum != nil {
+		exclusive := s.ExclusiveMinimum != nil && s.ExclusiveMinimum.Flag
+		if value < *s.Minimum || exclusive && value == *s.Minimum {
+			errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("value must be greater than %s%v", orEqual(!exclusive), *s.Minimum)})
+		}
+	}
+	if s.ExclusiveMinimum != nil && s.ExclusiveMinimum.Value != nil && value <= *s.ExclusiveMinimum.Value {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("value must be greater than %v", *s.ExclusiveMinimum.Value)})
+	}
+	if s.Maximum != nil {
+		exclusive := s.ExclusiveMaximum != nil && s.ExclusiveMaximum.Flag
+		if value > *s.Maximum || exclusive && value == *s.Maximum {
+			errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("value must be less than %s%v", orEqual(!exclusive), *s.Maximum)})
+		}
+	}
+	if s.ExclusiveMaximum != nil && s.ExclusiveMaximum.Value != nil && value >= *s.ExclusiveMaximum.Value {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("value must be less than %v", *s.ExclusiveMaximum.Value)})
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		if q := value / *s.MultipleOf; q != math.Trunc(q) {
+			errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("value must be a multiple of %v", *s.MultipleOf)})
+		}
+	}
+	return errs
+}
+
+func (s *Schema) validateArray(path string, value []interface{}) []ValidationError {
+	var errs []ValidationError
+	if s.MinItems != nil && len(value) < *s.MinItems {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("array must have at least %d items", *s.MinItems)})
+	}
+	if s.MaxItems != nil && len(value) > *s.MaxItems {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("array must have at most %d items", *s.MaxItems)})
+	}
+	if s.UniqueItems {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if equalValues(value[i], value[j]) {
+					errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("items %d and %d are not unique", i, j)})
+				}
+			}
+		}
+	}
+	if s.Items != nil {
+		for i, item := range value {
+			errs = append(errs, s.Items.validate(fmt.Sprintf("%s/%d", path, i), item)...)
+		}
+	}
+	return errs
+}
+
+func (s *Schema) validateObject(path string, value map[string]interface{}) []ValidationError {
+	var errs []ValidationError
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			errs = append(errs, ValidationError{Path: path + "/" + name, Message: "required property is missing"})
+		}
+	}
+
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "/" + key
+		if prop, ok := s.Properties[key]; ok {
+			errs = append(errs, prop.validate(childPath, value[key])...)
+			continue
+		}
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if !s.AdditionalProperties.Allowed {
+			errs = append(errs, ValidationError{Path: childPath, Message: "additional property is not allowed"})
+		} else if s.AdditionalProperties.Schema != nil {
+			errs = append(errs, s.AdditionalProperties.Schema.validate(childPath, value[key])...)
+		}
+	}
+	return errs
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "email":
+		return emailPattern.MatchString(value)
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "uri", "url":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() == nil
+	}
+	// Unknown formats are annotations only
+	return true
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := patternCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// TypeName returns the JSON type name of a decoded value
+func TypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if equalValues(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValues(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func describe(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+func orEqual(inclusive bool) string {
+	if inclusive {
+		return "or equal to "
+	}
+	return ""
+}
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/admin"
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/logging"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.uber.org/zap"
+)
+
+// handleAdminReset restores resource stores to their seed data, moves every
+// scenario back to its initial state and clears the request journal. The
+// optional "path" query parameter limits the reset to a single resource.
+func (s *Server) handleAdminReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.logger.Error("Invalid method for admin reset",
+			zap.String("method", r.Method),
+		)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	reset, ok := s.resetResources(path)
+	if !ok {
+		s.logger.Error("Resource not found for reset",
+			zap.String("resource", path),
+		)
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	s.logger.Info("Resources reset",
+		zap.Strings("resources", reset),
+	)
+	if path == "" {
+		scenarios := s.resetScenarios()
+		s.journal.Clear()
+		s.logger.Info("Scenarios and request journal reset",
+			zap.Strings("scenarios", scenarios),
+		)
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, admin.ResetResponse{
+		Status:    "success",
+		Resources: reset,
+	})
+}
+
+// handleAdminRequests returns the request journal on GET and clears it on
+// DELETE
+func (s *Server) handleAdminRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSONResponse(w, http.StatusOK, admin.RequestsResponse{
+			Status:   "success",
+			Requests: s.journal.Entries(),
+		})
+	case http.MethodDelete:
+		s.journal.Clear()
+		s.logger.Info("Request journal cleared")
+		s.writeJSONResponse(w, http.StatusOK, admin.RequestsResponse{
+			Status:   "success",
+			Requests: []journal.Entry{},
+		})
+	default:
+		s.logger.Error("Invalid method for admin requests",
+			zap.String("method", r.Method),
+		)
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminRequestCount counts the journaled requests matching the "method"
+// and "path" query parameters. Either may be omitted to match any value.
+func (s *Server) handleAdminRequestCount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.logger.Error("Invalid method for admin request count",
+			zap.String("method", r.Method),
+		)
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	method := strings.ToUpper(r.URL.Query().Get("method"))
+	path := r.URL.Query().Get("path")
+	count := 0
+	for _, entry := range s.journal.Entries() {
+		if (method == "" || entry.Method == method) && (path == "" || entry.Path == path) {
+			count++
+		}
+	}
+
+	s.writeJSONResponse(w, http.StatusOK, admin.CountResponse{
+		Status: "success",
+		Count:  count,
+	})
+}
+
+// handleAdminMocks lists the mocks on GET, adds or replaces a mock on POST
+// and removes the mock of the "path" query parameter on DELETE
+func (s *Server) handleAdminMocks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSONResponse(w, http.StatusOK, admin.MocksResponse{
+			Status: "success",
+			Mocks:  redactMocks(s.mocks()),
+		})
+	case http.MethodPost:
+		var m mock.Response
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			s.logger.Error("Invalid mock definition", zap.Error(err))
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if !strings.HasPrefix(m.Path, "/") {
+			http.Error(w, "Mock path must start with /", http.StatusBadRequest)
+			return
+		}
+		if m.Method == "" && m.Type != mock.TypeGRPC {
+			http.Error(w, "Mock method is required", http.StatusBadRequest)
+			return
+		}
+		// Mocks posted over HTTP must not read files from the host
+		if files := m.FileReferences(); len(files) > 0 {
+			s.logger.Error("Mock definition refers to files",
+				zap.String("path", m.Path),
+				zap.Strings("files", files),
+			)
+			http.Error(w, "Mocks added through the admin API cannot refer to files", http.StatusBadRequest)
+			return
+		}
+		if err := mock.Prepare(".", &m); err != nil {
+			s.logger.Error("Invalid mock definition",
+				zap.String("path", m.Path),
+				zap.Error(err),
+			)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.setMock(m.Path, m)
+		s.logger.Info("Mock added",
+			zap.String("method", m.Method),
+			zap.String("path", m.Path),
+		)
+		s.writeJSONResponse(w, http.StatusCreated, admin.MockResponse{
+			Status: "success",
+			Path:   m.Path,
+		})
+	case http.MethodDelete:
+		path := r.URL.Query().Get("path")
+		if !s.removeMock(path) {
+			s.logger.Error("Mock not found for removal",
+				zap.String("path", path),
+			)
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Info("Mock removed",
+			zap.String("path", path),
+		)
+		s.writeJSONResponse(w, http.StatusOK, admin.MockResponse{
+			Status: "success",
+			Path:   path,
+		})
+	default:
+		s.logger.Error("Invalid method for admin mocks",
+			zap.String("method", r.Method),
+		)
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// redactMocks returns a copy of the mocks with the auth secrets, API keys and
+// passwords masked, so listing the mocks doesn't hand out credentials
+func redactMocks(mocks map[string]mock.Response) map[string]mock.Response {
+	redacted := make(map[string]mock.Response, len(mocks))
+	for path, m := range mocks {
+		if m.Auth != nil {
+			auth := *m.Auth
+			if auth.Secret != "" {
+				auth.Secret = logging.Redacted
+			}
+			if len(auth.Keys) > 0 {
+				auth.Keys = make([]string, len(m.Auth.Keys))
+				for i := range auth.Keys {
+					auth.Keys[i] = logging.Redacted
+				}
+			}
+			if len(auth.Users) > 0 {
+				auth.Users = make(map[string]string, len(m.Auth.Users))
+				for user := range m.Auth.Users {
+					auth.Users[user] = logging.Redacted
+				}
+			}
+			m.Auth = &auth
+		}
+		redacted[path] = m
+	}
+	return redacted
+}
+
+// handleAdminScenarios lists scenario states on GET and moves every scenario
+// back to its initial state on DELETE
+func (s *Server) handleAdminScenarios(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSONResponse(w, http.StatusOK, admin.ScenariosResponse{
+			Status:    "success",
+			Scenarios: s.scenarioStates(),
+		})
+	case http.MethodDelete:
+		names := s.resetScenarios()
+		s.logger.Info("Scenarios reset",
+			zap.Strings("scenarios", names),
+		)
+		s.writeJSONResponse(w, http.StatusOK, admin.ScenariosResponse{
+			Status:    "success",
+			Scenarios: s.scenarioStates(),
+		})
+	default:
+		s.logger.Error("Invalid method for admin scenarios",
+			zap.String("method", r.Method),
+		)
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminScenario moves the scenario named in the path to the state
+// given in the body
+func (s *Server) handleAdminScenario(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		s.logger.Error("Invalid method for admin scenario",
+			zap.String("method", r.Method),
+		)
+		w.Header().Set("Allow", "PUT")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req admin.ScenarioStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.State == "" {
+		http.Error(w, "Body must be a JSON object with a state", http.StatusBadRequest)
+		return
+	}
+
+	name := r.PathValue("name")
+	s.setScenarioState(name, req.State)
+	s.logger.Info("Scenario state set",
+		zap.String("scenario", name),
+		zap.String("state", req.State),
+	)
+	s.writeJSONResponse(w, http.StatusOK, admin.ScenariosResponse{
+		Status:    "success",
+		Scenarios: s.scenarioStates(),
+	})
+}
+
+// handleAdminReload replaces the mocks with a fresh load. The current mocks
+// stay in place when the load fails.
+func (s *Server) handleAdminReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.logger.Error("Invalid method for admin reload",
+			zap.String("method", r.Method),
+		)
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.reload == nil {
+		http.Error(w, "Reloading is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	responses, err := s.reload()
+	if err != nil {
+		s.logger.Error("Failed to reload mocks", zap.Error(err))
+		s.recordReload(false)
+		s.responsesMu.Lock()
+		s.loadErr = err
+		s.responsesMu.Unlock()
+		http.Error(w, fmt.Sprintf("Failed to reload mocks: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	s.replaceMocks(responses)
+	s.recordReload(true)
+	s.logger.Info("Mocks reloaded",
+		zap.Int("endpoints", len(responses)),
+	)
+	s.writeJSONResponse(w, http.StatusOK, admin.ReloadResponse{
+		Status:    "success",
+		Endpoints: len(responses),
+	})
+}
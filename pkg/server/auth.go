@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.uber.org/zap"
+)
+
+// authenticate checks the credentials of the request against the auth
+// requirement of the endpoint at path and returns the claims they grant.
+// Without valid credentials it answers with the endpoint's 401 or 403
+// response and reports that the request was not allowed.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, path string, endpoint *mock.Response) (map[string]interface{}, bool) {
+	config := endpoint.Auth
+	if !config.Enabled() {
+		return nil, true
+	}
+
+	claims, err := config.Authenticate(r)
+	if err == nil {
+		return claims, true
+	}
+	s.deny(w, r, path, config, err)
+	return nil, false
+}
+
+// forClaims restricts the endpoint to the responses the claims grant. When
+// every response requires claims the credentials lack, it answers with the
+// endpoint's 403 response, or a 404 without auth, and reports that the
+// request was not allowed.
+func (s *Server) forClaims(w http.ResponseWriter, r *http.Request, path string, endpoint *mock.Response, claims map[string]interface{}) (*mock.Response, bool) {
+	filtered := endpoint.ForClaims(claims)
+	if len(filtered.Responses) > 0 || len(endpoint.Responses) == 0 {
+		return filtered, true
+	}
+	if !endpoint.Auth.Enabled() {
+		s.markUnmatched(r)
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return nil, false
+	}
+	s.deny(w, r, path, endpoint.Auth, fmt.Errorf("%w: no response for the claims", mock.ErrForbidden))
+	return nil, false
+}
+
+// deny answers a request refused for err with the 401 or 403 response of
+// the auth config
+func (s *Server) deny(w http.ResponseWriter, r *http.Request, path string, config *mock.AuthConfig, err error) {
+	s.logger.Warn("Request not authorized",
+		zap.String("path", path),
+		zap.String("auth", config.Type),
+		zap.Error(err),
+	)
+
+	response := mock.ResponseConfig{
+		Status: http.StatusUnauthorized,
+		Body:   map[string]interface{}{"error": "Unauthorized"},
+	}
+	override := config.Unauthorized
+	outcome := outcomeUnauthorized
+	if errors.Is(err, mock.ErrForbidden) {
+		response = mock.ResponseConfig{
+			Status: http.StatusForbidden,
+			Body:   map[string]interface{}{"error": "Forbidden"},
+		}
+		override = config.Forbidden
+		outcome = outcomeForbidden
+	}
+	if override != nil {
+		status := response.Status
+		response = *override
+		if response.Status == 0 {
+			response.Status = status
+		}
+	}
+
+	w.Header().Set("WWW-Authenticate", config.Challenge(err))
+	setOutcome(r, "", outcome)
+	setResponse(r, &response)
+	s.writeMockResponse(w, &response)
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+)
+
+// parseFormBody converts an application/x-www-form-urlencoded body into a map
+// so it can be matched like a JSON object
+func parseFormBody(body []byte) (interface{}, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid form data in request body")
+	}
+	form := make(map[string]interface{}, len(values))
+	for key, list := range values {
+		items := make([]interface{}, len(list))
+		for i, value := range list {
+			items[i] = value
+		}
+		form[key] = formValue(items)
+	}
+	return form, nil
+}
+
+// DefaultMaxBodySize limits how much of a JSON or form body, or of a
+// multipart field value, is read into memory for matching
+const DefaultMaxBodySize = 32 << 20
+
+// errBodyTooLarge reports a buffered body over the server's limit
+var errBodyTooLarge = errors.New("Request body is too large")
+
+// readLimited reads all of r, failing with errBodyTooLarge once more than
+// limit bytes are read
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
+
+// parseMultipartBody converts a multipart/form-data body into a map. Fields
+// become strings and files are described by their filename, size, content
+// type and SHA-256 hash rather than their content. Files are hashed as they
+// are read so they are never held in memory and have no size limit; field
+// values are limited to limit bytes.
+func parseMultipartBody(body io.Reader, boundary string, limit int64) (interface{}, error) {
+	if boundary == "" {
+		return nil, fmt.Errorf("Missing boundary in multipart Content-Type")
+	}
+
+	fields := make(map[string][]interface{})
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid multipart data in request body")
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			value, err := readLimited(part, limit)
+			if errors.Is(err, errBodyTooLarge) {
+				return nil, err
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Invalid multipart data in request body")
+			}
+			fields[name] = append(fields[name], string(value))
+			continue
+		}
+
+		hash := sha256.New()
+		size, err := io.Copy(hash, part)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid multipart data in request body")
+		}
+		fields[name] = append(fields[name], map[string]interface{}{
+			"filename":     part.FileName(),
+			"size":         size,
+			"content_type": part.Header.Get("Content-Type"),
+			"sha256":       hex.EncodeToString(hash.Sum(nil)),
+		})
+	}
+
+	form := make(map[string]interface{}, len(fields))
+	for name, values := range fields {
+		form[name] = formValue(values)
+	}
+	return form, nil
+}
+
+// formValue returns single values as is and repeated ones as a list
+func formValue(values []interface{}) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
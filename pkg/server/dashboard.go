@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/admin"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.uber.org/zap"
+)
+
+// requestStreamBuffer is the number of journal entries a request stream may
+// fall behind before it misses entries
+const requestStreamBuffer = 64
+
+// requestStreamKeepAlive is how often an idle request stream sends a comment
+// so proxies don't close it
+const requestStreamKeepAlive = 15 * time.Second
+
+// handleAdminRequestStream streams journal entries as Server-Sent Events as
+// requests complete, for the dashboard's live feed
+func (s *Server) handleAdminRequestStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.logger.Error("Invalid method for admin request stream",
+			zap.String("method", r.Method),
+		)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	entries, cancel := s.journal.Subscribe(requestStreamBuffer)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(requestStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case entry := <-entries:
+			data, err := json.Marshal(entry)
+			if err != nil {
+				s.logger.Error("Failed to encode journal entry", zap.Error(err))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: request\ndata: %s\n\n", entry.ID, data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.shutdownCh:
+			// The stream would otherwise hold up a graceful shutdown
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handleAdminForced lists forced responses on GET, forces a response on PUT
+// and clears forced responses on DELETE, for the endpoint in the optional
+// "path" query parameter or for every endpoint
+func (s *Server) handleAdminForced(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req admin.ForceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid force request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if status, err := s.forceResponse(req.Path, req.Index); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		s.logger.Info("Response forced",
+			zap.String("path", req.Path),
+			zap.Int("index", req.Index),
+		)
+	case http.MethodDelete:
+		path := r.URL.Query().Get("path")
+		s.responsesMu.Lock()
+		if path == "" {
+			s.forced = nil
+		} else {
+			delete(s.forced, path)
+		}
+		s.responsesMu.Unlock()
+		s.logger.Info("Forced responses cleared", zap.String("path", path))
+	default:
+		s.logger.Error("Invalid method for admin forced responses",
+			zap.String("method", r.Method),
+		)
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.responsesMu.RLock()
+	forced := make(map[string]int, len(s.forced))
+	for path, index := range s.forced {
+		forced[path] = index
+	}
+	s.responsesMu.RUnlock()
+	s.writeJSONResponse(w, http.StatusOK, admin.ForcedResponses{
+		Status: "success",
+		Forced: forced,
+	})
+}
+
+// forceResponse makes the HTTP endpoint at path answer with its response at
+// index. It returns the status to reply with when the response can't be
+// forced.
+func (s *Server) forceResponse(path string, index int) (int, error) {
+	s.responsesMu.Lock()
+	defer s.responsesMu.Unlock()
+
+	m, ok := s.responses[path]
+	if !ok {
+		return http.StatusNotFound, fmt.Errorf("no mock for path %q", path)
+	}
+	if m.Type != "" {
+		return http.StatusBadRequest, fmt.Errorf("responses of %s endpoints can't be forced", m.Type)
+	}
+	if index < 0 || index >= len(m.Responses) {
+		return http.StatusBadRequest, fmt.Errorf("%s has no response %d", path, index)
+	}
+	if s.forced == nil {
+		s.forced = make(map[string]int)
+	}
+	s.forced[path] = index
+	return http.StatusOK, nil
+}
+
+// forcedResponse returns the response forced for the endpoint at path
+func (s *Server) forcedResponse(path string, endpoint *mock.Response) (*mock.ResponseConfig, bool) {
+	s.responsesMu.RLock()
+	index, ok := s.forced[path]
+	s.responsesMu.RUnlock()
+	if !ok || index >= len(endpoint.Responses) {
+		return nil, false
+	}
+	return &endpoint.Responses[index], true
+}
+
+// describeResponse names a mock response in the journal, by its description
+// or else its status
+func describeResponse(response *mock.ResponseConfig) string {
+	switch {
+	case response == nil:
+		return ""
+	case response.Description != "":
+		return response.Description
+	case response.Status != 0:
+		return "status " + strconv.Itoa(response.Status)
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sachin-duhan/gomock/pkg/graphql"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.uber.org/zap"
+)
+
+// handleGraphQL answers a GraphQL request with the response matching its
+// operation. Malformed requests get a 400; schema violations and unmatched
+// operations are reported in the errors list of a 200 response, as GraphQL
+// clients expect.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request, endpoint *mock.Response) {
+	req, err := graphql.ParseRequest(r)
+	if err != nil {
+		s.logger.Error("Invalid GraphQL request",
+			zap.String("path", r.URL.Path),
+			zap.Error(err),
+		)
+		s.writeGraphQLErrors(w, http.StatusBadRequest, graphql.Error{Message: err.Error()})
+		return
+	}
+
+	operation := req.Operation()
+	if endpoint.GraphQL != nil {
+		schema, err := endpoint.GraphQL.Schema()
+		if err != nil {
+			s.logger.Error("Invalid GraphQL schema",
+				zap.String("path", r.URL.Path),
+				zap.Error(err),
+			)
+			http.Error(w, "Invalid GraphQL schema", http.StatusInternalServerError)
+			return
+		}
+		if schema != nil {
+			if errs := graphql.Validate(schema, req); len(errs) > 0 {
+				s.logger.Warn("GraphQL request does not match schema",
+					zap.String("path", r.URL.Path),
+					zap.String("operation", operation),
+					zap.Int("error_count", len(errs)),
+				)
+				s.writeGraphQLErrors(w, http.StatusOK, errs...)
+				return
+			}
+		}
+	}
+
+	endpoint = endpoint.ForProtocol(r.Proto).ForHeaders(r.Header).ForScenarios(s.scenarioState)
+	response := endpoint.FindGraphQLResponse(req)
+	if statusHeader := r.Header.Get("x-stub-status"); statusHeader != "" {
+		if status, err := strconv.Atoi(statusHeader); err == nil {
+			for i := range endpoint.Responses {
+				if endpoint.Responses[i].Status == status {
+					response = &endpoint.Responses[i]
+					break
+				}
+			}
+		}
+	}
+	if response == nil {
+		s.logger.Error("No matching GraphQL response found",
+			zap.String("path", r.URL.Path),
+			zap.String("operation", operation),
+		)
+		s.markUnmatched(r)
+		s.writeGraphQLErrors(w, http.StatusOK, graphql.Error{
+			Message: fmt.Sprintf("no mock response for operation %q", operation),
+		})
+		return
+	}
+	s.advanceScenario(response)
+	setResponse(r, response)
+
+	data := response.Body
+	if response.IsGenerated() {
+		data, err = s.generateBody(r, response)
+		if err != nil {
+			s.logger.Error("Failed to generate response body",
+				zap.String("path", r.URL.Path),
+				zap.Error(err),
+			)
+			http.Error(w, "Failed to generate response body", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	envelope := GraphQLEnvelope{Data: data}
+	if response.GraphQL != nil {
+		envelope.Errors = response.GraphQL.Errors
+	}
+
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	s.logger.Debug("Found matching GraphQL response",
+		zap.String("path", r.URL.Path),
+		zap.String("operation", operation),
+		zap.Int("status", status),
+	)
+
+	for key, value := range response.Headers {
+		w.Header().Set(key, value)
+	}
+	s.writeJSONResponse(w, status, envelope)
+}
+
+func (s *Server) writeGraphQLErrors(w http.ResponseWriter, status int, errs ...graphql.Error) {
+	envelope := GraphQLEnvelope{}
+	for _, err := range errs {
+		envelope.Errors = append(envelope.Errors, err)
+	}
+	s.writeJSONResponse(w, status, envelope)
+}
@@ -0,0 +1,379 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	v1reflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	v1alphareflectiongrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// newGRPCServer creates the gRPC server answering every method from the mocks.
+// The reflection service advertises the mocked services so tools like grpcurl
+// work without local proto files.
+func (s *Server) newGRPCServer() *grpc.Server {
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(s.handleGRPC))
+	opts := reflection.ServerOptions{
+		Services:           grpcServices{server: s},
+		DescriptorResolver: s.grpcRegistry.Files(),
+	}
+	v1reflectiongrpc.RegisterServerReflectionServer(srv, reflection.NewServerV1(opts))
+	v1alphareflectiongrpc.RegisterServerReflectionServer(srv, reflection.NewServer(opts))
+	return srv
+}
+
+// grpcServices lists the mocked services for the reflection service
+type grpcServices struct {
+	server *Server
+}
+
+func (g grpcServices) GetServiceInfo() map[string]grpc.ServiceInfo {
+	return g.server.grpcRegistry.ServiceInfo(g.server.mocks())
+}
+
+// handleGRPC serves unary and server-streaming methods. The request message
+// is matched against input_body field by field, and the response body and
+// stream messages are converted from JSON to the method's output type.
+func (s *Server) handleGRPC(_ interface{}, stream grpc.ServerStream) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	start := time.Now()
+
+	s.logger.Info("Incoming gRPC request", zap.String("method", fullMethod))
+	entryID := s.recordGRPC(stream, fullMethod)
+
+	var span trace.Span
+	if s.tracer != nil {
+		stream, span = s.startGRPCSpan(stream, fullMethod)
+	}
+
+	info := &requestInfo{}
+	err := s.serveGRPC(stream, fullMethod, entryID, info)
+	duration := time.Since(start)
+
+	st := status.Convert(err)
+	if span != nil {
+		endGRPCSpan(span, st)
+	}
+	if s.metrics != nil {
+		s.metrics.observeGRPC(s.grpcMetricMethod(fullMethod), st.Code(), info, duration.Seconds())
+	}
+	if entryID != 0 {
+		if info.outcome != "" {
+			s.journal.SetMatch(entryID, info.endpoint, info.outcome, describeResponse(info.response))
+		}
+		s.journal.AddMessage(entryID, journal.Message{
+			Direction: journal.DirectionOut,
+			Type:      "status",
+			Data:      st.Code().String() + ": " + st.Message(),
+		})
+		s.journal.Complete(entryID, http.StatusOK, duration)
+	}
+	s.logger.Info("gRPC request completed",
+		zap.String("method", fullMethod),
+		zap.String("code", st.Code().String()),
+		zap.Duration("duration", duration),
+	)
+	return err
+}
+
+// serveGRPC answers a call, recording in info the mocked method that handled
+// it and how its response was selected
+func (s *Server) serveGRPC(stream grpc.ServerStream, fullMethod string, entryID int64, info *requestInfo) error {
+	method, err := s.grpcRegistry.Method(fullMethod)
+	if err != nil {
+		s.markGRPCUnmatched(entryID, info)
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	endpoint, ok := s.mocks()[fullMethod]
+	if !ok || !endpoint.IsGRPC() {
+		s.markGRPCUnmatched(entryID, info)
+		return status.Errorf(codes.Unimplemented, "no mock for method %s", fullMethod)
+	}
+	info.endpoint = fullMethod
+	if method.IsStreamingClient() {
+		s.markGRPCUnmatched(entryID, info)
+		return status.Errorf(codes.Unimplemented, "client streaming is not supported")
+	}
+	if err := s.grpcRateLimited(stream, fullMethod, &endpoint, info); err != nil {
+		return err
+	}
+	claims, err := s.grpcAuthenticate(stream.Context(), fullMethod, &endpoint, info)
+	if err != nil {
+		return err
+	}
+	gated := endpoint.ForClaims(claims)
+	if len(gated.Responses) == 0 && len(endpoint.Responses) > 0 {
+		s.markGRPCUnmatched(entryID, info)
+		return status.Errorf(codes.PermissionDenied, "no response for the claims of the credentials")
+	}
+
+	request := s.grpcRegistry.NewMessage(method.Input())
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	input, err := s.grpcRegistry.ToJSON(request)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to decode request: %v", err)
+	}
+	s.recordGRPCMessage(entryID, journal.DirectionIn, input)
+
+	response := s.findGRPCResponse(stream.Context(), gated.ForScenarios(s.scenarioState), method, input)
+	if response == nil {
+		s.logger.Error("No matching gRPC response found",
+			zap.String("method", fullMethod),
+			zap.Any("request", input),
+		)
+		s.markGRPCUnmatched(entryID, info)
+		return status.Errorf(codes.Unimplemented, "no matching mock response for %s", fullMethod)
+	}
+	s.advanceScenario(response)
+	response = response.WithClaims(claims)
+	annotateResponse(trace.SpanFromContext(stream.Context()), response)
+	info.outcome, info.response = outcomeMatched, response
+
+	if len(response.Headers) > 0 {
+		if err := stream.SetHeader(metadata.New(response.Headers)); err != nil {
+			return err
+		}
+	}
+	if response.GRPC != nil && len(response.GRPC.Trailers) > 0 {
+		stream.SetTrailer(metadata.New(response.GRPC.Trailers))
+	}
+
+	st, err := s.grpcRegistry.Status(response.GRPC)
+	if err != nil {
+		return status.Errorf(codes.Internal, "invalid mock status: %v", err)
+	}
+
+	// Unary errors carry no message; streams send their messages before the
+	// error so consumers can be tested against failures mid-stream
+	if st == nil || method.IsStreamingServer() {
+		if err := s.sendGRPCMessages(stream, method, response, entryID); err != nil {
+			return err
+		}
+	}
+	if st != nil {
+		return st.Err()
+	}
+	return nil
+}
+
+// findGRPCResponse picks the response for a request. Configured input bodies
+// are normalized through the request type first so proto and JSON field
+// names both work. The x-stub-status metadata selects a response by its code.
+func (s *Server) findGRPCResponse(ctx context.Context, endpoint *mock.Response, method protoreflect.MethodDescriptor, input interface{}) *mock.ResponseConfig {
+	normalized := *endpoint
+	normalized.Responses = make([]mock.ResponseConfig, len(endpoint.Responses))
+	for i, resp := range endpoint.Responses {
+		if resp.InputBody != nil {
+			if value, err := s.grpcRegistry.Normalize(method.Input(), resp.InputBody); err == nil {
+				resp.InputBody = value
+			}
+		}
+		normalized.Responses[i] = resp
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-stub-status")) > 0 {
+		if code, err := mock.ParseCode(md.Get("x-stub-status")[0]); err == nil {
+			for i, resp := range normalized.Responses {
+				if respCode, _ := grpcCode(resp.GRPC); respCode == code {
+					return &normalized.Responses[i]
+				}
+			}
+			s.logger.Warn("No gRPC response found for desired status code",
+				zap.String("desired_code", code.String()),
+			)
+		}
+	}
+
+	return normalized.MatchFields(input)
+}
+
+// grpcRateLimited counts the call against the rate limit of the method and
+// sends the x-ratelimit-* headers. Over the limit, it returns a
+// ResourceExhausted status.
+func (s *Server) grpcRateLimited(stream grpc.ServerStream, fullMethod string, endpoint *mock.Response, info *requestInfo) error {
+	config := endpoint.RateLimit
+	if config == nil {
+		return nil
+	}
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		remoteAddr = p.Addr.String()
+	}
+	key := config.KeyFor(grpcHeaders(stream.Context()), remoteAddr)
+	result := s.rateLimiter(fullMethod, config).Allow(key)
+	md := metadata.Pairs(
+		"x-ratelimit-limit", strconv.Itoa(result.Limit),
+		"x-ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"x-ratelimit-reset", strconv.Itoa(seconds(result.Reset)),
+	)
+	if result.Allowed {
+		return stream.SetHeader(md)
+	}
+
+	s.logger.Warn("Rate limit exceeded",
+		zap.String("method", fullMethod),
+		zap.String("key", key),
+		zap.Duration("retry_after", result.RetryAfter),
+	)
+	md.Set("retry-after", strconv.Itoa(seconds(result.RetryAfter)))
+	if err := stream.SetHeader(md); err != nil {
+		return err
+	}
+	info.outcome = outcomeRateLimited
+	return status.Error(codes.ResourceExhausted, "Too Many Requests")
+}
+
+// grpcAuthenticate checks the credentials in the call metadata against the
+// auth requirement of the method and returns the claims they grant. Missing
+// or invalid credentials give an Unauthenticated status and insufficient
+// ones PermissionDenied.
+func (s *Server) grpcAuthenticate(ctx context.Context, fullMethod string, endpoint *mock.Response, info *requestInfo) (map[string]interface{}, error) {
+	config := endpoint.Auth
+	if !config.Enabled() {
+		return nil, nil
+	}
+
+	// Credentials are read from the metadata as from HTTP headers
+	r := &http.Request{Header: grpcHeaders(ctx), URL: &url.URL{}}
+	claims, err := config.Authenticate(r)
+	if err == nil {
+		return claims, nil
+	}
+
+	s.logger.Warn("gRPC call not authorized",
+		zap.String("method", fullMethod),
+		zap.String("auth", config.Type),
+		zap.Error(err),
+	)
+	code, outcome := codes.Unauthenticated, outcomeUnauthorized
+	if errors.Is(err, mock.ErrForbidden) {
+		code, outcome = codes.PermissionDenied, outcomeForbidden
+	}
+	info.outcome = outcome
+	return nil, status.Error(code, err.Error())
+}
+
+// grpcHeaders returns the metadata of a call as HTTP headers
+func grpcHeaders(ctx context.Context) http.Header {
+	header := http.Header{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	return header
+}
+
+// grpcCode returns the status code of a response, OK when it has none
+func grpcCode(resp *mock.GRPCResponse) (codes.Code, error) {
+	if resp == nil {
+		return codes.OK, nil
+	}
+	return resp.StatusCode()
+}
+
+// sendGRPCMessages sends the stream messages of a server-streaming method or
+// the body of a unary one
+func (s *Server) sendGRPCMessages(stream grpc.ServerStream, method protoreflect.MethodDescriptor, response *mock.ResponseConfig, entryID int64) error {
+	var chunks []mock.StreamChunk
+	if method.IsStreamingServer() && response.GRPC != nil && len(response.GRPC.Stream) > 0 {
+		chunks = response.GRPC.Stream
+	} else {
+		body := response.Body
+		if response.IsGenerated() {
+			// The x-stub-seed metadata seeds the body like the HTTP header
+			r := &http.Request{Header: grpcHeaders(stream.Context())}
+			generated, err := s.generateBody(r, response)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to generate response: %v", err)
+			}
+			body = generated
+		}
+		chunks = []mock.StreamChunk{{Data: body}}
+	}
+
+	for _, chunk := range chunks {
+		if chunk.DelayMS != nil && *chunk.DelayMS > 0 {
+			timer := time.NewTimer(time.Duration(*chunk.DelayMS) * time.Millisecond)
+			select {
+			case <-stream.Context().Done():
+				timer.Stop()
+				return status.FromContextError(stream.Context().Err()).Err()
+			case <-timer.C:
+			}
+		}
+		msg, err := s.grpcRegistry.FromJSON(method.Output(), chunk.Data)
+		if err != nil {
+			return status.Errorf(codes.Internal, "invalid mock response: %v", err)
+		}
+		if err := stream.SendMsg(msg); err != nil {
+			return err
+		}
+		s.recordGRPCMessage(entryID, journal.DirectionOut, chunk.Data)
+	}
+	return nil
+}
+
+// recordGRPC adds the call to the request journal
+func (s *Server) recordGRPC(stream grpc.ServerStream, fullMethod string) int64 {
+	if s.journal == nil {
+		return 0
+	}
+	entry := journal.Entry{
+		Method:   http.MethodPost,
+		Path:     fullMethod,
+		Protocol: "gRPC",
+		Headers:  s.journalRedactor().RedactHeader(grpcHeaders(stream.Context())),
+	}
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		entry.RemoteAddr = p.Addr.String()
+	}
+	return s.journal.Record(entry)
+}
+
+func (s *Server) markGRPCUnmatched(entryID int64, info *requestInfo) {
+	info.outcome = outcomeUnmatched
+	if entryID != 0 {
+		s.journal.MarkUnmatched(entryID)
+	}
+}
+
+// grpcMetricMethod bounds the method label to the methods of the loaded
+// proto files
+func (s *Server) grpcMetricMethod(fullMethod string) string {
+	if _, err := s.grpcRegistry.Method(fullMethod); err != nil {
+		return "OTHER"
+	}
+	return fullMethod
+}
+
+func (s *Server) recordGRPCMessage(entryID int64, direction string, value interface{}) {
+	if entryID == 0 {
+		return
+	}
+	data, _ := json.Marshal(value)
+	s.journal.AddMessage(entryID, journal.Message{
+		Direction: direction,
+		Type:      "message",
+		Data:      string(s.journalRedactor().RedactBody(data)),
+	})
+}
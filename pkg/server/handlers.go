@@ -3,45 +3,104 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/sachin-duhan/gomock/pkg/admin"
 	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/soap"
 	"go.uber.org/zap"
 )
 
 // handleMockRequest handles incoming API requests and returns mock responses
 func (s *Server) handleMockRequest(w http.ResponseWriter, r *http.Request) {
-	mock, err := s.findMockResponse(r.URL.Path)
+	if resourceMock, base, id, ok := s.findResource(r.URL.Path); ok {
+		setOutcome(r, base, outcomeMatched)
+		if s.rateLimited(w, r, base, resourceMock) {
+			return
+		}
+		if _, ok := s.authenticate(w, r, base, resourceMock); !ok {
+			return
+		}
+		s.handleResource(w, r, base, id, resourceMock)
+		return
+	}
+
+	endpoint, err := s.findMockResponse(r.URL.Path)
 	if err != nil {
 		s.logger.Error("Mock response not found",
 			zap.String("path", r.URL.Path),
 			zap.Error(err),
 		)
+		s.markUnmatched(r)
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	setOutcome(r, r.URL.Path, outcomeMatched)
+	if s.rateLimited(w, r, r.URL.Path, endpoint) {
+		return
+	}
+	claims, authorized := s.authenticate(w, r, r.URL.Path, endpoint)
+	if !authorized {
+		return
+	}
+	if endpoint, authorized = s.forClaims(w, r, r.URL.Path, endpoint, claims); !authorized {
+		return
+	}
+
+	if endpoint.IsWebSocket() {
+		s.handleWebSocket(w, r, endpoint)
+		return
+	}
+
+	if endpoint.IsGraphQL() {
+		s.handleGraphQL(w, r, endpoint)
+		return
+	}
+
+	if endpoint.IsGRPC() {
+		setOutcome(r, "", outcomeUnmatched)
+		http.Error(w, fmt.Sprintf("%s is a gRPC method, call it on the gRPC port", r.URL.Path), http.StatusNotFound)
+		return
+	}
 
-	if err := s.validateMethod(r.Method, mock.Method); err != nil {
+	if err := s.validateMethod(r.Method, endpoint.Method); err != nil {
 		s.logger.Error("Invalid HTTP method",
 			zap.String("path", r.URL.Path),
-			zap.String("expected_method", mock.Method),
+			zap.String("expected_method", endpoint.Method),
 			zap.String("actual_method", r.Method),
 			zap.Error(err),
 		)
+		s.markUnmatched(r)
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	inputBody, err := s.parseRequestBody(r)
+	// XML bodies are matched with XPath rather than compared as JSON
+	var inputBody interface{}
+	var xmlRequest *soap.Request
+	if soap.IsXMLContentType(r.Header.Get("Content-Type")) && r.ContentLength != 0 {
+		xmlRequest, err = soap.ParseRequest(r)
+		if err == nil {
+			inputBody = xmlRequest.Body
+		}
+	} else {
+		inputBody, err = s.parseRequestBody(r)
+	}
 	if err != nil {
 		s.logger.Error("Failed to parse request body",
 			zap.String("path", r.URL.Path),
 			zap.Error(err),
 		)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), bodyErrorStatus(err))
 		return
 	}
 
@@ -50,6 +109,21 @@ func (s *Server) handleMockRequest(w http.ResponseWriter, r *http.Request) {
 		zap.Any("input_body", inputBody),
 	)
 
+	if s.spec != nil {
+		if violations := s.spec.ValidateRequest(r, inputBody); len(violations) > 0 {
+			s.logger.Warn("Request does not match OpenAPI schema",
+				zap.String("path", r.URL.Path),
+				zap.Int("violation_count", len(violations)),
+			)
+			s.writeJSONResponse(w, http.StatusBadRequest, ValidationErrorResponse{
+				Status:     "error",
+				Message:    "Request does not match OpenAPI schema",
+				Violations: violations,
+			})
+			return
+		}
+	}
+
 	// Get desired status code from header
 	desiredStatus := 0
 	if statusHeader := r.Header.Get("x-stub-status"); statusHeader != "" {
@@ -61,15 +135,81 @@ func (s *Server) handleMockRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	response := s.findMatchingResponse(mock, inputBody, desiredStatus)
+	candidates := endpoint.ForProtocol(r.Proto).ForHeaders(r.Header).ForScenarios(s.scenarioState)
+	var response *mock.ResponseConfig
+	if desiredStatus == 0 {
+		if forced, ok := s.forcedResponse(r.URL.Path, endpoint); ok {
+			response = forced
+			setOutcome(r, "", outcomeForced)
+		}
+	}
+	if response == nil && xmlRequest != nil && desiredStatus == 0 {
+		response = candidates.FindXMLResponse(xmlRequest)
+	}
+	if response == nil {
+		var fallback bool
+		response, fallback = s.findMatchingResponse(candidates, inputBody, desiredStatus)
+		if fallback {
+			setOutcome(r, "", outcomeFallback)
+		}
+	}
 	if response == nil {
 		s.logger.Error("No matching response found",
 			zap.String("path", r.URL.Path),
 			zap.Any("input_body", inputBody),
 		)
+		s.markUnmatched(r)
+		// Requests no response applies to, such as ones lacking the headers
+		// every response requires, are answered as if the mock did not exist
+		if len(candidates.Responses) == 0 {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "No matching response found", http.StatusInternalServerError)
 		return
 	}
+	s.advanceScenario(response)
+	setResponse(r, response)
+
+	if response.IsGenerated() {
+		body, err := s.generateBody(r, response)
+		if err != nil {
+			s.logger.Error("Failed to generate response body",
+				zap.String("path", r.URL.Path),
+				zap.Error(err),
+			)
+			http.Error(w, "Failed to generate response body", http.StatusInternalServerError)
+			return
+		}
+		generated := *response
+		generated.Body = body
+		response = &generated
+	}
+
+	if response.Pagination != nil && r.Method == http.MethodGet {
+		body, headers, err := response.Pagination.Paginate(response.Body, r.URL.Query(), requestURL(r))
+		if err != nil {
+			s.logger.Error("Failed to paginate response",
+				zap.String("path", r.URL.Path),
+				zap.Error(err),
+			)
+			status := http.StatusInternalServerError
+			if errors.Is(err, mock.ErrInvalidPageQuery) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		for key := range headers {
+			w.Header().Set(key, headers.Get(key))
+		}
+		paginated := *response
+		paginated.Body = body
+		response = &paginated
+	}
+
+	// Responses may echo the claims of the credentials
+	response = response.WithClaims(claims)
 
 	s.logger.Debug("Found matching response",
 		zap.String("path", r.URL.Path),
@@ -77,7 +217,24 @@ func (s *Server) handleMockRequest(w http.ResponseWriter, r *http.Request) {
 		zap.Any("response_body", response.Body),
 	)
 
-	s.writeJSONResponse(w, response.Status, response.Body)
+	response, ok := s.selectRepresentation(w, r, response)
+	if !ok {
+		return
+	}
+	w, finish := s.compress(w, r, response)
+	defer finish()
+
+	if response.Stream != nil {
+		s.writeStreamResponse(w, r, response)
+		return
+	}
+
+	if response.XML != nil {
+		s.writeXMLResponse(w, response)
+		return
+	}
+
+	s.writeMockResponse(w, response)
 }
 
 // handleEndpointsList returns a list of all available endpoints
@@ -106,7 +263,7 @@ func (s *Server) handleEndpointsList(w http.ResponseWriter, r *http.Request) {
 // Helper functions
 
 func (s *Server) findMockResponse(path string) (*mock.Response, error) {
-	mock, exists := s.responses[path]
+	mock, exists := s.mocks()[path]
 	if !exists {
 		return nil, fmt.Errorf("Not Found")
 	}
@@ -125,7 +282,16 @@ func (s *Server) parseRequestBody(r *http.Request) (interface{}, error) {
 		return nil, nil
 	}
 
-	body, err := io.ReadAll(r.Body)
+	// Multipart bodies are streamed so uploaded files are never buffered
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return parseMultipartBody(r.Body, params["boundary"], s.maxBodySize())
+	}
+
+	body, err := readLimited(r.Body, s.maxBodySize())
+	if errors.Is(err, errBodyTooLarge) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Invalid request body")
 	}
@@ -136,6 +302,11 @@ func (s *Server) parseRequestBody(r *http.Request) (interface{}, error) {
 		return nil, nil
 	}
 
+	// Forms are matched as objects with one entry per field
+	if mediaType == "application/x-www-form-urlencoded" {
+		return parseFormBody(body)
+	}
+
 	var inputBody interface{}
 	if err := json.Unmarshal(body, &inputBody); err != nil {
 		return nil, fmt.Errorf("Invalid JSON in request body")
@@ -144,12 +315,32 @@ func (s *Server) parseRequestBody(r *http.Request) (interface{}, error) {
 	return inputBody, nil
 }
 
-func (s *Server) findMatchingResponse(mock *mock.Response, inputBody interface{}, desiredStatus int) *mock.ResponseConfig {
+// maxBodySize returns the limit on buffered request bodies
+func (s *Server) maxBodySize() int64 {
+	if s.maxBody > 0 {
+		return s.maxBody
+	}
+	return DefaultMaxBodySize
+}
+
+// bodyErrorStatus answers 413 for bodies over the limit and 400 for any
+// other unreadable body
+func bodyErrorStatus(err error) int {
+	if errors.Is(err, errBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// findMatchingResponse picks the response for the request body, preferring
+// the desired status when one is given. It reports whether a default response
+// was used because no input body matched.
+func (s *Server) findMatchingResponse(mock *mock.Response, inputBody interface{}, desiredStatus int) (*mock.ResponseConfig, bool) {
 	// If desired status is specified, try to find a response with that status first
 	if desiredStatus > 0 {
 		for _, resp := range mock.Responses {
 			if resp.Status == desiredStatus {
-				return &resp
+				return &resp, false
 			}
 		}
 		// If no response with desired status found, log a warning
@@ -161,13 +352,107 @@ func (s *Server) findMatchingResponse(mock *mock.Response, inputBody interface{}
 	// Fall back to normal matching logic
 	response := mock.FindResponse(inputBody)
 	if response == nil && len(mock.Responses) > 0 {
-		return &mock.Responses[len(mock.Responses)-1]
+		return &mock.Responses[len(mock.Responses)-1], true
+	}
+	return response, isFallback(mock, inputBody)
+}
+
+// isFallback reports whether FindResponse answers a body with the endpoint's
+// default response because none of its input bodies matched
+func isFallback(endpoint *mock.Response, inputBody interface{}) bool {
+	if endpoint.Method == http.MethodGet || inputBody == nil {
+		return false
+	}
+	for _, resp := range endpoint.Responses {
+		if resp.InputBody != nil {
+			return endpoint.MatchInput(inputBody) == nil
+		}
+	}
+	return false
+}
+
+// generateBody produces fake data for a schema-driven response. The
+// x-stub-seed header makes the output reproducible for a single request.
+func (s *Server) generateBody(r *http.Request, response *mock.ResponseConfig) (interface{}, error) {
+	if seedHeader := r.Header.Get("x-stub-seed"); seedHeader != "" {
+		if seed, err := strconv.ParseInt(seedHeader, 10, 64); err == nil {
+			return response.GenerateBody(rand.New(rand.NewSource(seed)))
+		}
+		s.logger.Warn("Ignoring invalid seed header",
+			zap.String("x-stub-seed", seedHeader),
+		)
+	}
+
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return response.GenerateBody(s.rng)
+}
+
+// writeMockResponse writes a configured response including its headers.
+// String bodies with a non-JSON Content-Type header are written verbatim.
+func (s *Server) writeMockResponse(w http.ResponseWriter, response *mock.ResponseConfig) {
+	for key, value := range response.Headers {
+		w.Header().Set(key, value)
+	}
+
+	if text, ok := response.Body.(string); ok {
+		if contentType := w.Header().Get("Content-Type"); contentType != "" && !isJSONContentType(contentType) {
+			w.WriteHeader(response.Status)
+			if _, err := io.WriteString(w, text); err != nil {
+				s.logger.Error("Failed to write response", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	s.writeJSONResponse(w, response.Status, response.Body)
+}
+
+// writeXMLResponse writes a string body as XML, wrapped in a SOAP envelope
+// when the response is a SOAP message. Faults replace the body and default to
+// status 500.
+func (s *Server) writeXMLResponse(w http.ResponseWriter, response *mock.ResponseConfig) {
+	for key, value := range response.Headers {
+		w.Header().Set(key, value)
+	}
+
+	body, _ := response.Body.(string)
+	status := response.Status
+	version := response.XML.Version()
+	if fault := response.XML.Fault; fault != nil {
+		body = soap.Fault(version, fault.Code, fault.Message, fault.Detail)
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		if version != "" {
+			w.Header().Set("Content-Type", soap.ContentType(version))
+		} else {
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		}
+	}
+	if version != "" {
+		body = soap.Envelope(version, body)
+	}
+
+	w.WriteHeader(status)
+	if _, err := io.WriteString(w, body); err != nil {
+		s.logger.Error("Failed to write response", zap.Error(err))
 	}
-	return response
 }
 
 func (s *Server) writeJSONResponse(w http.ResponseWriter, status int, body interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(body); err != nil {
 		s.logger.Error("Failed to encode response",
@@ -177,11 +462,27 @@ func (s *Server) writeJSONResponse(w http.ResponseWriter, status int, body inter
 	}
 }
 
+// requestURL returns the absolute URL the client used for the request
+func requestURL(r *http.Request) *url.URL {
+	u := *r.URL
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	u.Host = r.Host
+	return &u
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
 func (s *Server) buildEndpointsList(host string) map[string]EndpointInfo {
 	endpoints := make(map[string]EndpointInfo)
 
 	// Add mock endpoints
-	for path, mock := range s.responses {
+	for path, mock := range s.mocks() {
 		endpoints[path] = s.buildEndpointInfo(path, mock)
 	}
 
@@ -195,6 +496,36 @@ func (s *Server) buildEndpointsList(host string) map[string]EndpointInfo {
 		},
 	}
 
+	// Add admin and metrics endpoints
+	admins := map[string]string{
+		admin.HealthPath:                "GET",
+		admin.ReadyPath:                 "GET",
+		admin.InfoPath:                  "GET",
+		admin.ResetPath:                 "POST",
+		admin.RequestsPath:              "GET, DELETE",
+		admin.RequestCountPath:          "GET",
+		admin.RequestStreamPath:         "GET",
+		admin.DashboardPath:             "GET",
+		admin.ForcedPath:                "GET, PUT, DELETE",
+		admin.MocksPath:                 "GET, POST, DELETE",
+		admin.ScenariosPath:             "GET, DELETE",
+		admin.ScenariosPath + "/{name}": "PUT",
+		admin.ReloadPath:                "POST",
+	}
+	if s.metrics != nil {
+		admins[s.metricsPath] = "GET"
+	}
+	for path, method := range admins {
+		endpoints[s.reserved(path)] = EndpointInfo{
+			Method: method,
+			Responses: []ResponseInfo{
+				{
+					Status: http.StatusOK,
+				},
+			},
+		}
+	}
+
 	return endpoints
 }
 
@@ -202,14 +533,59 @@ func (s *Server) buildEndpointInfo(path string, mock mock.Response) EndpointInfo
 	responses := make([]ResponseInfo, len(mock.Responses))
 	for i, resp := range mock.Responses {
 		responses[i] = ResponseInfo{
-			Status:    resp.Status,
-			InputBody: resp.InputBody,
-			Body:      resp.Body,
+			Status:       resp.Status,
+			Headers:      resp.Headers,
+			InputBody:    resp.InputBody,
+			InputHeaders: resp.InputHeaders,
+			Body:         resp.Body,
+			Schema:       resp.Schema,
+			Fake:         resp.Fake,
+			Protocol:     resp.Protocol,
+			Stream:       resp.Stream,
+			GraphQL:      resp.GraphQL,
+			GRPC:         resp.GRPC,
+			XML:          resp.XML,
+
+			Representations: resp.Representations,
+			Encodings:       resp.Encodings,
+		}
+	}
+
+	if mock.IsResource() {
+		return EndpointInfo{
+			Method:    "GET, POST, PUT, PATCH, DELETE",
+			Type:      mock.Type,
+			Responses: responses,
+		}
+	}
+
+	if mock.IsGraphQL() {
+		return EndpointInfo{
+			Method:    "GET, POST",
+			Type:      mock.Type,
+			Responses: responses,
+		}
+	}
+
+	if mock.IsGRPC() {
+		return EndpointInfo{
+			Method:    "gRPC",
+			Type:      mock.Type,
+			Responses: responses,
+		}
+	}
+
+	if mock.IsWebSocket() {
+		return EndpointInfo{
+			Method:    "GET",
+			Type:      mock.Type,
+			Responses: responses,
 		}
 	}
 
 	return EndpointInfo{
 		Method:    mock.Method,
+		Type:      mock.Type,
 		Responses: responses,
 	}
 }
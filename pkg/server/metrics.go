@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"google.golang.org/grpc/codes"
+)
+
+// Match outcomes of a request, used as the outcome label of request metrics
+const (
+	// outcomeMatched means a mock response matched the request
+	outcomeMatched = "matched"
+
+	// outcomeFallback means no input body matched the request and the
+	// endpoint's default response was served instead
+	outcomeFallback = "fallback"
+
+	// outcomeForced means the response was forced through the admin API
+	outcomeForced = "forced"
+
+	// outcomeRateLimited means the endpoint's rate limit was exceeded
+	outcomeRateLimited = "rate_limited"
+
+	// outcomeUnauthorized means the request lacked valid credentials and
+	// outcomeForbidden that its credentials lacked the required claims
+	outcomeUnauthorized = "unauthorized"
+	outcomeForbidden    = "forbidden"
+
+	// outcomeUnmatched means no mock answered the request
+	outcomeUnmatched = "unmatched"
+
+	// outcomeInternal means the request was served by the server itself,
+	// such as the admin API or the endpoints list
+	outcomeInternal = "internal"
+)
+
+// endpointNone labels requests that matched no mocked endpoint, so unknown
+// paths don't create new series
+const endpointNone = "none"
+
+// metrics holds the Prometheus collectors of a server. Every server has its
+// own registry so several servers can run in one process.
+type metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	reloads         *prometheus.CounterVec
+
+	// gRPC calls are counted apart since their status is a gRPC code
+	grpcRequests        *prometheus.CounterVec
+	grpcRequestDuration *prometheus.HistogramVec
+}
+
+func newMetrics(s *Server) *metrics {
+	labels := []string{"method", "endpoint", "status", "outcome"}
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gomock_http_requests_total",
+			Help: "HTTP requests served, by method, matched endpoint, status and match outcome.",
+		}, labels),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gomock_http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, including configured delays.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gomock_grpc_requests_total",
+			Help: "gRPC calls served, by method, matched endpoint, status code and match outcome.",
+		}, labels),
+		grpcRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gomock_grpc_request_duration_seconds",
+			Help:    "Time taken to serve gRPC calls, including configured delays.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gomock_reloads_total",
+			Help: "Mock reloads, by result.",
+		}, []string{"result"}),
+	}
+	endpoints := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "gomock_endpoints_loaded",
+		Help: "Mocked endpoints currently served.",
+	}, func() float64 {
+		return float64(len(s.mocks()))
+	})
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.reloads,
+		m.grpcRequests,
+		m.grpcRequestDuration,
+		endpoints,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	// Reload results are exported as zero before the first reload
+	m.reloads.WithLabelValues("success")
+	m.reloads.WithLabelValues("failure")
+	return m
+}
+
+// handler serves the metrics in the Prometheus text format
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observe records a served request
+func (m *metrics) observe(r *http.Request, status int, info *requestInfo, seconds float64) {
+	endpoint, outcome := info.endpoint, info.outcome
+	if outcome == "" {
+		// Requests not answered by handleMockRequest are served by the
+		// server's own routes
+		endpoint, outcome = r.Pattern, outcomeInternal
+		if endpoint == "" || endpoint == "/" {
+			endpoint, outcome = endpointNone, outcomeUnmatched
+		}
+	}
+	if endpoint == "" {
+		endpoint = endpointNone
+	}
+
+	labels := prometheus.Labels{
+		"method":   metricMethod(r.Method),
+		"endpoint": endpoint,
+		"status":   strconv.Itoa(status),
+		"outcome":  outcome,
+	}
+	m.requests.With(labels).Inc()
+	m.requestDuration.With(labels).Observe(seconds)
+}
+
+// observeGRPC records a served gRPC call. Calls that ended before a response
+// was selected, such as those cancelled by the client, count as unmatched.
+func (m *metrics) observeGRPC(method string, code codes.Code, info *requestInfo, seconds float64) {
+	endpoint, outcome := info.endpoint, info.outcome
+	if endpoint == "" {
+		endpoint = endpointNone
+	}
+	if outcome == "" {
+		outcome = outcomeUnmatched
+	}
+
+	labels := prometheus.Labels{
+		"method":   method,
+		"endpoint": endpoint,
+		"status":   code.String(),
+		"outcome":  outcome,
+	}
+	m.grpcRequests.With(labels).Inc()
+	m.grpcRequestDuration.With(labels).Observe(seconds)
+}
+
+// recordReload counts a reload by result
+func (s *Server) recordReload(ok bool) {
+	if s.metrics == nil {
+		return
+	}
+	result := "success"
+	if !ok {
+		result = "failure"
+	}
+	s.metrics.reloads.WithLabelValues(result).Inc()
+}
+
+// metricMethod bounds the method label to the standard methods
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// requestInfo collects what the handlers learn about a request for request
+// metrics and traces
+type requestInfo struct {
+	endpoint string
+	outcome  string
+	response *mock.ResponseConfig
+}
+
+// requestInfoKey is the context key holding the requestInfo of a request
+type requestInfoKey struct{}
+
+// withRequestInfo attaches an empty requestInfo to the request
+func withRequestInfo(r *http.Request) (*http.Request, *requestInfo) {
+	info := &requestInfo{}
+	return r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)), info
+}
+
+// setOutcome records the mocked endpoint that handled the request and how
+// its response was selected. An empty endpoint keeps the one already set.
+func setOutcome(r *http.Request, endpoint, outcome string) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		if endpoint != "" {
+			info.endpoint = endpoint
+		}
+		info.outcome = outcome
+	}
+}
@@ -1,16 +1,34 @@
 package server
 
+import (
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/openapi"
+)
+
 // EndpointInfo represents the structure of endpoint information
 type EndpointInfo struct {
 	Method    string         `json:"method"`
+	Type      string         `json:"type,omitempty"`
 	Responses []ResponseInfo `json:"responses"`
 }
 
 // ResponseInfo represents the structure of response information
 type ResponseInfo struct {
-	Status    int         `json:"status"`
-	InputBody interface{} `json:"input_body,omitempty"`
-	Body      interface{} `json:"response_body,omitempty"`
+	Status       int                   `json:"status"`
+	Headers      map[string]string     `json:"headers,omitempty"`
+	InputBody    interface{}           `json:"input_body,omitempty"`
+	InputHeaders map[string]string     `json:"input_headers,omitempty"`
+	Body         interface{}           `json:"response_body,omitempty"`
+	Schema       interface{}           `json:"schema,omitempty"`
+	Fake         interface{}           `json:"fake,omitempty"`
+	Protocol     string                `json:"protocol,omitempty"`
+	Stream       *mock.StreamConfig    `json:"stream,omitempty"`
+	GraphQL      *mock.GraphQLResponse `json:"graphql,omitempty"`
+	GRPC         *mock.GRPCResponse    `json:"grpc,omitempty"`
+	XML          *mock.XMLResponse     `json:"xml,omitempty"`
+
+	Representations []mock.Representation `json:"representations,omitempty"`
+	Encodings       []string              `json:"encodings,omitempty"`
 }
 
 // EndpointsResponse represents the response structure for the /endpoints route
@@ -18,3 +36,17 @@ type EndpointsResponse struct {
 	Status    string                  `json:"status"`
 	Endpoints map[string]EndpointInfo `json:"endpoints"`
 }
+
+// ValidationErrorResponse represents the response returned when a request
+// violates the bound OpenAPI document
+type ValidationErrorResponse struct {
+	Status     string              `json:"status"`
+	Message    string              `json:"message"`
+	Violations []openapi.Violation `json:"violations"`
+}
+
+// GraphQLEnvelope is the data/errors envelope returned by GraphQL endpoints
+type GraphQLEnvelope struct {
+	Data   interface{}   `json:"data"`
+	Errors []interface{} `json:"errors,omitempty"`
+}
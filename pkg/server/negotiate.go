@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/negotiate"
+	"go.uber.org/zap"
+)
+
+// selectRepresentation picks the representation of a response that fits the
+// Accept header and returns the response with its body and Content-Type. A
+// 406 is written when none fits.
+func (s *Server) selectRepresentation(w http.ResponseWriter, r *http.Request, response *mock.ResponseConfig) (*mock.ResponseConfig, bool) {
+	if len(response.Representations) == 0 {
+		return response, true
+	}
+	w.Header().Add("Vary", "Accept")
+
+	types := response.ContentTypes()
+	index, ok := negotiate.ContentType(r.Header.Get("Accept"), types)
+	if !ok {
+		s.logger.Warn("No acceptable representation",
+			zap.String("path", r.URL.Path),
+			zap.String("accept", r.Header.Get("Accept")),
+		)
+		s.writeResourceError(w, http.StatusNotAcceptable,
+			fmt.Sprintf("None of the available representations is acceptable: %s", strings.Join(types, ", ")))
+		return nil, false
+	}
+
+	rep := response.Representations[index]
+	selected := *response
+	selected.Headers = make(map[string]string, len(response.Headers)+1)
+	for key, value := range response.Headers {
+		selected.Headers[key] = value
+	}
+	selected.Headers["Content-Type"] = rep.ContentType
+	selected.Body = rep.Body
+	if rep.BodyBase64 != "" {
+		// Validated at load time
+		content, _ := rep.Content()
+		selected.Body = string(content)
+	}
+	return &selected, true
+}
+
+// compressResponseWriter compresses everything written to the response
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoder negotiate.Writer
+}
+
+// compress wraps w so the response is compressed with one of the response's
+// encodings accepted by the client. The returned function finishes the
+// compressed stream and must be called once the response is written.
+func (s *Server) compress(w http.ResponseWriter, r *http.Request, response *mock.ResponseConfig) (http.ResponseWriter, func()) {
+	if len(response.Encodings) == 0 || response.Status == http.StatusNoContent || response.Status == http.StatusNotModified {
+		return w, func() {}
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := negotiate.Encoding(r.Header.Get("Accept-Encoding"), response.Encodings)
+	if encoding == "" {
+		return w, func() {}
+	}
+	encoder, err := negotiate.NewWriter(w, encoding)
+	if err != nil {
+		s.logger.Error("Failed to create compressor", zap.Error(err))
+		return w, func() {}
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Del("Content-Length")
+	return &compressResponseWriter{ResponseWriter: w, encoder: encoder}, func() {
+		if err := encoder.Close(); err != nil {
+			s.logger.Debug("Failed to finish compressed response", zap.Error(err))
+		}
+	}
+}
+
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	return cw.encoder.Write(b)
+}
+
+// Flush pushes the compressed data written so far to the client so streamed
+// responses stay incremental
+func (cw *compressResponseWriter) Flush() {
+	cw.encoder.Flush()
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
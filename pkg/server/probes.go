@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/admin"
+	"github.com/sachin-duhan/gomock/pkg/version"
+	"go.uber.org/zap"
+)
+
+// reserved returns one of the admin package routes under the server's prefix
+func (s *Server) reserved(path string) string {
+	return admin.Reserved(s.prefix, path)
+}
+
+// probeMethod rejects probe requests other than GET and HEAD
+func (s *Server) probeMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	s.logger.Error("Invalid method for probe",
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	)
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+// handleHealth answers liveness probes for as long as the server can serve
+// requests
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if !s.probeMethod(w, r) {
+		return
+	}
+	s.writeJSONResponse(w, http.StatusOK, admin.HealthResponse{Status: "ok"})
+}
+
+// handleReady answers readiness probes. The server is ready once its mocks
+// are loaded, stops being ready when a reload fails until one succeeds, and
+// is not ready while shutting down so load balancers stop sending traffic.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if !s.probeMethod(w, r) {
+		return
+	}
+
+	s.responsesMu.RLock()
+	resp := admin.ReadyResponse{Status: "ok", Ready: true, Endpoints: len(s.responses)}
+	if s.loadErr != nil {
+		resp.Error = "reload failed: " + s.loadErr.Error()
+	}
+	s.responsesMu.RUnlock()
+	select {
+	case <-s.shutdownCh:
+		resp.Error = "shutting down"
+	default:
+	}
+
+	status := http.StatusOK
+	if resp.Error != "" {
+		resp.Status, resp.Ready = "unavailable", false
+		status = http.StatusServiceUnavailable
+	}
+	s.writeJSONResponse(w, status, resp)
+}
+
+// handleInfo describes the build, the mocks loaded and the features enabled
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if !s.probeMethod(w, r) {
+		return
+	}
+
+	s.responsesMu.RLock()
+	files, endpoints := s.files, len(s.responses)
+	s.responsesMu.RUnlock()
+
+	v, commit := version.Get()
+	info := admin.InfoResponse{
+		Version:   v,
+		Commit:    commit,
+		Files:     files,
+		Endpoints: endpoints,
+		StartedAt: s.started,
+		Uptime:    time.Since(s.started).Round(time.Second).String(),
+		Config: admin.ConfigSummary{
+			TLSPort:        s.tlsPort,
+			GRPCPort:       s.grpcPort,
+			ReservedPrefix: s.reserved(admin.DefaultPrefix),
+			MetricsPath:    s.metricsPath,
+			OpenAPI:        s.spec != nil,
+			Reload:         s.reload != nil,
+			Tracing:        s.tracer != nil,
+			BodyLogging:    s.redactor != nil,
+		},
+	}
+	if !s.disableHTTP {
+		info.Config.Port = s.port
+	}
+	s.writeJSONResponse(w, http.StatusOK, info)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// rateLimiter returns the limiter of the endpoint at path, creating it on
+// first use
+func (s *Server) rateLimiter(path string, config *mock.RateLimitConfig) ratelimit.Limiter {
+	s.limitersMu.Lock()
+	defer s.limitersMu.Unlock()
+
+	if s.limiters == nil {
+		s.limiters = make(map[string]ratelimit.Limiter)
+	}
+	limiter, ok := s.limiters[path]
+	if !ok {
+		if config.Algorithm == mock.RateLimitFixedWindow {
+			limiter = ratelimit.NewFixedWindow(config.Limit, config.Window())
+		} else {
+			limiter = ratelimit.NewTokenBucket(config.Limit, config.Window())
+		}
+		s.limiters[path] = limiter
+	}
+	return limiter
+}
+
+// dropLimiter discards the limiter of an endpoint whose mock changed so its
+// new limit starts afresh
+func (s *Server) dropLimiter(path string) {
+	s.limitersMu.Lock()
+	defer s.limitersMu.Unlock()
+	delete(s.limiters, path)
+}
+
+// rateLimited counts the request against the rate limit of the endpoint at
+// path and sets the X-RateLimit-* headers. Over the limit, it answers with
+// the endpoint's limit response and reports that the request was handled.
+func (s *Server) rateLimited(w http.ResponseWriter, r *http.Request, path string, endpoint *mock.Response) bool {
+	config := endpoint.RateLimit
+	if config == nil {
+		return false
+	}
+
+	key := config.ClientKey(r)
+	result := s.rateLimiter(path, config).Allow(key)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	if result.Allowed {
+		return false
+	}
+
+	s.logger.Warn("Rate limit exceeded",
+		zap.String("path", path),
+		zap.String("key", key),
+		zap.Duration("retry_after", result.RetryAfter),
+	)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+	setOutcome(r, "", outcomeRateLimited)
+
+	response := mock.ResponseConfig{
+		Status: http.StatusTooManyRequests,
+		Body:   map[string]interface{}{"error": "Too Many Requests"},
+	}
+	if config.Response != nil {
+		response = *config.Response
+		if response.Status == 0 {
+			response.Status = http.StatusTooManyRequests
+		}
+	}
+	setResponse(r, &response)
+	s.writeMockResponse(w, &response)
+	return true
+}
+
+// seconds rounds a duration up to whole seconds, as used by Retry-After
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/resource"
+	"go.uber.org/zap"
+)
+
+// findResource returns the resource endpoint serving the path along with its
+// base path and the item id, which is empty for the collection itself
+func (s *Server) findResource(path string) (*mock.Response, string, string, bool) {
+	responses := s.mocks()
+	if m, ok := responses[path]; ok && m.IsResource() {
+		return &m, path, "", true
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return nil, "", "", false
+	}
+	base := path[:i]
+	m, ok := responses[base]
+	if !ok || !m.IsResource() {
+		return nil, "", "", false
+	}
+	id, err := url.PathUnescape(path[i+1:])
+	if err != nil {
+		return nil, "", "", false
+	}
+	return &m, base, id, true
+}
+
+// resourceStore returns the store backing a resource endpoint, creating it
+// from the seed data on first use
+func (s *Server) resourceStore(base string, m *mock.Response) *resource.Store {
+	s.storesMu.Lock()
+	defer s.storesMu.Unlock()
+
+	if s.stores == nil {
+		s.stores = make(map[string]*resource.Store)
+	}
+	store, ok := s.stores[base]
+	if !ok {
+		var idField string
+		var data []map[string]interface{}
+		if m.Resource != nil {
+			idField = m.Resource.IDField
+			data = m.Resource.Data
+		}
+		store = resource.NewStore(idField, data)
+		s.stores[base] = store
+	}
+	return store
+}
+
+// dropStore discards the store of a resource whose mock changed so it is
+// recreated from the new seed data
+func (s *Server) dropStore(base string) {
+	s.storesMu.Lock()
+	defer s.storesMu.Unlock()
+	delete(s.stores, base)
+}
+
+// resetResources restores resource stores to their seed data. An empty base
+// resets every resource and the reset base paths are returned.
+func (s *Server) resetResources(base string) ([]string, bool) {
+	var reset []string
+	for path, m := range s.mocks() {
+		if !m.IsResource() || base != "" && path != base {
+			continue
+		}
+		m := m
+		s.resourceStore(path, &m).Reset()
+		reset = append(reset, path)
+	}
+	sort.Strings(reset)
+	return reset, base == "" || len(reset) > 0
+}
+
+// handleResource serves list/get/create/update/patch/delete for a resource
+func (s *Server) handleResource(w http.ResponseWriter, r *http.Request, base, id string, m *mock.Response) {
+	store := s.resourceStore(base, m)
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.listResource(w, r, store)
+		case http.MethodPost:
+			s.createResource(w, r, base, store)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			s.writeResourceError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		item, ok := store.Get(id)
+		if !ok {
+			s.writeResourceError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.writeJSONResponse(w, http.StatusOK, item)
+	case http.MethodPut, http.MethodPatch:
+		body, ok := s.parseResourceBody(w, r)
+		if !ok {
+			return
+		}
+		var item map[string]interface{}
+		if r.Method == http.MethodPut {
+			item, ok = store.Replace(id, body)
+		} else {
+			item, ok = store.Patch(id, body)
+		}
+		if !ok {
+			s.writeResourceError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.writeJSONResponse(w, http.StatusOK, item)
+	case http.MethodDelete:
+		if !store.Delete(id) {
+			s.writeResourceError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, PATCH, DELETE")
+		s.writeResourceError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (s *Server) listResource(w http.ResponseWriter, r *http.Request, store *resource.Store) {
+	query, err := resource.ParseQuery(r.URL.Query())
+	if err != nil {
+		s.writeResourceError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, total := store.List(query)
+	s.logger.Debug("Resource listed",
+		zap.String("path", r.URL.Path),
+		zap.Int("returned", len(items)),
+		zap.Int("total", total),
+	)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	s.writeJSONResponse(w, http.StatusOK, items)
+}
+
+func (s *Server) createResource(w http.ResponseWriter, r *http.Request, base string, store *resource.Store) {
+	body, ok := s.parseResourceBody(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := store.Create(body)
+	if errors.Is(err, resource.ErrConflict) {
+		s.writeResourceError(w, http.StatusConflict, "Item already exists")
+		return
+	}
+
+	w.Header().Set("Location", base+"/"+url.PathEscape(resource.FormatID(item[store.IDField()])))
+	s.writeJSONResponse(w, http.StatusCreated, item)
+}
+
+// parseResourceBody reads a JSON object from the request body, writing a 400
+// response when the body is missing or not an object and a 413 response when
+// it is too large
+func (s *Server) parseResourceBody(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	body, err := s.parseRequestBody(r)
+	if err != nil {
+		s.writeResourceError(w, bodyErrorStatus(err), err.Error())
+		return nil, false
+	}
+	obj, ok := body.(map[string]interface{})
+	if !ok {
+		s.writeResourceError(w, http.StatusBadRequest, "Request body must be a JSON object")
+		return nil, false
+	}
+	return obj, true
+}
+
+func (s *Server) writeResourceError(w http.ResponseWriter, status int, message string) {
+	s.writeJSONResponse(w, status, map[string]string{"error": message})
+}
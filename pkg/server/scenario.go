@@ -0,0 +1,74 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.uber.org/zap"
+)
+
+// scenarioState returns the current state of a scenario
+func (s *Server) scenarioState(name string) string {
+	s.scenariosMu.Lock()
+	defer s.scenariosMu.Unlock()
+	if state, ok := s.scenarios[name]; ok {
+		return state
+	}
+	return mock.ScenarioStarted
+}
+
+// setScenarioState moves a scenario to a new state
+func (s *Server) setScenarioState(name, state string) {
+	s.scenariosMu.Lock()
+	defer s.scenariosMu.Unlock()
+	if s.scenarios == nil {
+		s.scenarios = make(map[string]string)
+	}
+	s.scenarios[name] = state
+}
+
+// advanceScenario applies the state transition of a served response
+func (s *Server) advanceScenario(response *mock.ResponseConfig) {
+	if response.Scenario == nil || response.Scenario.SetState == "" {
+		return
+	}
+	s.setScenarioState(response.Scenario.Name, response.Scenario.SetState)
+	s.logger.Debug("Scenario state changed",
+		zap.String("scenario", response.Scenario.Name),
+		zap.String("state", response.Scenario.SetState),
+	)
+}
+
+// scenarioStates returns the state of every scenario known to the mocks or
+// moved through the admin API
+func (s *Server) scenarioStates() map[string]string {
+	states := make(map[string]string)
+	for _, m := range s.mocks() {
+		for _, name := range m.Scenarios() {
+			states[name] = mock.ScenarioStarted
+		}
+	}
+
+	s.scenariosMu.Lock()
+	defer s.scenariosMu.Unlock()
+	for name, state := range s.scenarios {
+		states[name] = state
+	}
+	return states
+}
+
+// resetScenarios moves every scenario back to its initial state and returns
+// the names of the scenarios known before the reset
+func (s *Server) resetScenarios() []string {
+	states := s.scenarioStates()
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.scenariosMu.Lock()
+	s.scenarios = nil
+	s.scenariosMu.Unlock()
+	return names
+}
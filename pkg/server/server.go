@@ -1,146 +1,723 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/sachin-duhan/gomock/pkg/admin"
+	"github.com/sachin-duhan/gomock/pkg/dashboard"
+	"github.com/sachin-duhan/gomock/pkg/grpcmock"
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/logging"
 	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/openapi"
+	"github.com/sachin-duhan/gomock/pkg/ratelimit"
+	"github.com/sachin-duhan/gomock/pkg/resource"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
 // Server represents the mock server
 type Server struct {
-	responses map[string]mock.Response
-	port      string
-	logger    *zap.Logger
-	server    *http.Server
+	// responses is replaced, never modified, when mocks change at runtime
+	responses   map[string]mock.Response
+	responsesMu sync.RWMutex
+
+	port   string
+	logger *zap.Logger
+	server *http.Server
+	spec   *openapi.Spec
+
+	// tlsServer serves HTTPS alongside (or instead of) plain HTTP
+	tlsServer   *http.Server
+	tlsPort     string
+	tlsConfig   *tls.Config
+	disableHTTP bool
+	serversMu   sync.Mutex
+
+	// grpcServer serves gRPC mocks on their own port
+	grpcServer   *grpc.Server
+	grpcPort     string
+	grpcRegistry *grpcmock.Registry
+
+	// rng generates fake data for schema-driven responses
+	rng   *rand.Rand
+	rngMu sync.Mutex
+
+	// stores back resource endpoints, keyed by base path
+	stores   map[string]*resource.Store
+	storesMu sync.Mutex
+
+	// limiters count requests against endpoint rate limits, keyed by path
+	limiters   map[string]ratelimit.Limiter
+	limitersMu sync.Mutex
+
+	// journal records served requests for inspection through the admin API
+	journal *journal.Journal
+
+	// scenarios holds the state of scenarios that left their initial state
+	scenarios   map[string]string
+	scenariosMu sync.Mutex
+
+	// metrics are served in the Prometheus format on metricsPath when set
+	metrics     *metrics
+	metricsPath string
+
+	// reload loads a fresh set of mocks for the admin reload route
+	reload func() (map[string]mock.Response, error)
+
+	// files counts the mocks of the last successful load and loadErr holds
+	// the error of a failed reload, which keeps the server not ready until
+	// a reload succeeds. Both are guarded by responsesMu.
+	files   int
+	loadErr error
+
+	// forced maps paths to the index of the response forced through the
+	// admin API, guarded by responsesMu
+	forced map[string]int
+
+	// prefix replaces admin.DefaultPrefix in the reserved routes
+	prefix  string
+	started time.Time
+
+	// shutdownCh is closed when Stop begins so WebSocket sessions, which
+	// http.Server.Shutdown does not track, can close; sessions counts them
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
+	sessions     sync.WaitGroup
+
+	// maxBody limits buffered request bodies, DefaultMaxBodySize when zero
+	maxBody int64
+
+	// redactor logs request and response bodies when body logging is enabled
+	redactor *logging.Redactor
+
+	// redaction redacts journal entries, following redactor when not set
+	redaction *logging.Redactor
+
+	// tracer records a span per request when tracing is enabled
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// Option configures optional server behaviour
+type Option func(*Server)
+
+// WithOpenAPI binds the mock set to an OpenAPI document so incoming requests
+// are validated against the documented parameters and body schemas
+func WithOpenAPI(spec *openapi.Spec) Option {
+	return func(s *Server) {
+		s.spec = spec
+	}
+}
+
+// WithFakeSeed seeds the generator used for schema-driven responses so the
+// sequence of generated bodies is reproducible across runs
+func WithFakeSeed(seed int64) Option {
+	return func(s *Server) {
+		s.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithTLS serves HTTPS on the given port using the TLS config. Plain HTTP
+// keeps running on the main port unless WithoutHTTP is also given.
+func WithTLS(port string, config *tls.Config) Option {
+	return func(s *Server) {
+		s.tlsPort = port
+		s.tlsConfig = config
+	}
+}
+
+// WithoutHTTP disables the plain HTTP listener so only HTTPS is served
+func WithoutHTTP() Option {
+	return func(s *Server) {
+		s.disableHTTP = true
+	}
+}
+
+// WithGRPC serves the gRPC mocks on the given port, using the registry to
+// decode requests and encode responses
+func WithGRPC(port string, registry *grpcmock.Registry) Option {
+	return func(s *Server) {
+		s.grpcPort = port
+		s.grpcRegistry = registry
+	}
+}
+
+// WithResponses adds mocks on top of the ones given to New, typically mocks
+// built in code with mock.On next to mocks loaded from a folder. See
+// mock.Merge for how endpoints defined in both are combined.
+func WithResponses(responses map[string]mock.Response) Option {
+	return func(s *Server) {
+		s.responses = mock.Merge(s.responses, responses)
+	}
+}
+
+// WithLogger logs through the given logger instead of the default one, which
+// writes to the console and to a file under LOG_PATH
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithMetrics serves Prometheus metrics about the requests served on path,
+// such as /metrics
+func WithMetrics(path string) Option {
+	return func(s *Server) {
+		s.metricsPath = path
+	}
+}
+
+// WithReload lets the admin API replace the mocks with the ones returned by
+// load, typically by reading the mocks folder again
+func WithReload(load func() (map[string]mock.Response, error)) Option {
+	return func(s *Server) {
+		s.reload = load
+	}
+}
+
+// WithPrefix serves the admin API, probes and info route under prefix
+// instead of admin.DefaultPrefix, for mock sets that use paths starting with
+// /__. See admin.Reserved for how the prefix is applied.
+func WithPrefix(prefix string) Option {
+	return func(s *Server) {
+		s.prefix = prefix
+	}
+}
+
+// WithMaxBodySize limits JSON and form request bodies, and multipart field
+// values, to limit bytes instead of DefaultMaxBodySize. Larger bodies are
+// answered with 413. Uploaded files are streamed and not limited.
+func WithMaxBodySize(limit int64) Option {
+	return func(s *Server) {
+		s.maxBody = limit
+	}
+}
+
+// WithBodyLogging logs request and response headers and bodies, with
+// sensitive values redacted
+func WithBodyLogging(opts logging.BodyOptions) Option {
+	return func(s *Server) {
+		s.redactor = logging.NewRedactor(opts)
+	}
+}
+
+// WithJournalLimit keeps at most limit requests in the journal instead of
+// journal.DefaultLimit. journal.Unlimited keeps every request.
+func WithJournalLimit(limit int) Option {
+	return func(s *Server) {
+		s.journal = journal.New(limit)
+	}
+}
+
+// WithRedaction redacts sensitive headers and JSON fields from the request
+// journal. Without it the journal follows the body logging rules, or redacts
+// logging.DefaultRedactHeaders when body logging is disabled.
+func WithRedaction(opts logging.BodyOptions) Option {
+	return func(s *Server) {
+		s.redaction = logging.NewRedactor(opts)
+	}
 }
 
 // New creates a new mock server instance
-func New(responses map[string]mock.Response, port string) (*Server, error) {
-	logger, err := initLogger()
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize logger: %v", err)
+func New(responses map[string]mock.Response, port string, opts ...Option) (*Server, error) {
+	s := &Server{
+		responses:  responses,
+		files:      len(responses),
+		port:       port,
+		prefix:     admin.DefaultPrefix,
+		started:    time.Now(),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		journal:    journal.New(journal.DefaultLimit),
+		shutdownCh: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if s.metricsPath != "" {
+		s.metrics = newMetrics(s)
+	}
+
+	if s.logger == nil {
+		logger, err := initLogger()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize logger: %v", err)
+		}
+		s.logger = logger
+	}
+
+	// Routes of the server take precedence over mocks of the same path
+	prefix := s.reserved(admin.DefaultPrefix)
+	for path := range s.responses {
+		if strings.HasPrefix(path, prefix) {
+			s.logger.Warn("Mock path uses the reserved prefix and may be shadowed by the server's own routes",
+				zap.String("path", path),
+				zap.String("prefix", prefix),
+			)
+		}
+	}
+	return s, nil
+}
+
+// mocks returns the current mocks. The map must not be modified.
+func (s *Server) mocks() map[string]mock.Response {
+	s.responsesMu.RLock()
+	defer s.responsesMu.RUnlock()
+	return s.responses
+}
+
+// setMock adds or replaces the mock of a path
+func (s *Server) setMock(path string, m mock.Response) {
+	s.responsesMu.Lock()
+	defer s.responsesMu.Unlock()
+
+	responses := make(map[string]mock.Response, len(s.responses)+1)
+	for p, existing := range s.responses {
+		responses[p] = existing
+	}
+	responses[path] = m
+	s.responses = responses
+	delete(s.forced, path)
+	s.dropStore(path)
+	s.dropLimiter(path)
+}
+
+// removeMock removes the mock of a path and reports whether it existed
+func (s *Server) removeMock(path string) bool {
+	s.responsesMu.Lock()
+	defer s.responsesMu.Unlock()
+
+	if _, ok := s.responses[path]; !ok {
+		return false
+	}
+	responses := make(map[string]mock.Response, len(s.responses))
+	for p, existing := range s.responses {
+		if p != path {
+			responses[p] = existing
+		}
+	}
+	s.responses = responses
+	delete(s.forced, path)
+	s.dropStore(path)
+	s.dropLimiter(path)
+	return true
+}
+
+// replaceMocks serves a new set of mocks, discarding every resource store
+// and rate limit count
+func (s *Server) replaceMocks(responses map[string]mock.Response) {
+	s.responsesMu.Lock()
+	defer s.responsesMu.Unlock()
+
+	s.responses = responses
+	s.files = len(responses)
+	s.loadErr = nil
+	s.forced = nil
+	s.storesMu.Lock()
+	s.stores = nil
+	s.storesMu.Unlock()
+	s.limitersMu.Lock()
+	s.limiters = nil
+	s.limitersMu.Unlock()
+}
 
-	return &Server{
-		responses: responses,
-		port:      port,
-		logger:    logger,
-	}, nil
+// Handler returns the HTTP handler serving the mocks and the admin API, for
+// embedding the server in another listener such as an httptest.Server
+func (s *Server) Handler() http.Handler {
+	return s.routes()
+}
+
+// Journal returns the journal of the requests served
+func (s *Server) Journal() *journal.Journal {
+	return s.journal
 }
 
 // Start starts the mock server
 func (s *Server) Start() error {
-	// Set up server routes
-	mux := http.NewServeMux()
-	mux.HandleFunc("/endpoints", s.handleEndpointsList)
-	mux.HandleFunc("/", s.handleMockRequest)
+	handler := s.routes()
 
-	// Create HTTP server
-	s.server = &http.Server{
-		Addr:    ":" + s.port,
-		Handler: s.logMiddleware(mux),
+	if s.disableHTTP && s.tlsConfig == nil {
+		return fmt.Errorf("HTTP is disabled and no TLS listener is configured")
 	}
 
-	// Start the server
-	s.logger.Info("Starting mock server", zap.String("port", s.port))
-	return s.server.ListenAndServe()
-}
+	// Bind every port before serving any, so a port that is taken leaves
+	// nothing running
+	var httpListener, tlsListener, grpcListener net.Listener
+	var bound []net.Listener
+	listen := func(name, port string) (net.Listener, error) {
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			for _, l := range bound {
+				l.Close()
+			}
+			return nil, fmt.Errorf("failed to listen for %s: %v", name, err)
+		}
+		bound = append(bound, listener)
+		return listener, nil
+	}
+	var err error
+	if !s.disableHTTP {
+		if httpListener, err = listen("HTTP", s.port); err != nil {
+			return err
+		}
+	}
+	if s.tlsConfig != nil {
+		if tlsListener, err = listen("HTTPS", s.tlsPort); err != nil {
+			return err
+		}
+	}
+	if s.grpcRegistry != nil {
+		if grpcListener, err = listen("gRPC", s.grpcPort); err != nil {
+			return err
+		}
+	}
 
-// Stop gracefully shuts down the server
-func (s *Server) Stop(ctx context.Context) error {
-	if s.server != nil {
-		s.logger.Info("Shutting down server")
-		return s.server.Shutdown(ctx)
+	// Listeners run side by side; the first one to fail or stop ends Start
+	errCh := make(chan error, 3)
+
+	s.serversMu.Lock()
+	if httpListener != nil {
+		// Create HTTP server
+		s.server = &http.Server{
+			Addr:      ":" + s.port,
+			Handler:   handler,
+			Protocols: serverProtocols(),
+		}
+		s.logger.Info("Starting mock server", zap.String("port", s.port))
+		go func(srv *http.Server) {
+			errCh <- srv.Serve(httpListener)
+		}(s.server)
+	}
+	if tlsListener != nil {
+		// Create HTTPS server
+		s.tlsServer = &http.Server{
+			Addr:      ":" + s.tlsPort,
+			Handler:   handler,
+			TLSConfig: s.tlsConfig,
+			Protocols: serverProtocols(),
+		}
+		s.logger.Info("Starting mock server with TLS",
+			zap.String("port", s.tlsPort),
+			zap.String("client_auth", s.tlsConfig.ClientAuth.String()),
+		)
+		go func(srv *http.Server) {
+			errCh <- srv.ServeTLS(tlsListener, "", "")
+		}(s.tlsServer)
+	}
+	if grpcListener != nil {
+		s.grpcServer = s.newGRPCServer()
+		s.logger.Info("Starting gRPC mock server", zap.String("port", s.grpcPort))
+		go func(srv *grpc.Server) {
+			if err := srv.Serve(grpcListener); err != nil {
+				errCh <- err
+				return
+			}
+			errCh <- http.ErrServerClosed
+		}(s.grpcServer)
+	}
+	s.serversMu.Unlock()
+
+	err = <-errCh
+	if !errors.Is(err, http.ErrServerClosed) {
+		// One listener failed; don't leave the others serving
+		s.closeServers()
 	}
-	return nil
+	return err
 }
 
-// initLogger initializes the zap logger based on environment variables
-func initLogger() (*zap.Logger, error) {
-	// Get log path from environment variable, default to "logs" directory
-	logPath := os.Getenv("LOG_PATH")
-	if logPath == "" {
-		logPath = "logs"
+// closeServers stops every listener at once, dropping open connections
+func (s *Server) closeServers() {
+	s.serversMu.Lock()
+	servers := []*http.Server{s.server, s.tlsServer}
+	grpcServer := s.grpcServer
+	s.serversMu.Unlock()
+
+	for _, srv := range servers {
+		if srv != nil {
+			srv.Close()
+		}
+	}
+	if grpcServer != nil {
+		grpcServer.Stop()
 	}
+}
 
-	// Create logs directory if it doesn't exist
-	if err := os.MkdirAll(logPath, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %v", err)
+// routes sets up the server routes wrapped in the logging middleware
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/endpoints", s.handleEndpointsList)
+	mux.HandleFunc(s.reserved(admin.HealthPath), s.handleHealth)
+	mux.HandleFunc(s.reserved(admin.ReadyPath), s.handleReady)
+	mux.HandleFunc(s.reserved(admin.InfoPath), s.handleInfo)
+	mux.HandleFunc(s.reserved(admin.ResetPath), s.handleAdminReset)
+	mux.HandleFunc(s.reserved(admin.RequestsPath), s.handleAdminRequests)
+	mux.HandleFunc(s.reserved(admin.RequestCountPath), s.handleAdminRequestCount)
+	mux.HandleFunc(s.reserved(admin.RequestStreamPath), s.handleAdminRequestStream)
+	mux.HandleFunc(s.reserved(admin.MocksPath), s.handleAdminMocks)
+	mux.HandleFunc(s.reserved(admin.ScenariosPath), s.handleAdminScenarios)
+	mux.HandleFunc(s.reserved(admin.ScenariosPath+"/{name}"), s.handleAdminScenario)
+	mux.HandleFunc(s.reserved(admin.ReloadPath), s.handleAdminReload)
+	mux.HandleFunc(s.reserved(admin.ForcedPath), s.handleAdminForced)
+	dashboardPath := s.reserved(admin.DashboardPath)
+	mux.Handle(dashboardPath, http.StripPrefix(dashboardPath, dashboard.Handler()))
+	if s.metrics != nil {
+		mux.Handle(s.metricsPath, s.metrics.handler())
 	}
+	mux.HandleFunc("/", s.handleMockRequest)
+	return s.logMiddleware(mux)
+}
+
+// serverProtocols enables HTTP/1.1 and HTTP/2 on every listener. HTTP/2 is
+// negotiated through ALPN over TLS and spoken with prior knowledge (h2c) on
+// the plain HTTP listener.
+func serverProtocols() *http.Protocols {
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return protocols
+}
 
-	// Configure encoder
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.TimeKey = "timestamp"
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+// Stop gracefully shuts down the server. In-flight requests, streams and
+// gRPC calls are drained and WebSocket clients are sent a going-away close
+// frame. Connections still open when ctx expires are closed and ctx's error
+// is returned.
+func (s *Server) Stop(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		if s.shutdownCh != nil {
+			close(s.shutdownCh)
+		}
+	})
+
+	s.serversMu.Lock()
+	servers := []*http.Server{s.server, s.tlsServer}
+	grpcServer := s.grpcServer
+	s.serversMu.Unlock()
 
-	// Create file core
-	logFile := filepath.Join(logPath, "mock-server.log")
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %v", err)
+	var firstErr error
+	if grpcServer != nil {
+		s.logger.Info("Shutting down gRPC server", zap.String("port", s.grpcPort))
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+			firstErr = ctx.Err()
+		}
 	}
 
-	fileCore := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(file),
-		zap.InfoLevel,
-	)
+	for _, srv := range servers {
+		if srv == nil {
+			continue
+		}
+		s.logger.Info("Shutting down server", zap.String("addr", srv.Addr))
+		if err := srv.Shutdown(ctx); err != nil {
+			// Drop the connections that did not finish in time
+			srv.Close()
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
 
-	// Create console core for development
-	consoleCore := zapcore.NewCore(
-		zapcore.NewConsoleEncoder(encoderConfig),
-		zapcore.AddSync(os.Stdout),
-		zap.InfoLevel,
-	)
+	// WebSocket connections are hijacked, so Shutdown does not wait for them
+	sessionsDone := make(chan struct{})
+	go func() {
+		s.sessions.Wait()
+		close(sessionsDone)
+	}()
+	select {
+	case <-sessionsDone:
+	case <-ctx.Done():
+		if firstErr == nil {
+			firstErr = ctx.Err()
+		}
+	}
+	return firstErr
+}
 
-	// Combine cores
-	core := zapcore.NewTee(fileCore, consoleCore)
+// initLogger creates the default logger, writing INFO and above to the
+// console and to mock-server.log under LOG_PATH
+func initLogger() (*zap.Logger, error) {
+	// Get log path from environment variable, default to "logs" directory
+	logPath := os.Getenv("LOG_PATH")
+	if logPath == "" {
+		logPath = "logs"
+	}
 
-	// Create logger
-	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
-	return logger, nil
+	return logging.New(logging.Options{
+		Outputs: []string{logging.OutputFile, logging.OutputStdout},
+		File:    filepath.Join(logPath, "mock-server.log"),
+	})
 }
 
-// logMiddleware logs incoming requests and their responses
+// logMiddleware logs incoming requests and their responses and records them
+// in the request journal
 func (s *Server) logMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Create a response wrapper to capture the status code
 		rw := newResponseWriter(w)
+		r, info := withRequestInfo(r)
+		var span trace.Span
+		if s.tracer != nil {
+			r, span = s.startSpan(rw, r)
+		}
 
 		// Log request details
-		s.logger.Info("Incoming request",
+		fields := []zap.Field{
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
+			zap.String("protocol", r.Proto),
 			zap.String("remote_addr", r.RemoteAddr),
 			zap.String("user_agent", r.UserAgent()),
-		)
+		}
+		if s.redactor != nil {
+			body := captureBody(r, s.redactor.MaxBytes()+1)
+			fields = append(fields,
+				zap.Any("headers", s.redactor.Headers(r.Header)),
+				zap.String("request_body", s.logBody(body)),
+			)
+			rw.capture(s.redactor.MaxBytes() + 1)
+		}
+		s.logger.Info("Incoming request", fields...)
+
+		// Admin calls and probes are not journaled so inspecting the journal
+		// doesn't change it
+		var entryID int64
+		if s.journal != nil && !strings.HasPrefix(r.URL.Path, s.reserved(admin.DefaultPrefix)) {
+			entryID = s.journal.Record(journal.Entry{
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Query:      r.URL.RawQuery,
+				Protocol:   r.Proto,
+				RemoteAddr: r.RemoteAddr,
+				Headers:    s.journalRedactor().RedactHeader(r.Header),
+				Body:       string(s.journalRedactor().RedactBody(captureBody(r, maxJournalBody))),
+			})
+			r = r.WithContext(context.WithValue(r.Context(), journalEntryKey{}, entryID))
+		}
 
 		// Process the request
 		start := time.Now()
 		next.ServeHTTP(rw, r)
 		duration := time.Since(start)
 
+		if entryID != 0 {
+			s.journal.SetMatch(entryID, info.endpoint, info.outcome, describeResponse(info.response))
+			s.journal.Complete(entryID, rw.status, duration)
+		}
+		if s.metrics != nil {
+			s.metrics.observe(r, rw.status, info, duration.Seconds())
+		}
+		if span != nil {
+			s.endSpan(span, r, rw.status, info)
+		}
+
 		// Log response details
-		s.logger.Info("Request completed",
+		fields = []zap.Field{
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
+			zap.String("protocol", r.Proto),
 			zap.Int("status", rw.status),
 			zap.Duration("duration", duration),
-		)
+		}
+		if s.redactor != nil {
+			fields = append(fields,
+				zap.Any("response_headers", s.redactor.Headers(rw.Header())),
+				zap.String("response_body", s.logBody(rw.body)),
+			)
+		}
+		s.logger.Info("Request completed", fields...)
 	})
 }
 
-// responseWriter wraps http.ResponseWriter to capture the status code
+// markUnmatched flags the request in the journal as not answered by a mock
+func (s *Server) markUnmatched(r *http.Request) {
+	setOutcome(r, "", outcomeUnmatched)
+	if id := journalEntryID(r); id != 0 {
+		s.journal.MarkUnmatched(id)
+	}
+}
+
+// journalEntryKey is the context key holding the journal entry id of a request
+type journalEntryKey struct{}
+
+// journalEntryID returns the journal entry id of the request, or zero when it
+// is not journaled
+func journalEntryID(r *http.Request) int64 {
+	id, _ := r.Context().Value(journalEntryKey{}).(int64)
+	return id
+}
+
+// maxJournalBody limits how much of a request body is kept in the journal
+const maxJournalBody = 64 << 10
+
+// defaultRedactor redacts journal entries when body logging is disabled
+var defaultRedactor = logging.NewRedactor(logging.BodyOptions{})
+
+// journalRedactor returns the redaction rules of journal entries
+func (s *Server) journalRedactor() *logging.Redactor {
+	if s.redaction != nil {
+		return s.redaction
+	}
+	if s.redactor != nil {
+		return s.redactor
+	}
+	return defaultRedactor
+}
+
+// captureBody returns up to limit bytes from the start of the request body
+// and leaves the body intact for the handlers
+func captureBody(r *http.Request, limit int) []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	captured, _ := io.ReadAll(io.LimitReader(r.Body, int64(limit)))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), r.Body), r.Body}
+	return captured
+}
+
+// logBody prepares a body captured with one byte over the log limit for
+// logging, so bodies over the limit are marked as truncated
+func (s *Server) logBody(body []byte) string {
+	truncated := len(body) > s.redactor.MaxBytes()
+	if truncated {
+		body = body[:s.redactor.MaxBytes()]
+	}
+	return s.redactor.Body(body, truncated)
+}
+
+// responseWriter wraps http.ResponseWriter to capture the status code and,
+// for body logging, the start of the body
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+
+	body  []byte
+	limit int
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
@@ -148,6 +725,43 @@ func newResponseWriter(w http.ResponseWriter) *responseWriter {
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
-	rw.status = code
+	if rw.status == 0 {
+		rw.status = code
+	}
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if room := rw.limit - len(rw.body); room > 0 {
+		rw.body = append(rw.body, b[:min(room, len(b))]...)
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// capture keeps up to limit bytes of the body written
+func (rw *responseWriter) capture(limit int) {
+	rw.limit = limit
+}
+
+// Flush lets streaming handlers flush through the wrapper
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket handlers take over the connection
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection does not support hijacking")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
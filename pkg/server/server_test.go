@@ -1,14 +1,49 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/gorilla/websocket"
+	"github.com/sachin-duhan/gomock/pkg/admin"
+	"github.com/sachin-duhan/gomock/pkg/grpcmock"
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/logging"
 	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/openapi"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func setupTestServer(t *testing.T) *Server {
@@ -318,3 +353,1768 @@ func TestHandleEndpointsList(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
 	}
 }
+
+func TestHandleMockRequestOpenAPIValidation(t *testing.T) {
+	server := setupTestServer(t)
+
+	spec, err := openapi.Parse([]byte(`{
+		"openapi": "3.0.3",
+		"paths": {
+			"/create-user": {
+				"post": {
+					"requestBody": {
+						"required": true,
+						"content": {
+							"application/json": {
+								"schema": {
+									"type": "object",
+									"required": ["name", "email"],
+									"properties": {
+										"name": {"type": "string"},
+										"email": {"type": "string", "format": "email"}
+									}
+								}
+							}
+						}
+					},
+					"responses": {"201": {"description": "Created"}}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+	WithOpenAPI(spec)(server)
+
+	// A valid request reaches the mock
+	body, _ := json.Marshal(map[string]interface{}{"name": "Test User", "email": "test@example.com"})
+	req, _ := http.NewRequest("POST", "/create-user", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	// An invalid request gets a structured 400
+	body, _ = json.Marshal(map[string]interface{}{"name": "Test User", "email": "invalid"})
+	req, _ = http.NewRequest("POST", "/create-user", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	var response ValidationErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if response.Status != "error" || len(response.Violations) != 1 {
+		t.Fatalf("Unexpected validation response: %+v", response)
+	}
+	if v := response.Violations[0]; v.In != "body" || v.Path != "/email" {
+		t.Errorf("Unexpected violation: %+v", v)
+	}
+}
+
+func TestHandleMockRequestHeaders(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/report"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status:  200,
+				Headers: map[string]string{"Content-Type": "text/csv", "X-Report-Id": "42"},
+				Body:    "id,name\n1,Test User\n",
+			},
+		},
+	}
+	server.responses["/problem"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status:  400,
+				Headers: map[string]string{"Content-Type": "application/problem+json"},
+				Body:    map[string]interface{}{"title": "Bad Request"},
+			},
+		},
+	}
+
+	req, _ := http.NewRequest("GET", "/report", nil)
+	rr := httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+
+	if rr.Header().Get("X-Report-Id") != "42" || rr.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("Expected configured headers, got %v", rr.Header())
+	}
+	if rr.Body.String() != "id,name\n1,Test User\n" {
+		t.Errorf("Expected text body to be written verbatim, got %q", rr.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/problem", nil)
+	rr = httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+
+	if rr.Code != 400 || rr.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Expected JSON content type to be preserved, got %d %v", rr.Code, rr.Header())
+	}
+	if rr.Body.String() != "{\"title\":\"Bad Request\"}\n" {
+		t.Errorf("Unexpected body %q", rr.Body.String())
+	}
+}
+
+func TestHandleMockRequestInputHeaders(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/admin/users"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status:       200,
+				InputHeaders: map[string]string{"Authorization": "Bearer admin"},
+				Body:         []interface{}{},
+			},
+		},
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/users", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	rr := httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the required header, got %d", rr.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/users", nil)
+	rr = httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without the required header, got %d", rr.Code)
+	}
+}
+
+func TestHandleMockRequestScenarioState(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/orders/1"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status:   200,
+				Scenario: &mock.ScenarioConfig{Name: "checkout", State: "Done"},
+				Body:     map[string]interface{}{"status": "paid"},
+			},
+		},
+	}
+
+	req, _ := http.NewRequest("GET", "/orders/1", nil)
+	rr := httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before the scenario reaches its state, got %d", rr.Code)
+	}
+
+	server.setScenarioState("checkout", "Done")
+	rr = httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 once the scenario reaches its state, got %d", rr.Code)
+	}
+}
+
+func TestHandleMockRequestGeneratedBody(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/people"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status: 200,
+				Fake:   map[string]interface{}{"people": []interface{}{map[string]interface{}{"id": "uuid", "email": "email"}, 3.0, 3.0}},
+			},
+		},
+	}
+
+	fetch := func(seed string) string {
+		req, _ := http.NewRequest("GET", "/people", nil)
+		if seed != "" {
+			req.Header.Set("x-stub-seed", seed)
+		}
+		rr := httptest.NewRecorder()
+		server.handleMockRequest(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		return rr.Body.String()
+	}
+
+	first := fetch("123")
+	if first != fetch("123") {
+		t.Error("Expected identical bodies for the same seed header")
+	}
+	if first == fetch("456") {
+		t.Error("Expected different bodies for different seeds")
+	}
+
+	var body struct {
+		People []map[string]string `json:"people"`
+	}
+	if err := json.Unmarshal([]byte(fetch("")), &body); err != nil {
+		t.Fatalf("Failed to parse generated body: %v", err)
+	}
+	if len(body.People) != 3 || !strings.Contains(body.People[0]["email"], "@") {
+		t.Errorf("Unexpected generated body: %+v", body)
+	}
+
+	// Configured responses are not modified by generation
+	if server.responses["/people"].Responses[0].Body != nil {
+		t.Error("Expected the configured body to stay empty")
+	}
+}
+
+func TestHandleResource(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/api/books"] = mock.Response{
+		Type: mock.TypeResource,
+		Resource: &mock.ResourceConfig{
+			Data: []map[string]interface{}{
+				{"id": float64(1), "title": "Dune", "year": float64(1965)},
+				{"id": float64(2), "title": "Neuromancer", "year": float64(1984)},
+			},
+		},
+	}
+
+	do := func(method, target string, body interface{}) *httptest.ResponseRecorder {
+		var req *http.Request
+		if body != nil {
+			data, _ := json.Marshal(body)
+			req, _ = http.NewRequest(method, target, bytes.NewBuffer(data))
+		} else {
+			req, _ = http.NewRequest(method, target, nil)
+		}
+		rr := httptest.NewRecorder()
+		server.handleMockRequest(rr, req)
+		return rr
+	}
+
+	rr := do("GET", "/api/books?_sort=year&_order=desc&_limit=1", nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Total-Count") != "2" {
+		t.Fatalf("Unexpected list response: %d %v", rr.Code, rr.Header())
+	}
+	var list []map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if len(list) != 1 || list[0]["title"] != "Neuromancer" {
+		t.Errorf("Unexpected list body: %v", list)
+	}
+
+	rr = do("POST", "/api/books", map[string]interface{}{"title": "Hyperion"})
+	if rr.Code != http.StatusCreated || rr.Header().Get("Location") != "/api/books/3" {
+		t.Fatalf("Unexpected create response: %d %v", rr.Code, rr.Header())
+	}
+
+	if rr = do("PATCH", "/api/books/3", map[string]interface{}{"year": 1989}); rr.Code != http.StatusOK {
+		t.Errorf("Expected patch to succeed, got %d", rr.Code)
+	}
+	rr = do("GET", "/api/books/3", nil)
+	var book map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &book)
+	if book["title"] != "Hyperion" || book["year"] != float64(1989) {
+		t.Errorf("Unexpected item: %v", book)
+	}
+
+	if rr = do("PUT", "/api/books/3", map[string]interface{}{"title": "Endymion"}); rr.Code != http.StatusOK {
+		t.Errorf("Expected replace to succeed, got %d", rr.Code)
+	}
+	if rr = do("DELETE", "/api/books/1", nil); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected delete to succeed, got %d", rr.Code)
+	}
+
+	for _, tc := range []struct {
+		method, target string
+		body           interface{}
+		status         int
+	}{
+		{"GET", "/api/books/1", nil, http.StatusNotFound},
+		{"PATCH", "/api/books/1", map[string]interface{}{}, http.StatusNotFound},
+		{"DELETE", "/api/books/1", nil, http.StatusNotFound},
+		{"POST", "/api/books", []interface{}{1}, http.StatusBadRequest},
+		{"POST", "/api/books", map[string]interface{}{"id": 2}, http.StatusConflict},
+		{"DELETE", "/api/books", nil, http.StatusMethodNotAllowed},
+		{"GET", "/api/books?_page=0", nil, http.StatusBadRequest},
+	} {
+		if rr = do(tc.method, tc.target, tc.body); rr.Code != tc.status {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.target, tc.status, rr.Code)
+		}
+	}
+
+	// The admin API restores the seed data
+	req, _ := http.NewRequest("POST", "/__admin/reset?path=/api/books", nil)
+	rr = httptest.NewRecorder()
+	server.handleAdminReset(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected reset to succeed, got %d", rr.Code)
+	}
+	if rr = do("GET", "/api/books", nil); rr.Header().Get("X-Total-Count") != "2" {
+		t.Errorf("Expected seed data after reset, got %s", rr.Body.String())
+	}
+	if rr = do("GET", "/api/books/1", nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected deleted item to be restored, got %d", rr.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/__admin/reset?path=/missing", nil)
+	rr = httptest.NewRecorder()
+	server.handleAdminReset(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown resource, got %d", rr.Code)
+	}
+}
+
+func TestHandleMockRequestPagination(t *testing.T) {
+	server := setupTestServer(t)
+	items := make([]interface{}, 30)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i + 1}
+	}
+	server.responses["/items"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status:     200,
+				Body:       items,
+				Pagination: &mock.PaginationConfig{Style: mock.PaginationPage, PageSize: 10, LinkHeader: true},
+			},
+		},
+	}
+
+	req, _ := http.NewRequest("GET", "/items?page=3", nil)
+	req.Host = "localhost:8080"
+	rr := httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+
+	var page []map[string]int
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if len(page) != 10 || page[0]["id"] != 21 {
+		t.Errorf("Expected items 21-30, got %v", page)
+	}
+	if !strings.Contains(rr.Header().Get("Link"), `<http://localhost:8080/items?page=2&per_page=10>; rel="prev"`) {
+		t.Errorf("Unexpected Link header: %s", rr.Header().Get("Link"))
+	}
+
+	req, _ = http.NewRequest("GET", "/items?page=nope", nil)
+	rr = httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid page, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestHandleMockRequestProtocol(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/proto"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{Status: 200, Body: map[string]interface{}{"protocol": "http1"}},
+			{Status: 200, Body: map[string]interface{}{"protocol": "http2"}, Protocol: "HTTP/2"},
+		},
+	}
+
+	ts := httptest.NewUnstartedServer(server.routes())
+	ts.Config.Protocols = serverProtocols()
+	ts.Start()
+	defer ts.Close()
+
+	get := func(protocols *http.Protocols) (string, string) {
+		client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+		resp, err := client.Get(ts.URL + "/proto")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to parse response body: %v", err)
+		}
+		return resp.Proto, body["protocol"]
+	}
+
+	proto, body := get(nil)
+	if proto != "HTTP/1.1" || body != "http1" {
+		t.Errorf("Expected HTTP/1.1 response, got %s with %q", proto, body)
+	}
+
+	// h2c with prior knowledge
+	h2c := &http.Protocols{}
+	h2c.SetUnencryptedHTTP2(true)
+	proto, body = get(h2c)
+	if proto != "HTTP/2.0" || body != "http2" {
+		t.Errorf("Expected HTTP/2.0 response, got %s with %q", proto, body)
+	}
+
+	// A response pinned to HTTP/2 is not served over HTTP/1.1
+	server.responses["/h2-only"] = mock.Response{
+		Method:    "GET",
+		Responses: []mock.ResponseConfig{{Status: 200, Protocol: "HTTP/2"}},
+	}
+	resp, err := http.Get(ts.URL + "/h2-only")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Proto != "HTTP/1.1" || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 over HTTP/1.1, got %d over %s", resp.StatusCode, resp.Proto)
+	}
+}
+
+func TestHandleWebSocket(t *testing.T) {
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	server.responses["/ws"] = mock.Response{
+		Type: mock.TypeWebSocket,
+		WebSocket: &mock.WebSocketConfig{
+			OnConnect: []mock.ScriptedMessage{
+				{Body: map[string]interface{}{"type": "welcome"}},
+				{Body: "tick", DelayMS: 10, IntervalMS: 10, Repeat: 2},
+			},
+		},
+		Responses: []mock.ResponseConfig{
+			{InputBody: map[string]interface{}{"type": "ping"}, Body: map[string]interface{}{"type": "pong"}},
+			{InputBody: "bye", Body: "see you", Close: &mock.CloseFrame{Code: 4001, Reason: "done"}},
+		},
+	}
+
+	ts := httptest.NewServer(server.routes())
+	defer ts.Close()
+
+	// Plain HTTP requests are told to upgrade
+	resp, err := http.Get(ts.URL + "/ws")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("Expected status %d, got %d", http.StatusUpgradeRequired, resp.StatusCode)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	read := func() string {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		return string(data)
+	}
+
+	if msg := read(); msg != `{"type":"welcome"}` {
+		t.Errorf("Expected welcome message, got %s", msg)
+	}
+	for i := 0; i < 2; i++ {
+		if msg := read(); msg != "tick" {
+			t.Errorf("Expected tick, got %s", msg)
+		}
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "ping"}`))
+	if msg := read(); msg != `{"type":"pong"}` {
+		t.Errorf("Expected pong, got %s", msg)
+	}
+
+	// Unmatched messages get no reply; a matched reply can close the connection
+	conn.WriteMessage(websocket.TextMessage, []byte("hello?"))
+	conn.WriteMessage(websocket.TextMessage, []byte("bye"))
+	if msg := read(); msg != "see you" {
+		t.Errorf("Expected goodbye, got %s", msg)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, 4001) {
+		t.Fatalf("Expected close code 4001, got %v", err)
+	}
+	conn.Close()
+
+	// The conversation is recorded in the journal
+	var entry journal.Entry
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		entries := server.journal.Entries()
+		if last := entries[len(entries)-1]; last.Status == http.StatusSwitchingProtocols {
+			entry = last
+			break
+		}
+	}
+	if entry.Status != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected WebSocket journal entry, got %+v", server.journal.Entries())
+	}
+	var directions []string
+	for _, msg := range entry.Messages {
+		directions = append(directions, msg.Direction+":"+msg.Type)
+	}
+	expected := "out:text out:text out:text in:text out:text in:text in:text out:text out:close"
+	if got := strings.Join(directions, " "); !strings.HasPrefix(got, expected) {
+		t.Errorf("Expected conversation %q, got %q", expected, got)
+	}
+}
+
+func TestWebSocketDelayedClose(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/ws"] = mock.Response{
+		Type:      mock.TypeWebSocket,
+		WebSocket: &mock.WebSocketConfig{},
+		Responses: []mock.ResponseConfig{
+			{InputBody: "bye", Body: "closing soon", Close: &mock.CloseFrame{Code: 4001, AfterMS: 300}},
+			{InputBody: "ping", Body: "pong"},
+		},
+	}
+	ts := httptest.NewServer(server.routes())
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	conn.WriteMessage(websocket.TextMessage, []byte("bye"))
+	conn.WriteMessage(websocket.TextMessage, []byte("ping"))
+	for _, want := range []string{"closing soon", "pong"} {
+		_, data, err := conn.ReadMessage()
+		if err != nil || string(data) != want {
+			t.Fatalf("Expected %q before the delayed close, got %q %v", want, data, err)
+		}
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, 4001) {
+		t.Errorf("Expected close code 4001, got %v", err)
+	}
+}
+
+func TestHandleAdminRequests(t *testing.T) {
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	handler := server.routes()
+
+	req, _ := http.NewRequest("POST", "/create-user", strings.NewReader(`{"name": "Test User"}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/__admin/requests", nil))
+	var response admin.RequestsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if len(response.Requests) != 1 {
+		t.Fatalf("Expected 1 journaled request, got %+v", response.Requests)
+	}
+	entry := response.Requests[0]
+	if entry.Path != "/create-user" || entry.Status != 400 || entry.Body != `{"name": "Test User"}` {
+		t.Errorf("Unexpected journal entry: %+v", entry)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("DELETE", "/__admin/requests", nil))
+	if rr.Code != http.StatusOK || len(server.journal.Entries()) != 0 {
+		t.Errorf("Expected journal to be cleared, got status %d", rr.Code)
+	}
+}
+
+func TestHandleAdminMocksRedactsAuth(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/secure"] = mock.Response{
+		Method: "GET",
+		Auth: &mock.AuthConfig{
+			Type:   mock.AuthBasic,
+			Keys:   []string{"key-1"},
+			Users:  map[string]string{"ada": "lovelace"},
+			Secret: "hmac-secret",
+		},
+		Responses: []mock.ResponseConfig{{Status: 200}},
+	}
+
+	rr := httptest.NewRecorder()
+	server.routes().ServeHTTP(rr, httptest.NewRequest("GET", "/__admin/mocks", nil))
+	for _, secret := range []string{"key-1", "lovelace", "hmac-secret"} {
+		if strings.Contains(rr.Body.String(), secret) {
+			t.Errorf("Expected %s to be redacted, got %s", secret, rr.Body.String())
+		}
+	}
+	var response admin.MocksResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	auth := response.Mocks["/secure"].Auth
+	if auth == nil || auth.Users["ada"] != logging.Redacted || auth.Secret != logging.Redacted || auth.Keys[0] != logging.Redacted {
+		t.Errorf("Expected masked credentials, got %+v", auth)
+	}
+
+	// The served mock keeps its credentials
+	if server.responses["/secure"].Auth.Users["ada"] != "lovelace" {
+		t.Error("Expected the mock's own auth to be unchanged")
+	}
+}
+
+func TestHandleMockRequestStream(t *testing.T) {
+	server := setupTestServer(t)
+	delay := 0
+	server.responses["/events"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status: 200,
+				Stream: &mock.StreamConfig{
+					Type:    mock.StreamSSE,
+					DelayMS: 5,
+					Events: []mock.StreamEvent{
+						{ID: "1", Event: "token", Data: map[string]interface{}{"token": "Hel"}, Retry: 1000},
+						{ID: "2", Event: "token", Data: "lo\nworld", DelayMS: &delay},
+						{Data: "[DONE]"},
+					},
+				},
+			},
+		},
+	}
+	server.responses["/lines"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status: 200,
+				Stream: &mock.StreamConfig{
+					Type:   mock.StreamNDJSON,
+					Chunks: []mock.StreamChunk{{Data: map[string]interface{}{"n": 1}}, {Data: "two"}},
+				},
+			},
+		},
+	}
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+	rr := httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+
+	expected := "id: 1\nevent: token\nretry: 1000\ndata: {\"token\":\"Hel\"}\n\n" +
+		"id: 2\nevent: token\ndata: lo\ndata: world\n\n" +
+		"data: [DONE]\n\n"
+	if rr.Body.String() != expected {
+		t.Errorf("Expected SSE body %q, got %q", expected, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != "text/event-stream" || !rr.Flushed {
+		t.Errorf("Expected flushed event stream, got Content-Type %q", rr.Header().Get("Content-Type"))
+	}
+
+	req, _ = http.NewRequest("GET", "/lines", nil)
+	rr = httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Body.String() != "{\"n\":1}\n\"two\"\n" || rr.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("Unexpected NDJSON response %q", rr.Body.String())
+	}
+}
+
+func TestHandleGraphQL(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/graphql"] = mock.Response{
+		Type: mock.TypeGraphQL,
+		GraphQL: &mock.GraphQLConfig{SDL: `
+			type Query { user(id: ID!): User }
+			type User { id: ID! name: String! }
+		`},
+		Responses: []mock.ResponseConfig{
+			{
+				Status:  200,
+				GraphQL: &mock.GraphQLResponse{OperationName: "GetUser", Variables: map[string]interface{}{"id": "404"}},
+				Body:    map[string]interface{}{"user": nil},
+			},
+			{
+				Status:  200,
+				GraphQL: &mock.GraphQLResponse{Query: "query GetUser($id: ID!) { user(id: $id) { id name } }"},
+				Body:    map[string]interface{}{"user": map[string]interface{}{"id": "1", "name": "Ada"}},
+			},
+			{
+				Status: 200,
+				GraphQL: &mock.GraphQLResponse{Errors: []interface{}{
+					map[string]interface{}{"message": "Not authorized"},
+				}},
+			},
+		},
+	}
+
+	post := func(body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		server.handleMockRequest(rr, req)
+		var envelope map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("Failed to parse response body: %v", err)
+		}
+		return rr.Code, envelope
+	}
+
+	// Normalized query match, regardless of whitespace
+	status, envelope := post(`{"query": "query GetUser($id: ID!) {\n  user(id: $id) {\n    id\n    name\n  }\n}", "variables": {"id": "1"}}`)
+	user, _ := envelope["data"].(map[string]interface{})["user"].(map[string]interface{})
+	if status != http.StatusOK || user["name"] != "Ada" {
+		t.Errorf("Expected user Ada, got %d %v", status, envelope)
+	}
+
+	// Operation name and variables subset match
+	_, envelope = post(`{"query": "query GetUser($id: ID!) { user(id: $id) { id } }", "operationName": "GetUser", "variables": {"id": "404"}}`)
+	if data := envelope["data"].(map[string]interface{}); data["user"] != nil {
+		t.Errorf("Expected null user, got %v", envelope)
+	}
+
+	// Unmatched operations fall back to the response without criteria
+	_, envelope = post(`{"query": "{ user(id: 2) { id } }"}`)
+	if envelope["data"] != nil || len(envelope["errors"].([]interface{})) != 1 {
+		t.Errorf("Expected errors envelope, got %v", envelope)
+	}
+
+	// Schema violations are reported as GraphQL errors
+	status, envelope = post(`{"query": "{ user(id: 1) { email } }"}`)
+	if status != http.StatusOK || envelope["errors"] == nil {
+		t.Errorf("Expected validation errors, got %d %v", status, envelope)
+	}
+
+	// Malformed requests are rejected
+	status, _ = post(`{"variables": {}}`)
+	if status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for missing query, got %d", http.StatusBadRequest, status)
+	}
+}
+
+func TestHandleGRPC(t *testing.T) {
+	dir := t.TempDir()
+	proto := `
+		syntax = "proto3";
+		package greet.v1;
+		service Greeter {
+		  rpc SayHello(HelloRequest) returns (HelloReply);
+		  rpc StreamHellos(HelloRequest) returns (stream HelloReply);
+		}
+		message HelloRequest { string user_name = 1; }
+		message HelloReply { string message = 1; }
+	`
+	if err := ioutil.WriteFile(filepath.Join(dir, "greeter.proto"), []byte(proto), 0644); err != nil {
+		t.Fatalf("Failed to write proto file: %v", err)
+	}
+	registry, err := grpcmock.Load([]string{filepath.Join(dir, "greeter.proto")}, nil)
+	if err != nil {
+		t.Fatalf("Failed to load proto: %v", err)
+	}
+
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	server.metrics = newMetrics(server)
+	server.grpcRegistry = registry
+	server.responses["/greet.v1.Greeter/SayHello"] = mock.Response{
+		Type: mock.TypeGRPC,
+		Path: "/greet.v1.Greeter/SayHello",
+		Responses: []mock.ResponseConfig{
+			{
+				InputBody: map[string]interface{}{"userName": "ghost"},
+				GRPC:      &mock.GRPCResponse{Code: "NOT_FOUND", Message: "no such user"},
+			},
+			{Body: map[string]interface{}{"message": "Hello!"}},
+		},
+	}
+	server.responses["/greet.v1.Greeter/StreamHellos"] = mock.Response{
+		Type: mock.TypeGRPC,
+		Path: "/greet.v1.Greeter/StreamHellos",
+		Responses: []mock.ResponseConfig{
+			{GRPC: &mock.GRPCResponse{
+				Code: "UNAVAILABLE",
+				Stream: []mock.StreamChunk{
+					{Data: map[string]interface{}{"message": "one"}},
+					{Data: map[string]interface{}{"message": "two"}},
+				},
+			}},
+		},
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	srv := server.newGRPCServer()
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	method, _ := registry.Method("/greet.v1.Greeter/SayHello")
+	call := func(ctx context.Context, name string) (interface{}, error) {
+		req, _ := registry.FromJSON(method.Input(), map[string]interface{}{"user_name": name})
+		reply := registry.NewMessage(method.Output())
+		if err := conn.Invoke(ctx, "/greet.v1.Greeter/SayHello", req, reply); err != nil {
+			return nil, err
+		}
+		return registry.ToJSON(reply)
+	}
+
+	ctx := context.Background()
+	reply, err := call(ctx, "ada")
+	if err != nil || reply.(map[string]interface{})["message"] != "Hello!" {
+		t.Errorf("Expected Hello!, got %v %v", reply, err)
+	}
+
+	// Requests matching input_body get its status
+	if _, err := call(ctx, "ghost"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	// x-stub-status metadata selects a response by code
+	stubCtx := metadata.AppendToOutgoingContext(ctx, "x-stub-status", "NOT_FOUND")
+	if _, err := call(stubCtx, "ada"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound from x-stub-status, got %v", err)
+	}
+
+	// Server streams send their messages before the status
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/greet.v1.Greeter/StreamHellos")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	req, _ := registry.FromJSON(method.Input(), map[string]interface{}{"user_name": "ada"})
+	if err := stream.SendMsg(req); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	stream.CloseSend()
+	var messages []interface{}
+	for {
+		reply := registry.NewMessage(method.Output())
+		if err = stream.RecvMsg(reply); err != nil {
+			break
+		}
+		value, _ := registry.ToJSON(reply)
+		messages = append(messages, value.(map[string]interface{})["message"])
+	}
+	if len(messages) != 2 || messages[1] != "two" || status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected two messages then Unavailable, got %v %v", messages, err)
+	}
+
+	// Methods without mocks are unimplemented
+	if err := conn.Invoke(ctx, "/greet.v1.Greeter/Missing", req, registry.NewMessage(method.Output())); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented, got %v", err)
+	}
+
+	// Rate limits apply to gRPC methods too
+	limited := server.responses["/greet.v1.Greeter/SayHello"]
+	limited.RateLimit = &mock.RateLimitConfig{Limit: 1, WindowMS: 60000}
+	server.setMock("/greet.v1.Greeter/SayHello", limited)
+	var header metadata.MD
+	if err := conn.Invoke(ctx, "/greet.v1.Greeter/SayHello", req, registry.NewMessage(method.Output()), grpc.Header(&header)); err != nil || strings.Join(header.Get("x-ratelimit-remaining"), "") != "0" {
+		t.Errorf("Expected the first call to be allowed, got %v %v", header, err)
+	}
+	if err := conn.Invoke(ctx, "/greet.v1.Greeter/SayHello", req, registry.NewMessage(method.Output()), grpc.Header(&header)); status.Code(err) != codes.ResourceExhausted || len(header.Get("retry-after")) != 1 {
+		t.Errorf("Expected ResourceExhausted with retry-after, got %v %v", header, err)
+	}
+
+	// So does auth, with credentials in the metadata
+	secured := server.responses["/greet.v1.Greeter/SayHello"]
+	secured.RateLimit = nil
+	secured.Auth = &mock.AuthConfig{Type: mock.AuthAPIKey, Keys: []string{"key-1"}}
+	secured.Responses = append(secured.Responses, mock.ResponseConfig{
+		InputClaims: map[string]interface{}{"api_key": "key-1"},
+		Body:        map[string]interface{}{"message": "Hello {{claims.api_key}}"},
+	})
+	server.setMock("/greet.v1.Greeter/SayHello", secured)
+	if _, err := call(ctx, "ada"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without credentials, got %v", err)
+	}
+	keyCtx := metadata.AppendToOutgoingContext(ctx, "x-api-key", "key-1")
+	if reply, err := call(keyCtx, "ada"); err != nil || reply.(map[string]interface{})["message"] != "Hello key-1" {
+		t.Errorf("Expected the claim-gated reply, got %v %v", reply, err)
+	}
+
+	entries := server.journal.Entries()
+	if len(entries) == 0 || entries[0].Protocol != "gRPC" || len(entries[0].Messages) != 3 {
+		t.Errorf("Expected journaled gRPC call, got %+v", entries)
+	}
+
+	// Calls are counted in the metrics with their gRPC status
+	rr := httptest.NewRecorder()
+	server.metrics.handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`gomock_grpc_requests_total{endpoint="/greet.v1.Greeter/SayHello",method="/greet.v1.Greeter/SayHello",outcome="matched",status="NotFound"} 2`,
+		`gomock_grpc_requests_total{endpoint="/greet.v1.Greeter/StreamHellos",method="/greet.v1.Greeter/StreamHellos",outcome="matched",status="Unavailable"} 1`,
+		`gomock_grpc_requests_total{endpoint="none",method="OTHER",outcome="unmatched",status="Unimplemented"} 1`,
+		`gomock_grpc_requests_total{endpoint="/greet.v1.Greeter/SayHello",method="/greet.v1.Greeter/SayHello",outcome="rate_limited",status="ResourceExhausted"} 1`,
+		`gomock_grpc_requests_total{endpoint="/greet.v1.Greeter/SayHello",method="/greet.v1.Greeter/SayHello",outcome="unauthorized",status="Unauthenticated"} 1`,
+		`gomock_grpc_request_duration_seconds_count{endpoint="/greet.v1.Greeter/SayHello",method="/greet.v1.Greeter/SayHello",outcome="matched",status="OK"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+}
+
+func TestHandleMockRequestSOAP(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/soap/users"] = mock.Response{
+		Method: "POST",
+		Responses: []mock.ResponseConfig{
+			{
+				XML: &mock.XMLResponse{
+					SOAPAction: "urn:users/GetUser",
+					XPath:      []string{"//u:id = '404'"},
+					Namespaces: map[string]string{"u": "urn:users"},
+					Fault:      &mock.SOAPFault{Code: "Client", Message: "Unknown user"},
+				},
+			},
+			{
+				Status: 200,
+				XML:    &mock.XMLResponse{SOAPAction: "urn:users/GetUser", SOAP: "1.1"},
+				Body:   `<u:GetUserResponse xmlns:u="urn:users"><u:name>Ada</u:name></u:GetUserResponse>`,
+			},
+		},
+	}
+
+	post := func(id string) *httptest.ResponseRecorder {
+		body := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+			`<u:GetUser xmlns:u="urn:users"><u:id>` + id + `</u:id></u:GetUser></soap:Body></soap:Envelope>`
+		req, _ := http.NewRequest("POST", "/soap/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		req.Header.Set("SOAPAction", `"urn:users/GetUser"`)
+		rr := httptest.NewRecorder()
+		server.handleMockRequest(rr, req)
+		return rr
+	}
+
+	rr := post("1")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "<soap:Body><u:GetUserResponse") {
+		t.Errorf("Expected SOAP envelope, got %d %s", rr.Code, rr.Body.String())
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "text/xml; charset=utf-8" {
+		t.Errorf("Expected text/xml content type, got %s", contentType)
+	}
+
+	rr = post("404")
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), "<faultstring>Unknown user</faultstring>") {
+		t.Errorf("Expected SOAP fault, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	req, _ := http.NewRequest("POST", "/soap/users", strings.NewReader("<unclosed>"))
+	req.Header.Set("Content-Type", "application/xml")
+	rr = httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed XML, got %d", rr.Code)
+	}
+}
+
+func TestHandleMockRequestForm(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/login"] = mock.Response{
+		Method: "POST",
+		Responses: []mock.ResponseConfig{
+			{
+				Status:    200,
+				InputBody: map[string]interface{}{"username": "ada", "password": "secret", "scope": []interface{}{"read", "write"}},
+				Body:      map[string]interface{}{"token": "abc"},
+			},
+			{Status: 401, Body: map[string]interface{}{"error": "Invalid credentials"}},
+		},
+	}
+	server.responses["/upload"] = mock.Response{
+		Method: "POST",
+		Responses: []mock.ResponseConfig{
+			{
+				Status: 201,
+				InputBody: map[string]interface{}{
+					"title": "notes",
+					"file": map[string]interface{}{
+						"filename":     "notes.txt",
+						"size":         5,
+						"content_type": "text/plain",
+						"sha256":       "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+					},
+				},
+				Body: map[string]interface{}{"uploaded": true},
+			},
+			{Status: 400, Body: map[string]interface{}{"error": "Unexpected upload"}},
+		},
+	}
+
+	post := func(path, contentType, body string) int {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		server.handleMockRequest(rr, req)
+		return rr.Code
+	}
+
+	form := "application/x-www-form-urlencoded"
+	if status := post("/login", form, "username=ada&password=secret&scope=read&scope=write"); status != http.StatusOK {
+		t.Errorf("Expected form to match, got %d", status)
+	}
+	if status := post("/login", form, "username=ada&password=wrong&scope=read&scope=write"); status != http.StatusUnauthorized {
+		t.Errorf("Expected default response, got %d", status)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("title", "notes")
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="notes.txt"`)
+	header.Set("Content-Type", "text/plain")
+	part, _ := writer.CreatePart(header)
+	part.Write([]byte("hello"))
+	writer.Close()
+	if status := post("/upload", writer.FormDataContentType(), body.String()); status != http.StatusCreated {
+		t.Errorf("Expected multipart upload to match, got %d", status)
+	}
+
+	if status := post("/upload", "multipart/form-data", "--x\r\n"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for multipart body without boundary, got %d", status)
+	}
+
+	server.maxBody = 16
+	if status := post("/login", form, "username=ada&password=secret&scope=read"); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for form body over the limit, got %d", status)
+	}
+	body.Reset()
+	writer = multipart.NewWriter(&body)
+	writer.WriteField("title", strings.Repeat("x", 17))
+	writer.Close()
+	if status := post("/upload", writer.FormDataContentType(), body.String()); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for multipart field over the limit, got %d", status)
+	}
+}
+
+func TestHandleMockRequestLargeUpload(t *testing.T) {
+	server := setupTestServer(t)
+	server.maxBody = 1024
+
+	content := bytes.Repeat([]byte("x"), 1<<20)
+	sum := sha256.Sum256(content)
+	server.responses["/upload"] = mock.Response{
+		Method: "POST",
+		Responses: []mock.ResponseConfig{
+			{
+				Status: 201,
+				InputBody: map[string]interface{}{
+					"file": map[string]interface{}{
+						"filename":     "large.bin",
+						"size":         len(content),
+						"content_type": "application/octet-stream",
+						"sha256":       hex.EncodeToString(sum[:]),
+					},
+				},
+			},
+			{Status: 400},
+		},
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "large.bin")
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+	server.handleMockRequest(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected file over the body limit to match by size and hash, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHandleMockRequestNegotiation(t *testing.T) {
+	server := setupTestServer(t)
+	server.responses["/report"] = mock.Response{
+		Method: "GET",
+		Responses: []mock.ResponseConfig{
+			{
+				Status: 200,
+				Representations: []mock.Representation{
+					{ContentType: "application/json", Body: map[string]interface{}{"total": 3}},
+					{ContentType: "application/xml", Body: "<report><total>3</total></report>"},
+					{ContentType: "application/x-protobuf", BodyBase64: "CAM="},
+				},
+				Encodings: []string{"gzip", "br"},
+			},
+		},
+	}
+
+	get := func(accept, acceptEncoding string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/report", nil)
+		req.Header.Set("Accept", accept)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rr := httptest.NewRecorder()
+		server.handleMockRequest(rr, req)
+		return rr
+	}
+
+	rr := get("application/xml", "")
+	if rr.Header().Get("Content-Type") != "application/xml" || rr.Body.String() != "<report><total>3</total></report>" {
+		t.Errorf("Expected XML representation, got %s %q", rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+
+	rr = get("application/x-protobuf", "")
+	if !bytes.Equal(rr.Body.Bytes(), []byte{0x08, 0x03}) {
+		t.Errorf("Expected protobuf bytes, got %v", rr.Body.Bytes())
+	}
+
+	rr = get("image/png", "")
+	if rr.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected 406, got %d", rr.Code)
+	}
+
+	rr = get("", "gzip")
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip encoding, got %q", rr.Header().Get("Content-Encoding"))
+	}
+	reader, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("Failed to read gzip body: %v", err)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(reader).Decode(&body); err != nil || body["total"] != float64(3) {
+		t.Errorf("Expected decompressed JSON, got %v %v", body, err)
+	}
+	if vary := rr.Header().Values("Vary"); len(vary) != 2 {
+		t.Errorf("Expected Vary on Accept and Accept-Encoding, got %v", vary)
+	}
+
+	rr = get("", "deflate")
+	if rr.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected uncompressed response, got %q", rr.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	responses := map[string]mock.Response{
+		"/users": {
+			Method:    "GET",
+			Responses: []mock.ResponseConfig{{Status: 200, Body: map[string]interface{}{"id": 1}}},
+		},
+		"/login": {
+			Method: "POST",
+			Responses: []mock.ResponseConfig{
+				{Status: 200, InputBody: map[string]interface{}{"user": "ada"}},
+				{Status: 401, InputBody: map[string]interface{}{"user": "eve"}},
+			},
+		},
+	}
+	reloads := 0
+	reload := func() (map[string]mock.Response, error) {
+		reloads++
+		if reloads > 1 {
+			return nil, fmt.Errorf("broken mock file")
+		}
+		return map[string]mock.Response{"/users": responses["/users"]}, nil
+	}
+
+	server, err := New(responses, "", WithLogger(zaptest.NewLogger(t)), WithMetrics("/metrics"), WithReload(reload))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := server.Handler()
+	serve := func(method, path, body string) int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr.Code
+	}
+
+	serve("GET", "/users", "")
+	serve("POST", "/login", `{"user":"grace"}`)
+	serve("GET", "/missing", "")
+	serve("GET", "/endpoints", "")
+	if status := serve("POST", "/__admin/reload", ""); status != http.StatusOK {
+		t.Errorf("Expected reload to succeed, got %d", status)
+	}
+	if status := serve("POST", "/__admin/reload", ""); status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected reload to fail, got %d", status)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected metrics to be served, got %d", rr.Code)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		`gomock_http_requests_total{endpoint="/users",method="GET",outcome="matched",status="200"} 1`,
+		`gomock_http_requests_total{endpoint="/login",method="POST",outcome="fallback",status="401"} 1`,
+		`gomock_http_requests_total{endpoint="none",method="GET",outcome="unmatched",status="404"} 1`,
+		`gomock_http_requests_total{endpoint="/endpoints",method="GET",outcome="internal",status="200"} 1`,
+		`gomock_http_request_duration_seconds_count{endpoint="/users",method="GET",outcome="matched",status="200"} 1`,
+		`gomock_reloads_total{result="success"} 1`,
+		`gomock_reloads_total{result="failure"} 1`,
+		`gomock_endpoints_loaded 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	server := setupTestServer(t)
+	WithTracing(provider)(server)
+	handler := server.routes()
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("X-Trace-Id"); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the incoming trace id to be echoed, got %q", got)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /users" {
+		t.Errorf("Expected span name GET /users, got %q", span.Name)
+	}
+	if span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the span to continue the incoming trace, got parent %s", span.Parent.SpanID())
+	}
+	attrs := make(map[string]string)
+	for _, attr := range span.Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	for key, want := range map[string]string{
+		"gomock.endpoint":             "/users",
+		"gomock.outcome":              "matched",
+		"gomock.response.status":      "200",
+		"gomock.response.description": "Success response",
+		"http.response.status_code":   "200",
+	} {
+		if attrs[key] != want {
+			t.Errorf("Expected attribute %s=%s, got %q", key, want, attrs[key])
+		}
+	}
+}
+
+func TestBodyLogging(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	server := setupTestServer(t)
+	server.logger = zap.New(core)
+	server.journal = journal.New(0)
+	WithBodyLogging(logging.BodyOptions{RedactFields: []string{"email"}})(server)
+
+	req := httptest.NewRequest("POST", "/create-user", strings.NewReader(`{"name":"Test User","email":"test@example.com"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	server.routes().ServeHTTP(httptest.NewRecorder(), req)
+
+	incoming := logs.FilterMessage("Incoming request").All()
+	completed := logs.FilterMessage("Request completed").All()
+	if len(incoming) != 1 || len(completed) != 1 {
+		t.Fatalf("Expected one request logged, got %d and %d entries", len(incoming), len(completed))
+	}
+
+	fields := incoming[0].ContextMap()
+	if body := fields["request_body"].(string); strings.Contains(body, "test@example.com") || !strings.Contains(body, "Test User") {
+		t.Errorf("Expected the email to be redacted from the request body, got %s", body)
+	}
+	if headers := fields["headers"].(map[string]string); headers["Authorization"] != logging.Redacted {
+		t.Errorf("Expected the Authorization header to be redacted, got %v", headers)
+	}
+	if body := completed[0].ContextMap()["response_body"].(string); !strings.Contains(body, "Success") {
+		t.Errorf("Expected the response body to be logged, got %s", body)
+	}
+
+	// The journal applies the same rules
+	entries := server.journal.Entries()
+	if len(entries) != 1 || strings.Contains(entries[0].Body, "test@example.com") || entries[0].Headers.Get("Authorization") != logging.Redacted {
+		t.Errorf("Expected a redacted journal entry, got %+v", entries)
+	}
+}
+
+func TestJournalRedaction(t *testing.T) {
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	req := httptest.NewRequest("POST", "/create-user", strings.NewReader(`{"name":"Test User"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	server.routes().ServeHTTP(httptest.NewRecorder(), req)
+
+	// Sensitive headers are redacted even without body logging
+	entries := server.journal.Entries()
+	if len(entries) != 1 || entries[0].Headers.Get("Authorization") != logging.Redacted || entries[0].Body != `{"name":"Test User"}` {
+		t.Errorf("Expected a journal entry with redacted headers, got %+v", entries)
+	}
+
+	WithRedaction(logging.BodyOptions{RedactFields: []string{"name"}})(server)
+	req = httptest.NewRequest("POST", "/create-user", strings.NewReader(`{"name":"Test User"}`))
+	server.routes().ServeHTTP(httptest.NewRecorder(), req)
+	if entries = server.journal.Entries(); len(entries) != 2 || strings.Contains(entries[1].Body, "Test User") {
+		t.Errorf("Expected the configured fields to be redacted, got %+v", entries)
+	}
+}
+
+func TestStop(t *testing.T) {
+	// serve runs the server's routes on a random port
+	serve := func(server *Server) string {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		server.server = &http.Server{Handler: server.routes()}
+		go server.server.Serve(listener)
+		return listener.Addr().String()
+	}
+
+	t.Run("Drains WebSockets", func(t *testing.T) {
+		server := setupTestServer(t)
+		server.shutdownCh = make(chan struct{})
+		server.responses["/ws"] = mock.Response{
+			Type:      mock.TypeWebSocket,
+			WebSocket: &mock.WebSocketConfig{},
+		}
+		addr := serve(server)
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		closed := make(chan error, 1)
+		go func() {
+			_, _, err := conn.ReadMessage()
+			closed <- err
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Stop(ctx); err != nil {
+			t.Errorf("Expected a graceful stop, got %v", err)
+		}
+		if err := <-closed; !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+			t.Errorf("Expected a going away close frame, got %v", err)
+		}
+	})
+
+	t.Run("Forced after the deadline", func(t *testing.T) {
+		server := setupTestServer(t)
+		server.shutdownCh = make(chan struct{})
+		delay := 0
+		server.responses["/events"] = mock.Response{
+			Method: "GET",
+			Responses: []mock.ResponseConfig{
+				{
+					Status: 200,
+					Stream: &mock.StreamConfig{
+						Type:    mock.StreamSSE,
+						DelayMS: 5000,
+						Events:  []mock.StreamEvent{{Data: "one", DelayMS: &delay}, {Data: "two"}},
+					},
+				},
+			},
+		}
+		addr := serve(server)
+
+		resp, err := http.Get("http://" + addr + "/events")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := server.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the deadline to pass, got %v", err)
+		}
+		if _, err := io.ReadAll(resp.Body); err == nil {
+			t.Error("Expected the stream to be cut off")
+		}
+	})
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	server.journal.Record(journal.Entry{Method: "GET", Path: "/users", Status: 200})
+	server.setScenarioState("checkout", "paid")
+	if err := server.SaveState(path); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+
+	restored := setupTestServer(t)
+	restored.journal = journal.New(0)
+	if err := restored.LoadState(path); err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if entries := restored.journal.Entries(); len(entries) != 1 || entries[0].Path != "/users" {
+		t.Errorf("Expected the journal to be restored, got %+v", entries)
+	}
+	if state := restored.scenarioState("checkout"); state != "paid" {
+		t.Errorf("Expected scenario state paid, got %q", state)
+	}
+
+	// A missing state file starts from scratch
+	if err := restored.LoadState(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Expected a missing state file to be ignored, got %v", err)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	responses := map[string]mock.Response{
+		"/users": {Method: "GET", Responses: []mock.ResponseConfig{{Status: 200}}},
+	}
+	broken := false
+	reload := func() (map[string]mock.Response, error) {
+		if broken {
+			return nil, fmt.Errorf("broken mock file")
+		}
+		return responses, nil
+	}
+
+	server, err := New(responses, "8080", WithLogger(zaptest.NewLogger(t)), WithReload(reload), WithPrefix("/_mock/"))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := server.Handler()
+	serve := func(method, path string, out interface{}) int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		if out != nil {
+			json.Unmarshal(rr.Body.Bytes(), out)
+		}
+		return rr.Code
+	}
+
+	if status := serve("GET", "/_mock/health", nil); status != http.StatusOK {
+		t.Errorf("Expected healthy, got %d", status)
+	}
+	if status := serve("GET", "/__health", nil); status != http.StatusNotFound {
+		t.Errorf("Expected the default prefix to be free for mocks, got %d", status)
+	}
+
+	var ready admin.ReadyResponse
+	if status := serve("GET", "/_mock/ready", &ready); status != http.StatusOK || !ready.Ready || ready.Endpoints != 1 {
+		t.Errorf("Expected ready with 1 endpoint, got %d %+v", status, ready)
+	}
+
+	// A failed reload makes the server unready until a reload succeeds
+	broken = true
+	serve("POST", "/_mock/admin/reload", nil)
+	ready = admin.ReadyResponse{}
+	if status := serve("GET", "/_mock/ready", &ready); status != http.StatusServiceUnavailable || ready.Ready || !strings.Contains(ready.Error, "broken mock file") {
+		t.Errorf("Expected unready after a failed reload, got %d %+v", status, ready)
+	}
+	broken = false
+	serve("POST", "/_mock/admin/reload", nil)
+	if status := serve("GET", "/_mock/ready", nil); status != http.StatusOK {
+		t.Errorf("Expected ready after a successful reload, got %d", status)
+	}
+
+	var info admin.InfoResponse
+	if status := serve("GET", "/_mock/info", &info); status != http.StatusOK {
+		t.Fatalf("Expected info, got %d", status)
+	}
+	if info.Version == "" || info.Files != 1 || info.Endpoints != 1 || info.Uptime == "" {
+		t.Errorf("Unexpected info %+v", info)
+	}
+	if info.Config.Port != "8080" || info.Config.ReservedPrefix != "/_mock/" || !info.Config.Reload || info.Config.Tracing {
+		t.Errorf("Unexpected config summary %+v", info.Config)
+	}
+
+	// Probes are not journaled
+	if entries := server.journal.Entries(); len(entries) != 1 || entries[0].Path != "/__health" {
+		t.Errorf("Expected only the unmatched request to be journaled, got %+v", entries)
+	}
+
+	// Load balancers are told to stop sending traffic on shutdown
+	server.Stop(context.Background())
+	if status := serve("GET", "/_mock/ready", nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected unready while shutting down, got %d", status)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	ts := httptest.NewServer(server.routes())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/__dashboard/")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(page), "app.js") {
+		t.Errorf("Expected the dashboard page, got %d", resp.StatusCode)
+	}
+
+	// Completed requests are streamed with the response that answered them
+	stream, err := http.Get(ts.URL + "/__admin/requests/stream")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer stream.Body.Close()
+	if stream.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", stream.Header.Get("Content-Type"))
+	}
+	events := bufio.NewReader(stream.Body)
+
+	put := func(body string) int {
+		req, _ := http.NewRequest("PUT", ts.URL+"/__admin/forced", strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := put(`{"path": "/users", "index": 2}`); status != http.StatusOK {
+		t.Fatalf("Expected the response to be forced, got %d", status)
+	}
+	if status := put(`{"path": "/users", "index": 5}`); status != http.StatusBadRequest {
+		t.Errorf("Expected an unknown response to be rejected, got %d", status)
+	}
+	if status := put(`{"path": "/missing", "index": 0}`); status != http.StatusNotFound {
+		t.Errorf("Expected an unknown path to be rejected, got %d", status)
+	}
+
+	resp, err = http.Get(ts.URL + "/users")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected the forced 403, got %d", resp.StatusCode)
+	}
+
+	var data string
+	for data == "" {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read the stream: %v", err)
+		}
+		if rest, ok := strings.CutPrefix(line, "data: "); ok {
+			data = strings.TrimSpace(rest)
+		}
+	}
+	var entry journal.Entry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("Invalid event %q: %v", data, err)
+	}
+	if entry.Path != "/users" || entry.Status != http.StatusForbidden || entry.Outcome != outcomeForced || entry.Response != "Forbidden response" {
+		t.Errorf("Unexpected streamed entry %+v", entry)
+	}
+
+	// Clearing goes back to matching
+	req, _ := http.NewRequest("DELETE", ts.URL+"/__admin/forced?path=/users", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	resp, _ = http.Get(ts.URL + "/users")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 after clearing, got %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	server.responses["/search"] = mock.Response{
+		Method:    "GET",
+		RateLimit: &mock.RateLimitConfig{Limit: 2, WindowMS: 60000, Key: "header:X-Api-Key"},
+		Responses: []mock.ResponseConfig{{Status: 200, Body: map[string]interface{}{"results": []interface{}{}}}},
+	}
+	server.responses["/quota"] = mock.Response{
+		Method: "GET",
+		RateLimit: &mock.RateLimitConfig{
+			Algorithm: mock.RateLimitFixedWindow,
+			Limit:     1,
+			WindowMS:  60000,
+			Response:  &mock.ResponseConfig{Body: map[string]interface{}{"error": "quota exceeded"}},
+		},
+		Responses: []mock.ResponseConfig{{Status: 200}},
+	}
+	handler := server.routes()
+	serve := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := serve("/search", "key-1"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(1-i) {
+			t.Fatalf("Expected request %d to be allowed, got %d remaining %s", i, rr.Code, rr.Header().Get("X-RateLimit-Remaining"))
+		}
+	}
+	rr := serve("/search", "key-1")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "30" || rr.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("Expected 429 retrying after 30s, got %d %v", rr.Code, rr.Header())
+	}
+
+	// Every API key has its own limit
+	if rr := serve("/search", "key-2"); rr.Code != http.StatusOK {
+		t.Errorf("Expected another key to be allowed, got %d", rr.Code)
+	}
+
+	// Limit responses can be configured
+	serve("/quota", "")
+	rr = serve("/quota", "")
+	if rr.Code != http.StatusTooManyRequests || !strings.Contains(rr.Body.String(), "quota exceeded") {
+		t.Errorf("Expected the configured 429, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	entries := server.journal.Entries()
+	if last := entries[len(entries)-1]; last.Outcome != outcomeRateLimited {
+		t.Errorf("Expected the journal to record the rate limit, got %+v", last)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	server := setupTestServer(t)
+	server.journal = journal.New(0)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	server.responses["/orders"] = mock.Response{
+		Method: "GET",
+		Auth:   &mock.AuthConfig{Type: mock.AuthBearer, Secret: string(secret), Scopes: []string{"orders:read"}},
+		Responses: []mock.ResponseConfig{
+			{Status: 200, Body: map[string]interface{}{"owner": "{{claims.sub}}", "orders": []interface{}{}}},
+			{
+				Status:      200,
+				InputClaims: map[string]interface{}{"role": "admin"},
+				Headers:     map[string]string{"X-Role": "{{claims.role}}"},
+				Body:        map[string]interface{}{"owner": "all"},
+			},
+		},
+	}
+	server.responses["/admin/orders"] = mock.Response{
+		Method: "GET",
+		Auth:   &mock.AuthConfig{Type: mock.AuthBearer, Secret: string(secret)},
+		Responses: []mock.ResponseConfig{
+			{Status: 200, InputClaims: map[string]interface{}{"role": "admin"}, Body: map[string]interface{}{"owner": "all"}},
+		},
+	}
+	server.responses["/reports"] = mock.Response{
+		Method: "GET",
+		Auth: &mock.AuthConfig{
+			Type:         mock.AuthAPIKey,
+			Keys:         []string{"key-1"},
+			Unauthorized: &mock.ResponseConfig{Body: map[string]interface{}{"error": "API key required"}},
+		},
+		Responses: []mock.ResponseConfig{{Status: 200}},
+	}
+	handler := server.routes()
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for name := range header {
+			req.Header.Set(name, header.Get(name))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	bearer := func(claims map[string]interface{}) http.Header {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, nil)
+		if err != nil {
+			t.Fatalf("Failed to create signer: %v", err)
+		}
+		token, err := jwt.Signed(signer).Claims(claims).Serialize()
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+
+	rr := serve("/orders", nil)
+	if rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") != `Bearer realm="gomock"` {
+		t.Errorf("Expected 401 with a challenge, got %d %v", rr.Code, rr.Header())
+	}
+	if rr := serve("/orders", http.Header{"Authorization": {"Bearer not-a-token"}}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an invalid token, got %d", rr.Code)
+	}
+	rr = serve("/orders", bearer(map[string]interface{}{"sub": "user-1", "scope": "orders:write"}))
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Header().Get("WWW-Authenticate"), "insufficient_scope") {
+		t.Errorf("Expected 403 for a missing scope, got %d %v", rr.Code, rr.Header())
+	}
+	entries := server.journal.Entries()
+	if last := entries[len(entries)-1]; last.Outcome != outcomeForbidden {
+		t.Errorf("Expected the journal to record the refusal, got %+v", last)
+	}
+
+	// Claims are exposed to templates and matchers
+	rr = serve("/orders", bearer(map[string]interface{}{"sub": "user-1", "scope": "orders:read"}))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"owner":"user-1"`) {
+		t.Errorf("Expected the owner from the token, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = serve("/orders", bearer(map[string]interface{}{"sub": "user-2", "scope": "orders:read", "role": "admin"}))
+	if !strings.Contains(rr.Body.String(), `"owner":"all"`) || rr.Header().Get("X-Role") != "admin" {
+		t.Errorf("Expected the admin response, got %s %v", rr.Body.String(), rr.Header())
+	}
+
+	// Responses are never served to credentials lacking the claims they require
+	if rr := serve("/admin/orders", bearer(map[string]interface{}{"sub": "user-1"})); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without the admin role, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve("/admin/orders", bearer(map[string]interface{}{"sub": "user-2", "role": "admin"})); rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the admin role, got %d", rr.Code)
+	}
+
+	rr = serve("/reports", http.Header{"X-Api-Key": {"key-2"}})
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "API key required") {
+		t.Errorf("Expected the configured 401, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve("/reports", http.Header{"X-Api-Key": {"key-1"}}); rr.Code != http.StatusOK {
+		t.Errorf("Expected a valid key to be accepted, got %d", rr.Code)
+	}
+}
+
+func TestStartBindFailure(t *testing.T) {
+	// A free port for HTTP and a taken one for HTTPS
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	httpPort := strconv.Itoa(free.Addr().(*net.TCPAddr).Port)
+	free.Close()
+	taken, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer taken.Close()
+
+	server := setupTestServer(t)
+	server.port = httpPort
+	server.tlsPort = strconv.Itoa(taken.Addr().(*net.TCPAddr).Port)
+	server.tlsConfig = &tls.Config{}
+
+	done := make(chan error, 1)
+	go func() { done <- server.Start() }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "HTTPS") {
+			t.Fatalf("Expected the HTTPS bind error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Start to fail when a port is taken")
+	}
+
+	// The HTTP port was released rather than left serving
+	listener, err := net.Listen("tcp", ":"+httpPort)
+	if err != nil {
+		t.Fatalf("Expected the HTTP port to be free, got %v", err)
+	}
+	listener.Close()
+}
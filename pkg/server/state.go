@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"go.uber.org/zap"
+)
+
+// state is what the server keeps across restarts: the request journal,
+// scenario states and the items of resource endpoints
+type state struct {
+	Journal   []journal.Entry                     `json:"journal"`
+	Scenarios map[string]string                   `json:"scenarios,omitempty"`
+	Resources map[string][]map[string]interface{} `json:"resources,omitempty"`
+}
+
+// SaveState writes the journal, scenario states and resource items to a JSON
+// file so a later run can pick up where this one stopped
+func (s *Server) SaveState(path string) error {
+	st := state{
+		Journal:   s.journal.Entries(),
+		Resources: make(map[string][]map[string]interface{}),
+	}
+	s.scenariosMu.Lock()
+	if len(s.scenarios) > 0 {
+		st.Scenarios = make(map[string]string, len(s.scenarios))
+		for name, value := range s.scenarios {
+			st.Scenarios[name] = value
+		}
+	}
+	s.scenariosMu.Unlock()
+
+	// Only resources that were used have a store; the others are still at
+	// their seed data
+	s.storesMu.Lock()
+	for base, store := range s.stores {
+		st.Resources[base] = store.Items()
+	}
+	s.storesMu.Unlock()
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %v", err)
+	}
+
+	// Write through a temporary file so a crash never leaves half a file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+
+	s.logger.Info("State saved",
+		zap.String("path", path),
+		zap.Int("requests", len(st.Journal)),
+		zap.Int("resources", len(st.Resources)),
+	)
+	return nil
+}
+
+// LoadState restores state saved by SaveState. A missing file is not an
+// error, and resources that are no longer mocked are skipped.
+func (s *Server) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state: %v", err)
+	}
+
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("invalid state file %s: %v", path, err)
+	}
+
+	s.journal.Restore(st.Journal)
+	for name, value := range st.Scenarios {
+		s.setScenarioState(name, value)
+	}
+	responses := s.mocks()
+	for base, items := range st.Resources {
+		m, ok := responses[base]
+		if !ok || !m.IsResource() {
+			s.logger.Warn("Skipping saved resource that is no longer mocked", zap.String("resource", base))
+			continue
+		}
+		s.resourceStore(base, &m).Restore(items)
+	}
+
+	s.logger.Info("State restored",
+		zap.String("path", path),
+		zap.Int("requests", len(st.Journal)),
+		zap.Int("resources", len(st.Resources)),
+	)
+	return nil
+}
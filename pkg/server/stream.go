@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.uber.org/zap"
+)
+
+// writeStreamResponse writes a streaming response one event or chunk at a
+// time, flushing after each so clients see them as they are sent. The stream
+// stops early when the client goes away.
+func (s *Server) writeStreamResponse(w http.ResponseWriter, r *http.Request, response *mock.ResponseConfig) {
+	stream := response.Stream
+	for key, value := range response.Headers {
+		w.Header().Set(key, value)
+	}
+	if w.Header().Get("Content-Type") == "" {
+		switch stream.Type {
+		case mock.StreamSSE:
+			w.Header().Set("Content-Type", "text/event-stream")
+		case mock.StreamNDJSON:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		default:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	}
+	if stream.Type == mock.StreamSSE {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	var pieces []string
+	var delays []int
+	switch stream.Type {
+	case mock.StreamSSE:
+		for _, event := range stream.Events {
+			pieces = append(pieces, formatSSEEvent(event))
+			delays = append(delays, stream.Delay(event.DelayMS))
+		}
+	default:
+		for _, chunk := range stream.Chunks {
+			piece, err := formatChunk(stream.Type, chunk.Data)
+			if err != nil {
+				s.logger.Error("Failed to encode stream chunk",
+					zap.String("path", r.URL.Path),
+					zap.Error(err),
+				)
+				return
+			}
+			pieces = append(pieces, piece)
+			delays = append(delays, stream.Delay(chunk.DelayMS))
+		}
+	}
+
+	flusher, _ := w.(http.Flusher)
+	for i, piece := range pieces {
+		if delays[i] > 0 {
+			timer := time.NewTimer(time.Duration(delays[i]) * time.Millisecond)
+			select {
+			case <-r.Context().Done():
+				timer.Stop()
+				s.logger.Debug("Client left during stream",
+					zap.String("path", r.URL.Path),
+					zap.Int("sent", i),
+				)
+				return
+			case <-timer.C:
+			}
+		}
+		if _, err := fmt.Fprint(w, piece); err != nil {
+			s.logger.Debug("Failed to write stream", zap.Error(err))
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// formatSSEEvent encodes an event in the text/event-stream format. Multi-line
+// data is split over several data fields.
+func formatSSEEvent(event mock.StreamEvent) string {
+	var b strings.Builder
+	if event.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", event.ID)
+	}
+	if event.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event.Event)
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", event.Retry)
+	}
+	data, ok := event.Data.(string)
+	if !ok {
+		encoded, _ := json.Marshal(event.Data)
+		data = string(encoded)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// formatChunk encodes a chunk. NDJSON chunks are always JSON lines, chunked
+// streams send strings verbatim.
+func formatChunk(streamType string, data interface{}) (string, error) {
+	if text, ok := data.(string); ok && streamType == mock.StreamChunked {
+		return text, nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	if streamType == mock.StreamNDJSON {
+		return string(encoded) + "\n", nil
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"github.com/sachin-duhan/gomock/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// traceIDHeader echoes the trace id of a request so clients can look it up
+const traceIDHeader = "X-Trace-Id"
+
+// WithTracing records a span for every HTTP request and gRPC call through the
+// provider. Incoming traceparent headers and metadata are honored so the spans
+// join the caller's trace.
+func WithTracing(provider trace.TracerProvider) Option {
+	return func(s *Server) {
+		s.tracer = provider.Tracer("github.com/sachin-duhan/gomock/pkg/server")
+		s.propagator = tracing.Propagator()
+	}
+}
+
+// startSpan starts the server span of a request, continuing the trace of the
+// incoming headers, and echoes its trace id in the response headers
+func (s *Server) startSpan(w http.ResponseWriter, r *http.Request) (*http.Request, trace.Span) {
+	ctx := s.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := s.tracer.Start(ctx, r.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("network.protocol.version", r.Proto),
+			attribute.String("client.address", r.RemoteAddr),
+		),
+	)
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		w.Header().Set(traceIDHeader, sc.TraceID().String())
+	}
+	return r.WithContext(ctx), span
+}
+
+// endSpan names the span after the endpoint that answered and records how
+// the response was selected
+func (s *Server) endSpan(span trace.Span, r *http.Request, status int, info *requestInfo) {
+	defer span.End()
+
+	endpoint := info.endpoint
+	if info.outcome == "" && r.Pattern != "" && r.Pattern != "/" {
+		endpoint = r.Pattern
+	}
+	if endpoint != "" {
+		span.SetName(r.Method + " " + endpoint)
+		span.SetAttributes(attribute.String("gomock.endpoint", endpoint))
+	}
+	if info.outcome != "" {
+		span.SetAttributes(attribute.String("gomock.outcome", info.outcome))
+	}
+	annotateResponse(span, info.response)
+
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// annotateResponse records the mock response selected for a request on its
+// span
+func annotateResponse(span trace.Span, response *mock.ResponseConfig) {
+	if response == nil {
+		return
+	}
+	span.SetAttributes(attribute.Int("gomock.response.status", response.Status))
+	if response.Description != "" {
+		span.SetAttributes(attribute.String("gomock.response.description", response.Description))
+	}
+	if response.Scenario != nil {
+		span.SetAttributes(attribute.String("gomock.scenario", response.Scenario.Name))
+	}
+}
+
+// setResponse records the mock response selected for the request
+func setResponse(r *http.Request, response *mock.ResponseConfig) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.response = response
+	}
+}
+
+// tracedStream carries the context of the call's span to the handlers
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ts *tracedStream) Context() context.Context {
+	return ts.ctx
+}
+
+// startGRPCSpan starts the server span of a gRPC call, continuing the trace
+// of the incoming metadata, and echoes its trace id in the response headers
+func (s *Server) startGRPCSpan(stream grpc.ServerStream, fullMethod string) (grpc.ServerStream, trace.Span) {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	ctx := s.propagator.Extract(stream.Context(), metadataCarrier(md))
+
+	name := strings.TrimPrefix(fullMethod, "/")
+	service, method, _ := strings.Cut(name, "/")
+	ctx, span := s.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		),
+	)
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		stream.SetHeader(metadata.Pairs(strings.ToLower(traceIDHeader), sc.TraceID().String()))
+	}
+	return &tracedStream{ServerStream: stream, ctx: ctx}, span
+}
+
+// endGRPCSpan records the status of a gRPC call
+func endGRPCSpan(span trace.Span, st *status.Status) {
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+	if st.Code() != grpccodes.OK {
+		span.SetStatus(codes.Error, st.Message())
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to the propagator
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
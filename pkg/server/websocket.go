@@ -0,0 +1,264 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sachin-duhan/gomock/pkg/journal"
+	"github.com/sachin-duhan/gomock/pkg/mock"
+	"go.uber.org/zap"
+)
+
+// closeGracePeriod is how long the server waits for the client to answer a
+// close frame before dropping the connection
+const closeGracePeriod = 2 * time.Second
+
+var upgrader = websocket.Upgrader{
+	// Mocks are called from arbitrary local origins
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// handleWebSocket upgrades the connection and runs the scripted conversation
+// of a WebSocket endpoint
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request, endpoint *mock.Response) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		w.Header().Set("Upgrade", "websocket")
+		s.writeResourceError(w, http.StatusUpgradeRequired, "WebSocket upgrade required")
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		s.logger.Error("WebSocket upgrade failed",
+			zap.String("path", r.URL.Path),
+			zap.Error(err),
+		)
+		return
+	}
+
+	session := &wsSession{
+		server:   s,
+		request:  r,
+		endpoint: endpoint,
+		conn:     conn,
+		entryID:  journalEntryID(r),
+		done:     make(chan struct{}),
+	}
+	s.logger.Info("WebSocket connected", zap.String("path", r.URL.Path))
+	s.sessions.Add(1)
+	defer s.sessions.Done()
+	session.run()
+	s.logger.Info("WebSocket disconnected", zap.String("path", r.URL.Path))
+}
+
+// wsSession is a single WebSocket connection to a mock endpoint
+type wsSession struct {
+	server   *Server
+	request  *http.Request
+	endpoint *mock.Response
+	conn     *websocket.Conn
+	entryID  int64
+
+	writeMu   sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// run sends the scripted messages and answers incoming messages until the
+// connection closes
+func (ws *wsSession) run() {
+	defer ws.conn.Close()
+	defer close(ws.done)
+
+	// Clients are told the server is going away when it shuts down
+	go func() {
+		select {
+		case <-ws.server.shutdownCh:
+			ws.closeAfter(&mock.CloseFrame{Code: websocket.CloseGoingAway, Reason: "server shutting down"})
+		case <-ws.done:
+		}
+	}()
+
+	if config := ws.endpoint.WebSocket; config != nil {
+		var sent sync.WaitGroup
+		for _, msg := range config.OnConnect {
+			if msg.IntervalMS == 0 {
+				sent.Add(1)
+			}
+			go ws.script(msg, &sent)
+		}
+		if config.Close != nil {
+			// Scheduled closes count from the moment the one-off messages
+			// are out so they are never cut off
+			go func(frame *mock.CloseFrame) {
+				sent.Wait()
+				ws.closeAfter(frame)
+			}(config.Close)
+		}
+	}
+
+	for {
+		messageType, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				ws.record(journal.DirectionIn, "close", closeErr.Text, closeErr.Code)
+			}
+			return
+		}
+		ws.record(journal.DirectionIn, messageTypeName(messageType), string(data), 0)
+		ws.reply(data)
+	}
+}
+
+// script sends a scripted message after its delay, repeating it when it has
+// an interval
+func (ws *wsSession) script(msg mock.ScriptedMessage, sent *sync.WaitGroup) {
+	if !ws.wait(msg.DelayMS) {
+		if msg.IntervalMS == 0 {
+			sent.Done()
+		}
+		return
+	}
+	ws.send(msg.Body)
+	if msg.IntervalMS == 0 {
+		sent.Done()
+		return
+	}
+
+	for i := 1; msg.Repeat == 0 || i < msg.Repeat; i++ {
+		if !ws.wait(msg.IntervalMS) {
+			return
+		}
+		ws.send(msg.Body)
+	}
+}
+
+// reply answers an incoming message with the response whose input_body
+// matches it, falling back to the first response without an input_body
+func (ws *wsSession) reply(data []byte) {
+	var input interface{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		input = string(data)
+	}
+
+	response := ws.endpoint.MatchInput(input)
+	if response == nil {
+		for i := range ws.endpoint.Responses {
+			if ws.endpoint.Responses[i].InputBody == nil {
+				response = &ws.endpoint.Responses[i]
+				break
+			}
+		}
+	}
+	if response == nil {
+		ws.server.logger.Debug("No WebSocket reply for message",
+			zap.String("path", ws.request.URL.Path),
+			zap.ByteString("message", data),
+		)
+		return
+	}
+
+	body := response.Body
+	if response.IsGenerated() {
+		generated, err := ws.server.generateBody(ws.request, response)
+		if err != nil {
+			ws.server.logger.Error("Failed to generate WebSocket reply",
+				zap.String("path", ws.request.URL.Path),
+				zap.Error(err),
+			)
+			return
+		}
+		body = generated
+	}
+	ws.send(body)
+
+	if response.Close != nil {
+		// The read loop keeps answering messages until the close is sent
+		go ws.closeAfter(response.Close)
+	}
+}
+
+// send writes a body as a text message. Strings are sent verbatim and other
+// values as JSON.
+func (ws *wsSession) send(body interface{}) {
+	data, ok := body.(string)
+	if !ok {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			ws.server.logger.Error("Failed to encode WebSocket message", zap.Error(err))
+			return
+		}
+		data = string(encoded)
+	}
+
+	ws.writeMu.Lock()
+	err := ws.conn.WriteMessage(websocket.TextMessage, []byte(data))
+	ws.writeMu.Unlock()
+	if err != nil {
+		ws.server.logger.Debug("Failed to write WebSocket message", zap.Error(err))
+		return
+	}
+	ws.record(journal.DirectionOut, "text", data, 0)
+}
+
+// closeAfter sends the close frame after its delay and waits briefly for the
+// client to acknowledge it
+func (ws *wsSession) closeAfter(frame *mock.CloseFrame) {
+	if !ws.wait(frame.AfterMS) {
+		return
+	}
+	ws.closeOnce.Do(func() {
+		message := websocket.FormatCloseMessage(frame.Code, frame.Reason)
+		if err := ws.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+			ws.server.logger.Debug("Failed to write WebSocket close frame", zap.Error(err))
+		}
+		ws.record(journal.DirectionOut, "close", frame.Reason, frame.Code)
+		ws.conn.SetReadDeadline(time.Now().Add(closeGracePeriod))
+	})
+}
+
+// wait sleeps for the given milliseconds and reports whether the connection
+// is still open afterwards
+func (ws *wsSession) wait(ms int) bool {
+	if ms <= 0 {
+		select {
+		case <-ws.done:
+			return false
+		default:
+			return true
+		}
+	}
+	timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-ws.done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (ws *wsSession) record(direction, messageType, data string, closeCode int) {
+	if ws.entryID == 0 || ws.server.journal == nil {
+		return
+	}
+	ws.server.journal.AddMessage(ws.entryID, journal.Message{
+		Direction: direction,
+		Type:      messageType,
+		Data:      string(ws.server.journalRedactor().RedactBody([]byte(data))),
+		CloseCode: closeCode,
+	})
+}
+
+func messageTypeName(messageType int) string {
+	if messageType == websocket.BinaryMessage {
+		return "binary"
+	}
+	return "text"
+}
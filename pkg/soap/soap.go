@@ -0,0 +1,153 @@
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// SOAP versions
+const (
+	Version11 = "1.1"
+	Version12 = "1.2"
+)
+
+// Envelope namespaces of the SOAP versions
+const (
+	Namespace11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	Namespace12 = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// Request is an XML request body, usually a SOAP envelope
+type Request struct {
+	// Action is the SOAPAction header, or the action parameter of the
+	// Content-Type for SOAP 1.2
+	Action string
+	Body   string
+	doc    *xmlquery.Node
+}
+
+// IsXMLContentType reports whether a Content-Type header denotes XML,
+// including SOAP 1.2 and other +xml media types
+func IsXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/xml" || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// ParseRequest reads and parses an XML request body. The body is restored so
+// it can be read again.
+func ParseRequest(r *http.Request) (*Request, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid request body")
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	doc, err := xmlquery.Parse(bytes.NewReader(body))
+	if err != nil || xmlquery.FindOne(doc, "/*") == nil {
+		return nil, fmt.Errorf("Invalid XML in request body")
+	}
+
+	req := &Request{Body: string(body), doc: doc}
+	req.Action = strings.Trim(r.Header.Get("SOAPAction"), `"`)
+	if req.Action == "" {
+		if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil {
+			req.Action = params["action"]
+		}
+	}
+	return req, nil
+}
+
+// Compile parses an XPath expression. Prefixes in the expression are
+// resolved with the given namespaces.
+func Compile(expr string, namespaces map[string]string) (*xpath.Expr, error) {
+	if len(namespaces) > 0 {
+		return xpath.CompileWithNS(expr, namespaces)
+	}
+	return xpath.Compile(expr)
+}
+
+// Matches reports whether an XPath predicate holds for the request. Boolean
+// expressions must be true, node sets must not be empty, and strings and
+// numbers must not be empty or zero.
+func (req *Request) Matches(expr *xpath.Expr) bool {
+	switch value := expr.Evaluate(xmlquery.CreateXPathNavigator(req.doc)).(type) {
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	case *xpath.NodeIterator:
+		return value.MoveNext()
+	default:
+		return false
+	}
+}
+
+// Envelope wraps body content in a SOAP envelope of the given version
+func Envelope(version, body string) string {
+	namespace := Namespace11
+	if version == Version12 {
+		namespace = Namespace12
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<soap:Envelope xmlns:soap="%s"><soap:Body>%s</soap:Body></soap:Envelope>`, namespace, body)
+}
+
+// Fault builds the body of a SOAP fault. Detail is inserted as raw XML.
+// SOAP 1.1 codes are qualified with the envelope prefix when they have none,
+// so Server becomes soap:Server; SOAP 1.2 uses Receiver and Sender.
+func Fault(version, code, message, detail string) string {
+	if code != "" && !strings.Contains(code, ":") {
+		code = "soap:" + code
+	}
+
+	var b strings.Builder
+	if version == Version12 {
+		if code == "" {
+			code = "soap:Receiver"
+		}
+		fmt.Fprintf(&b, "<soap:Fault><soap:Code><soap:Value>%s</soap:Value></soap:Code>", escape(code))
+		fmt.Fprintf(&b, `<soap:Reason><soap:Text xml:lang="en">%s</soap:Text></soap:Reason>`, escape(message))
+		if detail != "" {
+			fmt.Fprintf(&b, "<soap:Detail>%s</soap:Detail>", detail)
+		}
+		b.WriteString("</soap:Fault>")
+		return b.String()
+	}
+
+	if code == "" {
+		code = "soap:Server"
+	}
+	fmt.Fprintf(&b, "<soap:Fault><faultcode>%s</faultcode><faultstring>%s</faultstring>", escape(code), escape(message))
+	if detail != "" {
+		fmt.Fprintf(&b, "<detail>%s</detail>", detail)
+	}
+	b.WriteString("</soap:Fault>")
+	return b.String()
+}
+
+// ContentType returns the Content-Type of SOAP messages of the given version
+func ContentType(version string) string {
+	if version == Version12 {
+		return "application/soap+xml; charset=utf-8"
+	}
+	return "text/xml; charset=utf-8"
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
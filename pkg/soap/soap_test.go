@@ -0,0 +1,103 @@
+package soap
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const getUserEnvelope = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:u="urn:users">
+  <soap:Body>
+    <u:GetUser><u:id>42</u:id></u:GetUser>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestParseRequest(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/soap", strings.NewReader(getUserEnvelope))
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", `"urn:users/GetUser"`)
+
+	parsed, err := ParseRequest(req)
+	if err != nil {
+		t.Fatalf("ParseRequest failed: %v", err)
+	}
+	if parsed.Action != "urn:users/GetUser" {
+		t.Errorf("Expected unquoted action, got %q", parsed.Action)
+	}
+
+	// SOAP 1.2 carries the action in the Content-Type
+	req, _ = http.NewRequest("POST", "/soap", strings.NewReader(getUserEnvelope))
+	req.Header.Set("Content-Type", `application/soap+xml; charset=utf-8; action="urn:users/GetUser"`)
+	if parsed, err := ParseRequest(req); err != nil || parsed.Action != "urn:users/GetUser" {
+		t.Errorf("Expected action from Content-Type, got %v %v", parsed, err)
+	}
+
+	req, _ = http.NewRequest("POST", "/soap", strings.NewReader(`{"id": 42}`))
+	if _, err := ParseRequest(req); err == nil {
+		t.Error("Expected error for non-XML body")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/soap", strings.NewReader(getUserEnvelope))
+	parsed, err := ParseRequest(req)
+	if err != nil {
+		t.Fatalf("ParseRequest failed: %v", err)
+	}
+
+	tests := []struct {
+		expr       string
+		namespaces map[string]string
+		want       bool
+	}{
+		{"//u:GetUser/u:id = '42'", map[string]string{"u": "urn:users"}, true},
+		{"//u:GetUser/u:id = '7'", map[string]string{"u": "urn:users"}, false},
+		{"//*[local-name()='GetUser']", nil, true},
+		{"//*[local-name()='DeleteUser']", nil, false},
+		{"count(//*[local-name()='id'])", nil, true},
+		{"string(//*[local-name()='name'])", nil, false},
+	}
+	for _, tt := range tests {
+		expr, err := Compile(tt.expr, tt.namespaces)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.expr, err)
+		}
+		if got := parsed.Matches(expr); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestFault(t *testing.T) {
+	fault := Fault(Version11, "Client", "Unknown user", "<u:error>42</u:error>")
+	for _, want := range []string{"<faultcode>soap:Client</faultcode>", "<faultstring>Unknown user</faultstring>", "<detail><u:error>42</u:error></detail>"} {
+		if !strings.Contains(fault, want) {
+			t.Errorf("Expected %s in %s", want, fault)
+		}
+	}
+
+	fault = Fault(Version12, "", "a < b", "")
+	if !strings.Contains(fault, "<soap:Value>soap:Receiver</soap:Value>") || !strings.Contains(fault, "a &lt; b") {
+		t.Errorf("Unexpected SOAP 1.2 fault %s", fault)
+	}
+
+	envelope := Envelope(Version12, fault)
+	if !strings.Contains(envelope, Namespace12) || !strings.Contains(envelope, "<soap:Body><soap:Fault>") {
+		t.Errorf("Unexpected envelope %s", envelope)
+	}
+}
+
+func TestIsXMLContentType(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"text/xml; charset=utf-8": true,
+		"application/soap+xml":    true,
+		"application/atom+xml":    true,
+		"application/json":        false,
+		"":                        false,
+	} {
+		if got := IsXMLContentType(contentType); got != want {
+			t.Errorf("IsXMLContentType(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package tracing exports OpenTelemetry traces of the mock server over OTLP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// OTLP transport protocols
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// tracesPath is where OTLP/HTTP collectors receive traces
+const tracesPath = "/v1/traces"
+
+// Options configures the trace exporter
+type Options struct {
+	// Endpoint is the collector URL, such as http://localhost:4318 for
+	// OTLP/HTTP or http://localhost:4317 for gRPC. An http scheme sends
+	// traces without TLS.
+	Endpoint string
+
+	// Protocol is ProtocolHTTP (the default) or ProtocolGRPC
+	Protocol string
+
+	// ServiceName identifies the server in traces, "gomock" by default
+	ServiceName string
+}
+
+// NewProvider creates a tracer provider exporting spans in batches to the
+// collector. The provider must be shut down to flush the remaining spans.
+func NewProvider(ctx context.Context, opts Options) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = "gomock"
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// Propagator reads and writes W3C trace context and baggage headers
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+func newExporter(ctx context.Context, opts Options) (*otlptrace.Exporter, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: expected an http or https URL", opts.Endpoint)
+	}
+
+	switch opts.Protocol {
+	case "", ProtocolHTTP:
+		// A base URL points at the standard traces path, as with
+		// OTEL_EXPORTER_OTLP_ENDPOINT
+		if strings.TrimSuffix(endpoint.Path, "/") == "" {
+			endpoint.Path = tracesPath
+		}
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint.String()))
+	case ProtocolGRPC:
+		return otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint.String()))
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol %q, expected %s or %s", opts.Protocol, ProtocolHTTP, ProtocolGRPC)
+	}
+}
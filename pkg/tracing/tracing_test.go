@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is a stand-in OTLP collector recording the span names received
+type collector struct {
+	collectortrace.UnimplementedTraceServiceServer
+
+	mu       sync.Mutex
+	paths    []string
+	spans    []string
+	services []string
+}
+
+func (c *collector) record(path string, req *collectortrace.ExportTraceServiceRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, path)
+	for _, rs := range req.ResourceSpans {
+		for _, attr := range rs.GetResource().GetAttributes() {
+			if attr.Key == "service.name" {
+				c.services = append(c.services, attr.GetValue().GetStringValue())
+			}
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans = append(c.spans, span.Name)
+			}
+		}
+	}
+}
+
+func (c *collector) Export(_ context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	c.record("grpc", req)
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req collectortrace.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.record(r.URL.Path, &req)
+	data, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(data)
+}
+
+func (c *collector) check(t *testing.T, path, span, service string) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.paths) != 1 || c.paths[0] != path {
+		t.Errorf("Expected one export to %s, got %v", path, c.paths)
+	}
+	if len(c.spans) != 1 || c.spans[0] != span {
+		t.Errorf("Expected span %q, got %v", span, c.spans)
+	}
+	if len(c.services) != 1 || c.services[0] != service {
+		t.Errorf("Expected service %q, got %v", service, c.services)
+	}
+}
+
+func exportSpan(t *testing.T, opts Options, name string) {
+	t.Helper()
+	ctx := context.Background()
+	provider, err := NewProvider(ctx, opts)
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	_, span := provider.Tracer("test").Start(ctx, name)
+	span.End()
+	if err := provider.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+}
+
+func TestNewProviderHTTP(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	exportSpan(t, Options{Endpoint: srv.URL}, "GET /users")
+	c.check(t, "/v1/traces", "GET /users", "gomock")
+}
+
+func TestNewProviderGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	c := &collector{}
+	srv := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(srv, c)
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	exportSpan(t, Options{Endpoint: "http://" + listener.Addr().String(), Protocol: ProtocolGRPC, ServiceName: "checkout-mocks"}, "greeter.Greeter/SayHello")
+	c.check(t, "grpc", "greeter.Greeter/SayHello", "checkout-mocks")
+}
+
+func TestNewProviderInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Endpoint: "localhost:4318"},
+		{Endpoint: "http://localhost:4318", Protocol: "zipkin"},
+	} {
+		if _, err := NewProvider(context.Background(), opts); err == nil {
+			t.Errorf("Expected error for %+v", opts)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package version identifies the running build of the mock server.
+package version
+
+import "runtime/debug"
+
+// Version and Commit are set at build time with
+//
+//	go build -ldflags "-X github.com/sachin-duhan/gomock/pkg/version.Version=v1.4.0 -X github.com/sachin-duhan/gomock/pkg/version.Commit=$(git rev-parse HEAD)"
+//
+// Builds without them report the module version and the VCS revision
+// recorded by the go command, when known.
+var (
+	Version = ""
+	Commit  = ""
+)
+
+// Get returns the version and commit of the build. The version is "dev" and
+// the commit empty when neither was recorded.
+func Get() (version, commit string) {
+	version, commit = Version, Commit
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			if commit == "" && setting.Key == "vcs.revision" {
+				commit = setting.Value
+			}
+		}
+	}
+	if version == "" {
+		version = "dev"
+	}
+	return version, commit
+}
@@ -0,0 +1,17 @@
+package version
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	defer func(v, c string) { Version, Commit = v, c }(Version, Commit)
+
+	Version, Commit = "v1.4.0", "0123abc"
+	if version, commit := Get(); version != "v1.4.0" || commit != "0123abc" {
+		t.Errorf("Expected the build-time version, got %s %s", version, commit)
+	}
+
+	Version = ""
+	if version, _ := Get(); version == "" {
+		t.Error("Expected a fallback version")
+	}
+}